@@ -5,12 +5,15 @@
 package clickhouse
 
 import (
+	"context"
 	"database/sql"
 	"strconv"
 	"strings"
 
 	"github.com/ClickHouse/clickhouse-go/v2" // DRIVER
 	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/drivers/explain"
+	"github.com/xo/usql/text"
 )
 
 func init() {
@@ -37,5 +40,15 @@ func init() {
 		},
 		Copy:              drivers.CopyWithInsert(func(int) string { return "?" }),
 		NewMetadataReader: NewMetadataReader,
+		Explain: func(ctx context.Context, db drivers.DB, sqlstr string, analyze bool, bind ...interface{}) (*explain.Plan, error) {
+			if analyze {
+				return nil, text.ErrNotSupported
+			}
+			lines, err := explain.QueryLines(ctx, db, "EXPLAIN "+sqlstr, bind...)
+			if err != nil {
+				return nil, err
+			}
+			return explain.ParseText(lines), nil
+		},
 	})
 }
@@ -208,6 +208,39 @@ FROM
 	return metadata.NewFunctionSet(results), nil
 }
 
+func (r MetadataReader) Settings(f metadata.Filter) (*metadata.SettingSet, error) {
+	qstr := `SELECT
+  name,
+  value,
+  IF(changed = 1, 'session', 'default') AS source,
+  description
+FROM
+  system.settings`
+	var conds []string
+	var vals []interface{}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		conds = append(conds, "name ILIKE ?")
+	}
+	rows, closeRows, err := r.query(qstr, conds, "name", vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows()
+	var results []metadata.Setting
+	for rows.Next() {
+		rec := metadata.Setting{RestartRequired: metadata.NO}
+		if err := rows.Scan(&rec.Name, &rec.Value, &rec.Source, &rec.Description); err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewSettingSet(results), nil
+}
+
 func (r MetadataReader) query(qstr string, conds []string, order string, vals ...interface{}) (*sql.Rows, func(), error) {
 	if len(conds) != 0 {
 		qstr += "\nWHERE " + strings.Join(conds, " AND ")
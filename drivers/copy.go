@@ -0,0 +1,422 @@
+package drivers
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/xo/usql/text"
+)
+
+// CopyOptions are the options for reading and writing copy data.
+type CopyOptions struct {
+	// Format is the data format, either "text" (tab-separated, with
+	// backslash escapes) or "csv".
+	Format string
+	// Delimiter is the column delimiter.
+	Delimiter rune
+	// Quote is the quote character (csv only).
+	Quote rune
+	// Null is the string representing a NULL value.
+	Null string
+	// Header indicates the data has a header line.
+	Header bool
+}
+
+// NewCopyOptions creates copy options for the format with the format's
+// default delimiter, quote, and null string.
+func NewCopyOptions(format string) (CopyOptions, error) {
+	switch format = strings.ToLower(format); format {
+	case "", "text":
+		return CopyOptions{Format: "text", Delimiter: '\t', Null: `\N`}, nil
+	case "csv":
+		return CopyOptions{Format: "csv", Delimiter: ',', Quote: '"'}, nil
+	}
+	return CopyOptions{}, fmt.Errorf(text.UnknownCopyFormat, format)
+}
+
+// NewCopyRows creates a result set that reads the copy data from r. The
+// returned func must be called after the result set is no longer needed.
+//
+// The result set's columns are named using the header line, when present,
+// otherwise the columns are named column1, column2, ... based on the number of
+// columns in the first line. Column values are either a string, or nil for
+// NULL values.
+func NewCopyRows(ctx context.Context, r io.Reader, opts CopyOptions) (*sql.Rows, func(), error) {
+	cr := &copyReader{r: bufio.NewReader(r), opts: opts}
+	src := &copySource{r: cr}
+	switch row, err := cr.Read(); {
+	case err == io.EOF:
+	case err != nil:
+		return nil, nil, err
+	case opts.Header:
+		for _, v := range row {
+			src.cols = append(src.cols, v.String)
+		}
+	default:
+		for i := range row {
+			src.cols = append(src.cols, "column"+strconv.Itoa(i+1))
+		}
+		src.first = row
+	}
+	db := sql.OpenDB(copyConnector{src: src})
+	rows, err := db.QueryContext(ctx, "")
+	if err != nil {
+		_ = db.Close()
+		return nil, nil, err
+	}
+	return rows, func() {
+		_ = rows.Close()
+		_ = db.Close()
+	}, nil
+}
+
+// CopyWriter writes copy data.
+type CopyWriter struct {
+	w    *bufio.Writer
+	opts CopyOptions
+}
+
+// NewCopyWriter creates a copy data writer.
+func NewCopyWriter(w io.Writer, opts CopyOptions) *CopyWriter {
+	return &CopyWriter{
+		w:    bufio.NewWriter(w),
+		opts: opts,
+	}
+}
+
+// WriteHeader writes a header line with the column names.
+func (cw *CopyWriter) WriteHeader(cols []string) error {
+	row := make([]sql.NullString, len(cols))
+	for i, s := range cols {
+		row[i] = sql.NullString{String: s, Valid: true}
+	}
+	return cw.Write(row)
+}
+
+// Write writes a row.
+func (cw *CopyWriter) Write(row []sql.NullString) error {
+	for i, v := range row {
+		if i != 0 {
+			if _, err := cw.w.WriteRune(cw.opts.Delimiter); err != nil {
+				return err
+			}
+		}
+		var s string
+		switch {
+		case !v.Valid:
+			s = cw.opts.Null
+		case cw.opts.Format == "csv":
+			s = cw.csvQuote(v.String)
+		default:
+			s = cw.textEscape(v.String)
+		}
+		if _, err := cw.w.WriteString(s); err != nil {
+			return err
+		}
+	}
+	_, err := cw.w.WriteString("\n")
+	return err
+}
+
+// Flush flushes any buffered data to the underlying writer.
+func (cw *CopyWriter) Flush() error {
+	return cw.w.Flush()
+}
+
+// csvQuote quotes s when it contains the delimiter, quote, or line breaks, or
+// when it is the same as the null string.
+func (cw *CopyWriter) csvQuote(s string) string {
+	if s != cw.opts.Null && !strings.ContainsAny(s, string([]rune{cw.opts.Delimiter, cw.opts.Quote, '\r', '\n'})) {
+		return s
+	}
+	q := string(cw.opts.Quote)
+	return q + strings.ReplaceAll(s, q, q+q) + q
+}
+
+// textEscape backslash escapes s.
+func (cw *CopyWriter) textEscape(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case cw.opts.Delimiter:
+			b.WriteRune('\\')
+			b.WriteRune(c)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// copyReader reads copy data.
+type copyReader struct {
+	r    *bufio.Reader
+	opts CopyOptions
+	line int
+	eof  bool
+}
+
+// readLine reads the next line, sans line ending. Returns io.EOF at the end
+// of the data, or when the end of data marker (\.) is encountered.
+func (cr *copyReader) readLine() (string, error) {
+	if cr.eof {
+		return "", io.EOF
+	}
+	s, err := cr.r.ReadString('\n')
+	switch {
+	case err == io.EOF && s == "":
+		cr.eof = true
+		return "", io.EOF
+	case err != nil && err != io.EOF:
+		return "", err
+	}
+	cr.line++
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "\n"), "\r")
+	if s == `\.` {
+		cr.eof = true
+		return "", io.EOF
+	}
+	return s, nil
+}
+
+// Read reads the next row.
+func (cr *copyReader) Read() ([]sql.NullString, error) {
+	s, err := cr.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if cr.opts.Format == "csv" {
+		return cr.parseCSV(s)
+	}
+	return cr.parseText(s), nil
+}
+
+// parseText parses a text format line.
+func (cr *copyReader) parseText(s string) []sql.NullString {
+	var row []sql.NullString
+	var raw, field strings.Builder
+	r := []rune(s)
+	push := func() {
+		v := sql.NullString{String: field.String(), Valid: true}
+		if raw.String() == cr.opts.Null {
+			v = sql.NullString{}
+		}
+		row = append(row, v)
+		raw.Reset()
+		field.Reset()
+	}
+	for i := 0; i < len(r); i++ {
+		c := r[i]
+		switch {
+		case c == cr.opts.Delimiter:
+			push()
+			continue
+		case c != '\\' || i+1 >= len(r):
+			raw.WriteRune(c)
+			field.WriteRune(c)
+			continue
+		}
+		start := i
+		i++
+		switch c = r[i]; c {
+		case 'b':
+			field.WriteRune('\b')
+		case 'f':
+			field.WriteRune('\f')
+		case 'n':
+			field.WriteRune('\n')
+		case 'r':
+			field.WriteRune('\r')
+		case 't':
+			field.WriteRune('\t')
+		case 'v':
+			field.WriteRune('\v')
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i
+			for j < len(r) && j < i+3 && '0' <= r[j] && r[j] <= '7' {
+				j++
+			}
+			n, _ := strconv.ParseUint(string(r[i:j]), 8, 8)
+			field.WriteByte(byte(n))
+			i = j - 1
+		case 'x':
+			j := i + 1
+			for j < len(r) && j < i+3 && strings.ContainsRune("0123456789abcdefABCDEF", r[j]) {
+				j++
+			}
+			if j == i+1 {
+				field.WriteRune(c)
+				break
+			}
+			n, _ := strconv.ParseUint(string(r[i+1:j]), 16, 8)
+			field.WriteByte(byte(n))
+			i = j - 1
+		default:
+			field.WriteRune(c)
+		}
+		raw.WriteString(string(r[start : i+1]))
+	}
+	push()
+	return row
+}
+
+// parseCSV parses a csv format line, reading additional lines when a quoted
+// value contains line breaks.
+func (cr *copyReader) parseCSV(s string) ([]sql.NullString, error) {
+	var row []sql.NullString
+	var field strings.Builder
+	var quoted, inQuote bool
+	push := func() {
+		v := sql.NullString{String: field.String(), Valid: true}
+		if !quoted && v.String == cr.opts.Null {
+			v = sql.NullString{}
+		}
+		row = append(row, v)
+		field.Reset()
+		quoted = false
+	}
+	line := cr.line
+	r := []rune(s)
+	for i := 0; ; i++ {
+		if i >= len(r) {
+			if !inQuote {
+				break
+			}
+			// quoted value continues on next line
+			next, err := cr.readLine()
+			switch {
+			case err == io.EOF:
+				return nil, fmt.Errorf(text.CopyUnterminatedCSVQuote, line)
+			case err != nil:
+				return nil, err
+			}
+			field.WriteRune('\n')
+			r, i = []rune(next), -1
+			continue
+		}
+		switch c := r[i]; {
+		case inQuote && c == cr.opts.Quote && i+1 < len(r) && r[i+1] == cr.opts.Quote:
+			field.WriteRune(c)
+			i++
+		case inQuote && c == cr.opts.Quote:
+			inQuote = false
+		case inQuote:
+			field.WriteRune(c)
+		case c == cr.opts.Quote:
+			inQuote, quoted = true, true
+		case c == cr.opts.Delimiter:
+			push()
+		default:
+			field.WriteRune(c)
+		}
+	}
+	push()
+	return row, nil
+}
+
+// copySource is the source for a copy data result set.
+type copySource struct {
+	r     *copyReader
+	cols  []string
+	first []sql.NullString
+}
+
+// copyConnector is a database/sql connector for a copy data result set.
+type copyConnector struct {
+	src *copySource
+}
+
+// Connect satisfies the [driver.Connector] interface.
+func (c copyConnector) Connect(context.Context) (driver.Conn, error) {
+	return copyConn{src: c.src}, nil
+}
+
+// Driver satisfies the [driver.Connector] interface.
+func (c copyConnector) Driver() driver.Driver {
+	return copyDriver{}
+}
+
+// copyDriver is a database/sql driver for copy data result sets.
+type copyDriver struct{}
+
+// Open satisfies the [driver.Driver] interface.
+func (copyDriver) Open(string) (driver.Conn, error) {
+	return nil, text.ErrNotSupported
+}
+
+// copyConn is a database/sql connection for a copy data result set.
+type copyConn struct {
+	src *copySource
+}
+
+// Prepare satisfies the [driver.Conn] interface.
+func (copyConn) Prepare(string) (driver.Stmt, error) {
+	return nil, text.ErrNotSupported
+}
+
+// Close satisfies the [driver.Conn] interface.
+func (copyConn) Close() error {
+	return nil
+}
+
+// Begin satisfies the [driver.Conn] interface.
+func (copyConn) Begin() (driver.Tx, error) {
+	return nil, text.ErrNotSupported
+}
+
+// QueryContext satisfies the [driver.QueryerContext] interface.
+func (c copyConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &copyRows{src: c.src}, nil
+}
+
+// copyRows is a database/sql result set reading copy data.
+type copyRows struct {
+	src *copySource
+}
+
+// Columns satisfies the [driver.Rows] interface.
+func (r *copyRows) Columns() []string {
+	return r.src.cols
+}
+
+// Close satisfies the [driver.Rows] interface.
+func (r *copyRows) Close() error {
+	return nil
+}
+
+// Next satisfies the [driver.Rows] interface.
+func (r *copyRows) Next(dest []driver.Value) error {
+	row := r.src.first
+	if row != nil {
+		r.src.first = nil
+	} else {
+		var err error
+		if row, err = r.src.r.Read(); err != nil {
+			return err
+		}
+	}
+	if len(row) != len(dest) {
+		return fmt.Errorf(text.CopyWrongColumnCount, r.src.r.line, len(dest), len(row))
+	}
+	for i, v := range row {
+		dest[i] = nil
+		if v.Valid {
+			dest[i] = v.String
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package drivers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+
+	"github.com/xo/dburl"
+	"github.com/xo/tblfmt"
+	"github.com/xo/usql/drivers/datadiff"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/text"
+)
+
+// dataDiffBatch is the number of differences buffered when writing the
+// differences as a table.
+const dataDiffBatch = 1000
+
+// DataDiff compares the rows of the source and destination queries (or
+// tables) by the key columns, and writes the rows that are missing from the
+// destination, the extra rows in the destination, and the changed column
+// values, followed by summary counts.
+//
+// Both queries are read sorted by the key columns and compared as they are
+// read, so only the current row of each side is held in memory. Text keys
+// are sorted in byte order when supported by the driver (see
+// [Driver.ByteOrder]), as they are compared.
+func DataDiff(ctx context.Context, w io.Writer, srcURL *dburl.URL, src DB, dstURL *dburl.URL, dst DB, srcQuery, dstQuery string, keys []string) error {
+	if len(keys) == 0 {
+		return fmt.Errorf(`\datadiff: %w`, text.ErrMissingRequiredArgument)
+	}
+	tfmt := env.Vars().PrintTimeFormat()
+	s, closeSrc, err := newDiffSide(ctx, srcURL, src, srcQuery, keys, tfmt, "source")
+	if err != nil {
+		return err
+	}
+	defer closeSrc()
+	d, closeDst, err := newDiffSide(ctx, dstURL, dst, dstQuery, keys, tfmt, "destination")
+	if err != nil {
+		return err
+	}
+	defer closeDst()
+	diff, err := datadiff.New(s, d)
+	if err != nil {
+		return err
+	}
+	// only write the differences table when there are differences
+	if diff.Peek() {
+		params := env.Vars().Print()
+		params["title"] = "Data differences"
+		if err := tblfmt.EncodeAll(w, diff, params, tblfmt.WithCount(dataDiffBatch)); err != nil {
+			return err
+		}
+		if params["format"] == "aligned" {
+			fmt.Fprintln(w)
+		}
+	}
+	if err := diff.Err(); err != nil {
+		return err
+	}
+	return diff.Summarize(w)
+}
+
+// newDiffSide queries the rows of the table or query sorted by the key
+// columns, returning the data diff side reading the rows.
+func newDiffSide(ctx context.Context, u *dburl.URL, db DB, query string, keys []string, tfmt, name string) (*datadiff.Side, func(), error) {
+	orderBy, err := dataDiffOrderBy(ctx, u, db, query, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := db.QueryContext(ctx, datadiff.Query(query, orderBy...))
+	if err != nil {
+		return nil, nil, WrapErr(u.Driver, err)
+	}
+	cols, err := Columns(u, rows)
+	if err != nil {
+		rows.Close()
+		return nil, nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		rows.Close()
+		return nil, nil, err
+	}
+	numeric := make([]bool, len(types))
+	for i, typ := range types {
+		numeric[i] = isNumeric(typ)
+	}
+	s, err := datadiff.NewSide(name, cols, numeric, keys, func() ([]sql.NullString, error) {
+		if !rows.Next() {
+			return nil, WrapErr(u.Driver, rows.Err())
+		}
+		r := make([]interface{}, len(cols))
+		for i := range r {
+			r[i] = new(interface{})
+		}
+		if err := rows.Scan(r...); err != nil {
+			return nil, WrapErr(u.Driver, err)
+		}
+		return ConvertNull(u, r, tfmt)
+	})
+	if err != nil {
+		rows.Close()
+		return nil, nil, err
+	}
+	return s, func() { rows.Close() }, nil
+}
+
+// dataDiffOrderBy returns the order by expressions for the key columns,
+// retrieving the types of the key columns when the driver can sort text in
+// byte order.
+func dataDiffOrderBy(ctx context.Context, u *dburl.URL, db DB, query string, keys []string) ([]string, error) {
+	var orderBy []string
+	d, ok := drivers[u.Driver]
+	if !ok || d.ByteOrder == nil {
+		for _, k := range keys {
+			orderBy = append(orderBy, datadiff.OrderBy(k, nil)...)
+		}
+		return orderBy, nil
+	}
+	rows, err := db.QueryContext(ctx, datadiff.Query(query)+" WHERE 1=0")
+	if err != nil {
+		return nil, WrapErr(u.Driver, err)
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		var collate func(string) string
+		if i := datadiff.ColumnIndex(columnNames(types), k); i != -1 && isText(types[i]) {
+			collate = d.ByteOrder
+		}
+		orderBy = append(orderBy, datadiff.OrderBy(k, collate)...)
+	}
+	return orderBy, nil
+}
+
+// columnNames returns the names of the columns.
+func columnNames(types []*sql.ColumnType) []string {
+	names := make([]string, len(types))
+	for i, typ := range types {
+		names[i] = typ.Name()
+	}
+	return names
+}
+
+// numericTypeRE matches numeric database type names.
+var numericTypeRE = regexp.MustCompile(`(?i)^(unsigned )?(u?(tiny|small|medium|big|huge)?int(eger)?\d*|numeric|decimal|number|float\d*|real|double|money|(small|big)?serial\d*)\b`)
+
+// isNumeric returns true when the column type is numeric.
+func isNumeric(typ *sql.ColumnType) bool {
+	if t := typ.ScanType(); t != nil {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return true
+		}
+	}
+	return numericTypeRE.MatchString(typ.DatabaseTypeName())
+}
+
+// textTypeRE matches text database type names.
+var textTypeRE = regexp.MustCompile(`(?i)(char|text|string|clob|^name$)`)
+
+// isText returns true when the column type is text.
+func isText(typ *sql.ColumnType) bool {
+	if name := typ.DatabaseTypeName(); name != "" {
+		return textTypeRE.MatchString(name)
+	}
+	t := typ.ScanType()
+	return t != nil && t.Kind() == reflect.String
+}
@@ -0,0 +1,360 @@
+// Package datadiff compares the rows of two queries by their key columns,
+// merging the rows, sorted by the key columns, as they are read.
+package datadiff
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/xo/usql/text"
+)
+
+// nameRE matches a (possibly qualified) table name.
+var nameRE = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_$]*(\.[\p{L}_][\p{L}\p{N}_$]*)*$`)
+
+// Query returns the query reading the table or query, sorted by the order by
+// expressions.
+func Query(query string, orderBy ...string) string {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	if !nameRE.MatchString(query) {
+		query = "(" + query + ") t"
+	}
+	query = "SELECT * FROM " + query
+	if len(orderBy) != 0 {
+		query += " ORDER BY " + strings.Join(orderBy, ", ")
+	}
+	return query
+}
+
+// OrderBy returns the order by expressions sorting rows by the key, in the
+// same order as the rows are compared: NULL before other values, and text
+// values by the collate func (ie, in byte order) when not nil.
+func OrderBy(key string, collate func(string) string) []string {
+	expr := key
+	if collate != nil {
+		expr = collate(key)
+	}
+	return []string{"CASE WHEN " + key + " IS NULL THEN 0 ELSE 1 END", expr}
+}
+
+// Side is one side of a data diff.
+type Side struct {
+	name string
+	cols []string
+	// numeric indicates the columns with numeric types.
+	numeric []bool
+	// keys are the indexes of the key columns, and numericKeys indicates the
+	// keys compared as numbers.
+	keys        []int
+	numericKeys []bool
+	// read reads the next row, returning nil when there are no more rows.
+	read func() ([]sql.NullString, error)
+	// row and prev are the current and previous rows.
+	row, prev []sql.NullString
+	done      bool
+	count     int64
+}
+
+// NewSide creates a data diff side (ie, "source") for the columns, finding
+// the key columns. The numeric columns are compared as numbers. Rows are read
+// with read, which returns nil when there are no more rows.
+func NewSide(name string, cols []string, numeric []bool, keys []string, read func() ([]sql.NullString, error)) (*Side, error) {
+	s := &Side{name: name, cols: cols, numeric: numeric, read: read}
+	for _, k := range keys {
+		i := ColumnIndex(cols, k)
+		if i == -1 {
+			return nil, fmt.Errorf(text.DataDiffKeyNotFound, k, name)
+		}
+		s.keys = append(s.keys, i)
+	}
+	return s, nil
+}
+
+// next reads the next row, checking the rows are sorted by the key columns.
+func (s *Side) next() error {
+	s.prev = s.row
+	row, err := s.read()
+	switch {
+	case err != nil:
+		return err
+	case row == nil:
+		s.row, s.done = nil, true
+		return nil
+	}
+	s.row = row
+	s.count++
+	if s.prev != nil {
+		switch c := compareKeys(s.prev, s.keys, s.row, s.keys, s.numericKeys); {
+		case c == 0:
+			return fmt.Errorf(text.DataDiffDuplicateKey, s.name, s.key())
+		case c > 0:
+			return fmt.Errorf(text.DataDiffNotSorted, s.name, s.key())
+		}
+	}
+	return nil
+}
+
+// key returns the key of the current row.
+func (s *Side) key() string {
+	v := make([]string, len(s.keys))
+	for i, k := range s.keys {
+		v[i] = s.cols[k] + "=" + nullString(s.row[k])
+	}
+	return strings.Join(v, ", ")
+}
+
+// Diff is a result set of the differences between the rows of two queries,
+// merged as the rows are read.
+type Diff struct {
+	src, dst *Side
+	// cols are the common (non-key) columns.
+	cols []column
+	// srcOnly and dstOnly are the columns only in the source or destination.
+	srcOnly, dstOnly []string
+	// pending are the differences of the current row.
+	pending [][]interface{}
+	current []interface{}
+	peeked  bool
+	err     error
+	// counts
+	missing, extra, changed, unchanged int64
+	columns                            map[string]int64
+}
+
+// column is a column compared by a data diff.
+type column struct {
+	name     string
+	src, dst int
+	numeric  bool
+}
+
+// New creates a data diff of the source and destination, reading the first
+// row of each.
+func New(src, dst *Side) (*Diff, error) {
+	d := &Diff{src: src, dst: dst, columns: make(map[string]int64)}
+	// keys are compared as numbers when either side is numeric, so that both
+	// sides are compared the same way
+	for i := range src.keys {
+		numeric := src.numeric[src.keys[i]] || dst.numeric[dst.keys[i]]
+		src.numericKeys = append(src.numericKeys, numeric)
+		dst.numericKeys = append(dst.numericKeys, numeric)
+	}
+	used := make(map[int]bool)
+	for i, c := range src.cols {
+		j := ColumnIndex(dst.cols, c)
+		switch {
+		case j == -1:
+			d.srcOnly = append(d.srcOnly, c)
+		case !slices.Contains(src.keys, i):
+			d.cols = append(d.cols, column{name: c, src: i, dst: j, numeric: src.numeric[i] || dst.numeric[j]})
+			used[j] = true
+		default:
+			used[j] = true
+		}
+	}
+	for j, c := range dst.cols {
+		if !used[j] {
+			d.dstOnly = append(d.dstOnly, c)
+		}
+	}
+	if err := src.next(); err != nil {
+		return nil, err
+	}
+	if err := dst.next(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Peek returns true when there are differences, without advancing past the
+// first difference.
+func (d *Diff) Peek() bool {
+	if d.peeked {
+		return true
+	}
+	d.peeked = d.Next()
+	return d.peeked
+}
+
+// Next satisfies the [tblfmt.ResultSet] interface.
+func (d *Diff) Next() bool {
+	if d.peeked {
+		d.peeked = false
+		return true
+	}
+	for len(d.pending) == 0 && d.err == nil {
+		if d.src.done && d.dst.done {
+			return false
+		}
+		d.err = d.compare()
+	}
+	if d.err != nil {
+		return false
+	}
+	d.current, d.pending = d.pending[0], d.pending[1:]
+	return true
+}
+
+// compare compares the current rows of the source and destination, and
+// advances past the compared rows.
+func (d *Diff) compare() error {
+	c := 0
+	switch {
+	case d.src.done:
+		c = 1
+	case d.dst.done:
+		c = -1
+	default:
+		c = compareKeys(d.src.row, d.src.keys, d.dst.row, d.dst.keys, d.src.numericKeys)
+	}
+	switch {
+	case c < 0:
+		d.missing++
+		d.pending = append(d.pending, []interface{}{"missing", d.src.key(), nil, nil, nil})
+		return d.src.next()
+	case c > 0:
+		d.extra++
+		d.pending = append(d.pending, []interface{}{"extra", d.dst.key(), nil, nil, nil})
+		return d.dst.next()
+	}
+	for _, col := range d.cols {
+		a, b := d.src.row[col.src], d.dst.row[col.dst]
+		if compareValues(a, b, col.numeric) == 0 {
+			continue
+		}
+		d.columns[col.name]++
+		d.pending = append(d.pending, []interface{}{"changed", d.src.key(), col.name, nullValue(a), nullValue(b)})
+	}
+	if len(d.pending) != 0 {
+		d.changed++
+	} else {
+		d.unchanged++
+	}
+	if err := d.src.next(); err != nil {
+		return err
+	}
+	return d.dst.next()
+}
+
+// Scan satisfies the [tblfmt.ResultSet] interface.
+func (d *Diff) Scan(v ...interface{}) error {
+	for i := range v {
+		*(v[i].(*interface{})) = d.current[i]
+	}
+	return nil
+}
+
+// Columns satisfies the [tblfmt.ResultSet] interface.
+func (d *Diff) Columns() ([]string, error) {
+	return []string{"Change", "Key", "Column", "Source", "Destination"}, nil
+}
+
+// Close satisfies the [tblfmt.ResultSet] interface.
+func (d *Diff) Close() error {
+	return nil
+}
+
+// Err satisfies the [tblfmt.ResultSet] interface.
+func (d *Diff) Err() error {
+	return d.err
+}
+
+// NextResultSet satisfies the [tblfmt.ResultSet] interface.
+func (d *Diff) NextResultSet() bool {
+	return false
+}
+
+// Summarize writes the summary counts.
+func (d *Diff) Summarize(w io.Writer) error {
+	fmt.Fprintf(w, text.DataDiffSummary, d.src.count, d.dst.count, d.missing, d.extra, d.changed, d.unchanged)
+	fmt.Fprintln(w)
+	if len(d.columns) != 0 {
+		names := make([]string, 0, len(d.columns))
+		for name := range d.columns {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if d.columns[names[i]] != d.columns[names[j]] {
+				return d.columns[names[i]] > d.columns[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		v := make([]string, len(names))
+		for i, name := range names {
+			v[i] = fmt.Sprintf("%s (%d)", name, d.columns[name])
+		}
+		fmt.Fprintln(w, "Changed columns: "+strings.Join(v, ", "))
+	}
+	if len(d.srcOnly) != 0 {
+		fmt.Fprintln(w, "Columns only in source: "+strings.Join(d.srcOnly, ", "))
+	}
+	if len(d.dstOnly) != 0 {
+		fmt.Fprintln(w, "Columns only in destination: "+strings.Join(d.dstOnly, ", "))
+	}
+	return nil
+}
+
+// ColumnIndex returns the index of the column, ignoring case and identifier
+// quotes, or -1 when not found.
+func ColumnIndex(cols []string, name string) int {
+	name = strings.Trim(name, "\"`[]")
+	for i, c := range cols {
+		if strings.EqualFold(c, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// compareKeys compares the key columns of two rows.
+func compareKeys(a []sql.NullString, ak []int, b []sql.NullString, bk []int, numeric []bool) int {
+	for i := range ak {
+		if c := compareValues(a[ak[i]], b[bk[i]], numeric[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareValues compares two values, comparing numeric values as numbers,
+// other values in byte order, and sorting NULL before other values.
+func compareValues(a, b sql.NullString, numeric bool) int {
+	switch {
+	case !a.Valid && !b.Valid:
+		return 0
+	case !a.Valid:
+		return -1
+	case !b.Valid:
+		return 1
+	}
+	if numeric && a.String != b.String {
+		if x, ok := new(big.Rat).SetString(a.String); ok {
+			if y, ok := new(big.Rat).SetString(b.String); ok {
+				return x.Cmp(y)
+			}
+		}
+	}
+	return strings.Compare(a.String, b.String)
+}
+
+// nullString returns the string of a value, or NULL.
+func nullString(v sql.NullString) string {
+	if !v.Valid {
+		return "NULL"
+	}
+	return v.String
+}
+
+// nullValue returns the value as a interface{}, or nil for NULL.
+func nullValue(v sql.NullString) interface{} {
+	if !v.Valid {
+		return nil
+	}
+	return v.String
+}
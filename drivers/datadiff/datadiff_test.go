@@ -0,0 +1,180 @@
+package datadiff
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xo/usql/text"
+)
+
+func TestDataDiff(t *testing.T) {
+	src := "id,name,amount,extra\n1,a,1.5,x\n2,b,2,x\n3,c,3,x\n10,j,10,x\n"
+	dst := "id,name,amount\n1,a,1.50\n2,B,\n4,d,4\n10,j,11\n"
+	d := newTestDiff(t, src, dst, "id")
+	var diffs []string
+	for d.Next() {
+		var v [5]interface{}
+		if err := d.Scan(&v[0], &v[1], &v[2], &v[3], &v[4]); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		diffs = append(diffs, fmt.Sprint(v))
+	}
+	if d.Err() != nil {
+		t.Fatalf("expected no error, got: %v", d.Err())
+	}
+	exp := []string{
+		"[changed id=2 name b B]",
+		"[changed id=2 amount 2 <nil>]",
+		"[missing id=3 <nil> <nil> <nil>]",
+		"[extra id=4 <nil> <nil> <nil>]",
+		"[changed id=10 amount 10 11]",
+	}
+	if !reflect.DeepEqual(diffs, exp) {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(exp, "\n"), strings.Join(diffs, "\n"))
+	}
+	buf := new(strings.Builder)
+	if err := d.Summarize(buf); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s, exp := buf.String(), "Compared 4 source and 4 destination rows: 1 missing, 1 extra, 2 changed, 1 unchanged.\n"+
+		"Changed columns: amount (2), name (1)\n"+
+		"Columns only in source: extra\n"; s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+}
+
+func TestDataDiffByteOrder(t *testing.T) {
+	// NULL keys sort first, and text keys in byte order
+	src := "name,v\n,1\nB,1\na,1\nb,1\n"
+	dst := "name,v\n,2\nB,1\nb,1\n"
+	d := newTestDiff(t, src, dst, "name")
+	var diffs []string
+	for d.Next() {
+		var v [5]interface{}
+		if err := d.Scan(&v[0], &v[1], &v[2], &v[3], &v[4]); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		diffs = append(diffs, fmt.Sprint(v))
+	}
+	if d.Err() != nil {
+		t.Fatalf("expected no error, got: %v", d.Err())
+	}
+	exp := []string{
+		"[changed name=NULL v 1 2]",
+		"[missing name=a <nil> <nil> <nil>]",
+	}
+	if !reflect.DeepEqual(diffs, exp) {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(exp, "\n"), strings.Join(diffs, "\n"))
+	}
+}
+
+func TestDataDiffErrors(t *testing.T) {
+	tests := []struct {
+		src, keys, exp string
+	}{
+		{"id,name\n2,a\n1,b\n", "id", fmt.Sprintf(text.DataDiffNotSorted, "source", "id=1")},
+		{"id,name\n1,a\n1,b\n", "id", fmt.Sprintf(text.DataDiffDuplicateKey, "source", "id=1")},
+		{"id,name\nb,1\na,2\n", "id", fmt.Sprintf(text.DataDiffNotSorted, "source", "id=a")},
+		{"id,name\n1,a\n2,b\n", "name,x", fmt.Sprintf(text.DataDiffKeyNotFound, "x", "source")},
+	}
+	for _, test := range tests {
+		keys := strings.Split(test.keys, ",")
+		src, err := newTestSide(t, test.src, keys, "source")
+		if err == nil {
+			var dst *Side
+			if dst, err = newTestSide(t, "id,name\n", keys, "destination"); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			var d *Diff
+			if d, err = New(src, dst); err == nil {
+				for d.Next() {
+				}
+				err = d.Err()
+			}
+		}
+		if err == nil || err.Error() != test.exp {
+			t.Errorf("expected error %q, got: %v", test.exp, err)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		query, exp string
+	}{
+		{"t", "SELECT * FROM t ORDER BY a, b"},
+		{"public.t", "SELECT * FROM public.t ORDER BY a, b"},
+		{"select * from t where a > 1;", "SELECT * FROM (select * from t where a > 1) t ORDER BY a, b"},
+	}
+	for _, test := range tests {
+		if s := Query(test.query, "a", "b"); s != test.exp {
+			t.Errorf("expected %q, got: %q", test.exp, s)
+		}
+	}
+	if s, exp := Query("t"), "SELECT * FROM t"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	tests := []struct {
+		collate func(string) string
+		exp     []string
+	}{
+		{nil, []string{"CASE WHEN a IS NULL THEN 0 ELSE 1 END", "a"}},
+		{func(s string) string { return s + ` COLLATE "C"` }, []string{"CASE WHEN a IS NULL THEN 0 ELSE 1 END", `a COLLATE "C"`}},
+	}
+	for _, test := range tests {
+		if v := OrderBy("a", test.collate); !reflect.DeepEqual(v, test.exp) {
+			t.Errorf("expected %q, got: %q", test.exp, v)
+		}
+	}
+}
+
+// newTestDiff creates a data diff of the source and destination csv data.
+func newTestDiff(t *testing.T, src, dst string, keys ...string) *Diff {
+	t.Helper()
+	s, err := newTestSide(t, src, keys, "source")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	d, err := newTestSide(t, dst, keys, "destination")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	diff, err := New(s, d)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	return diff
+}
+
+// newTestSide creates a data diff side reading the csv data, with empty values
+// read as NULL, and the id and amount columns compared as numbers.
+func newTestSide(t *testing.T, data string, keys []string, name string) (*Side, error) {
+	t.Helper()
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	cols, records := records[0], records[1:]
+	numeric := make([]bool, len(cols))
+	for i, c := range cols {
+		numeric[i] = c == "id" || c == "amount"
+	}
+	return NewSide(name, cols, numeric, keys, func() ([]sql.NullString, error) {
+		if len(records) == 0 {
+			return nil, nil
+		}
+		row := make([]sql.NullString, len(records[0]))
+		for i, v := range records[0] {
+			row[i] = sql.NullString{String: v, Valid: v != ""}
+		}
+		records = records[1:]
+		return row, nil
+	})
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/gohxs/readline"
 	"github.com/xo/dburl"
 	"github.com/xo/usql/drivers/completer"
+	"github.com/xo/usql/drivers/explain"
 	"github.com/xo/usql/drivers/metadata"
 	"github.com/xo/usql/stmt"
 	"github.com/xo/usql/text"
@@ -82,6 +83,8 @@ type Driver struct {
 	RowsAffected func(sql.Result) (int64, error)
 	// Err will be used by Error.Error if defined.
 	Err func(error) (string, string)
+	// ErrDetail will be used by ErrDetail if defined.
+	ErrDetail func(error) *ErrorDetail
 	// ConvertBytes will be used by ConvertBytes to convert a raw []byte
 	// slice to a string if defined.
 	ConvertBytes func([]byte, string) (string, error)
@@ -107,6 +110,21 @@ type Driver struct {
 	NewCompleter func(db DB, opts ...completer.Option) readline.AutoCompleter
 	// Copy rows into the database table
 	Copy func(ctx context.Context, db *sql.DB, rows *sql.Rows, table string) (int64, error)
+	// Placeholder returns the n-th query parameter placeholder, and is used
+	// to copy rows with INSERT statements when Copy is not defined or when
+	// copying within a transaction. Defaults to ?.
+	Placeholder func(int) string
+	// FunctionTemplate is the template used by FunctionTemplate if defined.
+	FunctionTemplate string
+	// ViewTemplate is the template used by ViewTemplate if defined.
+	ViewTemplate string
+	// DescribeQuery will be used by DescribeQuery if defined.
+	DescribeQuery func(string) string
+	// Explain will be used by Explain if defined.
+	Explain func(context.Context, DB, string, bool, ...interface{}) (*explain.Plan, error)
+	// ByteOrder returns the expression sorting a text expression in byte
+	// order, and is used by DataDiff to sort rows as they are compared.
+	ByteOrder func(string) string
 }
 
 // drivers are registered drivers.
@@ -154,6 +172,89 @@ func UseColumnTypes(u *dburl.URL) bool {
 	return false
 }
 
+// FunctionTemplate returns the template for creating a new function for a
+// driver, or the generic template when not connected.
+func FunctionTemplate(u *dburl.URL) string {
+	if u == nil {
+		return text.FunctionTemplate
+	}
+	if d, ok := drivers[u.Driver]; ok && d.FunctionTemplate != "" {
+		return d.FunctionTemplate
+	}
+	return text.FunctionTemplate
+}
+
+// ViewTemplate returns the template for creating a new view for a driver, or
+// the generic template when not connected.
+func ViewTemplate(u *dburl.URL) string {
+	if u == nil {
+		return text.ViewTemplate
+	}
+	if d, ok := drivers[u.Driver]; ok && d.ViewTemplate != "" {
+		return d.ViewTemplate
+	}
+	return text.ViewTemplate
+}
+
+// DescribeQuery returns the query wrapped in a form returning no rows for a
+// driver, used to retrieve the query's result columns without executing it.
+// Statements without side effects (ie, SHOW) are returned as-is, and other
+// statements that can not be wrapped (ie, INSERT ... RETURNING) return an
+// error.
+func DescribeQuery(u *dburl.URL, prefix, sqlstr string) (string, error) {
+	sqlstr = strings.TrimRight(strings.TrimSpace(sqlstr), ";")
+	typ, _, _ := strings.Cut(prefix, " ")
+	switch {
+	case describeExec[typ] && !explainAnalyzeRE.MatchString(sqlstr):
+		return sqlstr, nil
+	case !describeWrap[typ]:
+		return "", fmt.Errorf(text.DescribeNotSupported, prefix)
+	}
+	if d, ok := drivers[u.Driver]; ok && d.DescribeQuery != nil {
+		return d.DescribeQuery(sqlstr), nil
+	}
+	return "SELECT * FROM (" + sqlstr + "\n) gdesc WHERE 1=0", nil
+}
+
+// describeWrap are the statements wrapped by DescribeQuery.
+var describeWrap = map[string]bool{
+	"SELECT": true,
+	"WITH":   true,
+	"VALUES": true,
+	"TABLE":  true,
+	"FROM":   true,
+}
+
+// describeExec are the statements without side effects executed as-is by
+// DescribeQuery.
+var describeExec = map[string]bool{
+	"SHOW":     true,
+	"PRAGMA":   true,
+	"EXPLAIN":  true,
+	"DESCRIBE": true,
+	"DESC":     true,
+	"LIST":     true,
+	"ADMIN":    true,
+}
+
+// explainAnalyzeRE matches an EXPLAIN statement executing the query.
+var explainAnalyzeRE = regexp.MustCompile(`(?is)^EXPLAIN\s+(?:\([^)]*\bANALYZE\b|ANALYZE\b)`)
+
+// Explain returns the query plan for the query for a driver, executing the
+// query when analyze is true.
+func Explain(ctx context.Context, u *dburl.URL, db DB, sqlstr string, analyze bool, bind ...interface{}) (*explain.Plan, error) {
+	d, ok := drivers[u.Driver]
+	if !ok || d.Explain == nil {
+		return nil, fmt.Errorf(text.NotSupportedByDriver, `\explain`, u.Driver)
+	}
+	sqlstr = strings.TrimRight(strings.TrimSpace(sqlstr), ";")
+	plan, err := d.Explain(ctx, db, sqlstr, analyze, bind...)
+	if err == text.ErrNotSupported {
+		return nil, fmt.Errorf(text.NotSupportedByDriver, `\explain analyze`, u.Driver)
+	}
+	return plan, err
+}
+
 // ForceParams forces parameters on the DSN for a driver.
 func ForceParams(u *dburl.URL) {
 	d, ok := drivers[u.Driver]
@@ -380,6 +481,58 @@ func ConvertDefault(u *dburl.URL) func(interface{}) (string, error) {
 	}
 }
 
+// ConvertNull converts scanned values (pointers to interface{}) to strings,
+// retaining NULL values.
+func ConvertNull(u *dburl.URL, r []interface{}, tfmt string) ([]sql.NullString, error) {
+	clen := len(r)
+	// get conversion funcs
+	cb, cm, cs, cd := ConvertBytes(u), ConvertMap(u), ConvertSlice(u), ConvertDefault(u)
+	row := make([]sql.NullString, clen)
+	for n, z := range r {
+		j := z.(*interface{})
+		row[n].Valid = *j != nil
+		switch x := (*j).(type) {
+		case []byte:
+			if x != nil {
+				var err error
+				if row[n].String, err = cb(x, tfmt); err != nil {
+					return nil, err
+				}
+			} else {
+				row[n].Valid = false
+			}
+		case string:
+			row[n].String = x
+		case time.Time:
+			row[n].String = x.Format(tfmt)
+		case fmt.Stringer:
+			row[n].String = x.String()
+		case map[string]interface{}:
+			if x != nil {
+				var err error
+				if row[n].String, err = cm(x); err != nil {
+					return nil, err
+				}
+			}
+		case []interface{}:
+			if x != nil {
+				var err error
+				if row[n].String, err = cs(x); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			if x != nil {
+				var err error
+				if row[n].String, err = cd(x); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return row, nil
+}
+
 // BatchAsTransaction returns whether or not a driver requires batched queries
 // to be done within a transaction block.
 func BatchAsTransaction(u *dburl.URL) bool {
@@ -511,19 +664,38 @@ func NewCompleter(ctx context.Context, u *dburl.URL, db DB, readerOpts []metadat
 
 // Copy copies the result set to the destination sql.DB.
 func Copy(ctx context.Context, u *dburl.URL, stdout, stderr func() io.Writer, rows *sql.Rows, table string) (int64, error) {
-	d, ok := drivers[u.Driver]
-	if !ok {
+	if _, ok := drivers[u.Driver]; !ok {
 		return 0, WrapErr(u.Driver, text.ErrDriverNotAvailable)
 	}
-	if d.Copy == nil {
-		return 0, fmt.Errorf(text.NotSupportedByDriver, "copy", u.Driver)
-	}
 	db, err := Open(ctx, u, stdout, stderr)
 	if err != nil {
 		return 0, err
 	}
 	defer db.Close()
-	return d.Copy(ctx, db, rows, table)
+	return CopyDB(ctx, u, db, rows, table)
+}
+
+// CopyDB copies the result set to the table using the open database
+// connection. The rows are copied with INSERT statements when the driver does
+// not define a Copy func, or when db is a transaction, as the driver's copy
+// func manages its own transaction.
+func CopyDB(ctx context.Context, u *dburl.URL, db DB, rows *sql.Rows, table string) (int64, error) {
+	d, ok := drivers[u.Driver]
+	if !ok {
+		return 0, WrapErr(u.Driver, text.ErrDriverNotAvailable)
+	}
+	sqldb, ok := db.(*sql.DB)
+	if d.Copy != nil && ok {
+		return d.Copy(ctx, sqldb, rows, table)
+	}
+	placeholder := d.Placeholder
+	if placeholder == nil {
+		placeholder = func(int) string { return "?" }
+	}
+	if ok {
+		return FlexibleCopyWithInsert(ctx, sqldb, rows, table, placeholder, true)
+	}
+	return copyWithInsert(ctx, db, rows, table, placeholder)
 }
 
 // CopyWithInsert builds a typical copy handler based on insert.
@@ -537,6 +709,28 @@ func CopyWithInsert(placeholder func(int) string) func(ctx context.Context, db *
 }
 
 func FlexibleCopyWithInsert(ctx context.Context, db *sql.DB, rows *sql.Rows, table string, placeholder func(int) string, withTransaction bool) (int64, error) {
+	if !withTransaction {
+		return copyWithInsert(ctx, db, rows, table, placeholder)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// no-op after commit
+	defer tx.Rollback()
+	n, err := copyWithInsert(ctx, tx, rows, table, placeholder)
+	if err != nil {
+		return n, err
+	}
+	if err := tx.Commit(); err != nil {
+		return n, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return n, nil
+}
+
+// copyWithInsert copies the rows to the table with INSERT statements executed
+// on db.
+func copyWithInsert(ctx context.Context, db DB, rows *sql.Rows, table string, placeholder func(int) string) (int64, error) {
 	columns, err := rows.Columns()
 	if err != nil {
 		return 0, fmt.Errorf("failed to fetch source rows columns: %w", err)
@@ -564,17 +758,7 @@ func FlexibleCopyWithInsert(ctx context.Context, db *sql.DB, rows *sql.Rows, tab
 		}
 		query = "INSERT INTO " + table + " VALUES (" + strings.Join(placeholders, ", ") + ")"
 	}
-	var stmt *sql.Stmt
-	var tx *sql.Tx
-	if withTransaction {
-		tx, err = db.BeginTx(ctx, nil)
-		if err != nil {
-			return 0, fmt.Errorf("failed to begin transaction: %w", err)
-		}
-		stmt, err = tx.PrepareContext(ctx, query)
-	} else {
-		stmt, err = db.PrepareContext(ctx, query)
-	}
+	stmt, err := db.PrepareContext(ctx, query)
 	if err != nil {
 		return 0, fmt.Errorf("failed to prepare insert query: %w", err)
 	}
@@ -613,12 +797,6 @@ func FlexibleCopyWithInsert(ctx context.Context, db *sql.DB, rows *sql.Rows, tab
 	}
 	// TODO if using batches, flush the last batch,
 	// TODO prepare another statement and count remaining rows
-	if tx != nil {
-		err = tx.Commit()
-		if err != nil {
-			return n, fmt.Errorf("failed to commit transaction: %w", err)
-		}
-	}
 	return n, rows.Err()
 }
 
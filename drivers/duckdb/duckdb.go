@@ -6,12 +6,17 @@ package duckdb
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
+	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
-	_ "github.com/duckdb/duckdb-go/v2" // DRIVER
+	"github.com/duckdb/duckdb-go/v2" // DRIVER
 	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/drivers/explain"
 	"github.com/xo/usql/drivers/metadata"
 	infos "github.com/xo/usql/drivers/metadata/informationschema"
 	mymeta "github.com/xo/usql/drivers/metadata/mysql"
@@ -24,6 +29,9 @@ type metaReader struct {
 var (
 	_ metadata.CatalogReader    = &metaReader{}
 	_ metadata.ColumnStatReader = &metaReader{}
+	_ metadata.TypeReader       = &metaReader{}
+	_ metadata.ExtensionReader  = &metaReader{}
+	_ metadata.SettingsReader   = &metaReader{}
 )
 
 func (r metaReader) Catalogs(metadata.Filter) (*metadata.CatalogSet, error) {
@@ -114,6 +122,192 @@ func (r metaReader) ColumnStats(f metadata.Filter) (*metadata.ColumnStatSet, err
 	return metadata.NewColumnStatSet(results), nil
 }
 
+func (r metaReader) Types(f metadata.Filter) (*metadata.TypeSet, error) {
+	qstr := `SELECT
+  database_name,
+  schema_name,
+  type_name,
+  CASE
+    WHEN internal THEN 'base'
+    WHEN logical_type = 'ENUM' THEN 'enum'
+    WHEN logical_type IN ('STRUCT', 'UNION') THEN 'composite'
+    ELSE 'domain'
+  END,
+  CASE WHEN COALESCE(type_size, 0) = 0 THEN 'var' ELSE CAST(type_size AS VARCHAR) END,
+  CASE WHEN logical_type = 'ENUM' THEN COALESCE(array_to_string(labels, chr(10)), '') ELSE '' END,
+  COALESCE(comment, '')
+FROM duckdb_types()`
+	// built-in types are repeated for every attached database
+	conds := []string{"(NOT internal OR database_name = 'system')"}
+	vals := []interface{}{}
+	if !f.WithSystem {
+		conds = append(conds, "NOT internal")
+	}
+	if f.Schema != "" {
+		vals = append(vals, f.Schema)
+		conds = append(conds, "schema_name LIKE ?")
+	}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		conds = append(conds, "type_name LIKE ?")
+	}
+	qstr += "\nWHERE " + strings.Join(conds, " AND ") + "\nORDER BY schema_name, type_name"
+	rows, closeRows, err := r.Query(qstr, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Type{}
+	for rows.Next() {
+		var elements string
+		rec := metadata.Type{}
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.Name, &rec.Type, &rec.Size, &elements, &rec.Description)
+		if err != nil {
+			return nil, err
+		}
+		if elements != "" {
+			rec.Elements = strings.Split(elements, "\n")
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	// user defined types only report their logical type, so retrieve the
+	// complete type for the underlying type or the attributes
+	for i := range results {
+		if t := results[i].Type; t != "domain" && t != "composite" {
+			continue
+		}
+		typ, err := r.underlyingType(results[i])
+		if err != nil {
+			return nil, err
+		}
+		results[i].Elements = []string{typ}
+		if results[i].Type == "composite" {
+			results[i].Elements = splitStructType(typ)
+		}
+	}
+	return metadata.NewTypeSet(results), nil
+}
+
+// underlyingType returns the complete type the user defined type is an alias
+// for.
+func (r metaReader) underlyingType(t metadata.Type) (string, error) {
+	quote := func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	q := fmt.Sprintf(`SELECT typeof(CAST(NULL AS %s.%s.%s))`, quote(t.Catalog), quote(t.Schema), quote(t.Name))
+	rows, closeRows, err := r.Query(q)
+	if err != nil {
+		return "", err
+	}
+	defer closeRows()
+	var typ string
+	if rows.Next() {
+		if err := rows.Scan(&typ); err != nil {
+			return "", err
+		}
+	}
+	return typ, rows.Err()
+}
+
+// Extensions lists the installed extensions. Objects belonging to extensions
+// (\dx+) are not listed, as duckdb_functions() and the other catalog functions
+// do not report the extension an object was loaded from.
+func (r metaReader) Extensions(f metadata.Filter) (*metadata.ExtensionSet, error) {
+	qstr := `SELECT
+  extension_name,
+  COALESCE(extension_version, ''),
+  COALESCE(description, '')
+FROM duckdb_extensions()
+WHERE installed`
+	vals := []interface{}{}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		qstr += " AND extension_name LIKE ?"
+	}
+	rows, closeRows, err := r.Query(qstr+"\nORDER BY extension_name", vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Extension{}
+	for rows.Next() {
+		rec := metadata.Extension{}
+		err = rows.Scan(&rec.Name, &rec.Version, &rec.Description)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewExtensionSet(results), nil
+}
+
+func (r metaReader) Settings(f metadata.Filter) (*metadata.SettingSet, error) {
+	qstr := `SELECT
+  name,
+  COALESCE(value, ''),
+  COALESCE(scope, ''),
+  COALESCE(description, '')
+FROM duckdb_settings()`
+	vals := []interface{}{}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		qstr += "\nWHERE name ILIKE ?"
+	}
+	rows, closeRows, err := r.Query(qstr+"\nORDER BY name", vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Setting{}
+	for rows.Next() {
+		rec := metadata.Setting{}
+		err = rows.Scan(&rec.Name, &rec.Value, &rec.Source, &rec.Description)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewSettingSet(results), nil
+}
+
+// splitStructType splits the attributes of a STRUCT(...) type, ie
+// STRUCT(x INTEGER, y VARCHAR).
+func splitStructType(typ string) []string {
+	start, end := strings.IndexByte(typ, '('), strings.LastIndexByte(typ, ')')
+	if start == -1 || end < start {
+		return []string{typ}
+	}
+	var v []string
+	var depth, last int
+	s := typ[start+1 : end]
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				v = append(v, strings.TrimSpace(s[last:i]))
+				last = i + 1
+			}
+		}
+	}
+	return append(v, strings.TrimSpace(s[last:]))
+}
+
 func init() {
 	newReader := func(db drivers.DB, opts ...metadata.ReaderOption) metadata.Reader {
 		ir := infos.New(
@@ -152,5 +346,57 @@ func init() {
 		},
 		Copy:         drivers.CopyWithInsert(func(int) string { return "?" }),
 		NewCompleter: mymeta.NewCompleter,
+		Explain: func(ctx context.Context, db drivers.DB, sqlstr string, analyze bool, bind ...interface{}) (*explain.Plan, error) {
+			opts := "FORMAT JSON"
+			if analyze {
+				opts = "ANALYZE, " + opts
+			}
+			lines, err := explain.QueryLines(ctx, db, "EXPLAIN ("+opts+") "+sqlstr, bind...)
+			if err != nil {
+				return nil, err
+			}
+			return explain.ParseDuckDB([]byte(strings.Join(lines, "\n")))
+		},
+		Err: func(err error) (string, string) {
+			if e := new(duckdb.Error); errors.As(err, &e) {
+				msg, _, _ := errPosition(e.Msg)
+				return "", msg
+			}
+			return "", err.Error()
+		},
+		ErrDetail: func(err error) *drivers.ErrorDetail {
+			e := new(duckdb.Error)
+			if !errors.As(err, &e) {
+				return nil
+			}
+			msg, line, pos := errPosition(e.Msg)
+			return &drivers.ErrorDetail{
+				Message:  msg,
+				Position: pos,
+				Line:     line,
+			}
+		},
 	})
 }
+
+// errLineRE matches the error context DuckDB appends to error messages.
+var errLineRE = regexp.MustCompile(`\n+(LINE ([0-9]+): )(.*)\n( *)\^\s*$`)
+
+// errPosition splits the error context (the "LINE n: ..." line and caret)
+// from a DuckDB error message, returning the message, the 1-based line, and
+// the 1-based character position of the error in the line. When the position
+// cannot be determined (ie, when DuckDB truncated the line), the message is
+// returned as-is.
+func errPosition(msg string) (string, int, int) {
+	m := errLineRE.FindStringSubmatchIndex(msg)
+	if m == nil {
+		return msg, 0, 0
+	}
+	prefix, num, line, caret := msg[m[2]:m[3]], msg[m[4]:m[5]], msg[m[6]:m[7]], m[9]-m[8]
+	n, err := strconv.Atoi(num)
+	if err != nil || strings.HasPrefix(line, "...") || caret < len(prefix) {
+		return msg, 0, 0
+	}
+	col := min(caret-len(prefix), len(line))
+	return msg[:m[0]], n, utf8.RuneCountInString(line[:col]) + 1
+}
@@ -1,6 +1,7 @@
 package drivers
 
 import (
+	"errors"
 	"strings"
 	"unicode"
 )
@@ -52,6 +53,56 @@ func (e *Error) Unwrap() error {
 	return e.Err
 }
 
+// ErrorDetail is the detailed information about a database error.
+type ErrorDetail struct {
+	// Severity is the severity of the error, ie ERROR or FATAL.
+	Severity string
+	// Code is the SQLSTATE or driver specific error code.
+	Code    string
+	Message string
+	Detail  string
+	Hint    string
+	// Position is the 1-based character position of the error in the query,
+	// or 0 when not known.
+	Position int
+	// Line is the 1-based line of the query that Position is relative to, or
+	// 0 when Position is relative to the start of the query.
+	Line int
+	// Where is the context in which the error occurred.
+	Where      string
+	Schema     string
+	Table      string
+	Column     string
+	DataType   string
+	Constraint string
+	// Location is the location in the database server's source code where
+	// the error was reported.
+	Location string
+}
+
+// ErrDetail returns the detailed information about an error, using the
+// driver's ErrDetail or Err when defined.
+func ErrDetail(err error) *ErrorDetail {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if !errors.As(err, &e) {
+		return &ErrorDetail{Message: err.Error()}
+	}
+	d, ok := drivers[e.Driver]
+	if ok && d.ErrDetail != nil {
+		if detail := d.ErrDetail(e.Err); detail != nil {
+			return detail
+		}
+	}
+	detail := &ErrorDetail{Message: e.Err.Error()}
+	if ok && d.Err != nil {
+		detail.Code, detail.Message = d.Err(e.Err)
+	}
+	return detail
+}
+
 // chop chops off a "prefix: " prefix from a string.
 func chop(s, prefix string) string {
 	return strings.TrimLeftFunc(strings.TrimPrefix(strings.TrimSpace(s), prefix+":"), unicode.IsSpace)
@@ -0,0 +1,279 @@
+// Package explain provides a common query plan tree for the different EXPLAIN
+// output formats of databases, and renders plans as an indented tree.
+package explain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Plan is a query plan.
+type Plan struct {
+	// Nodes are the root nodes of the plan.
+	Nodes []*Node
+	// Footer are additional lines displayed after the plan (ie, the planning
+	// and execution time).
+	Footer []string
+}
+
+// Node is a query plan node.
+type Node struct {
+	// Name is the name of the node (ie, "Seq Scan on t").
+	Name string
+	// Details are additional details of the node (ie, "Filter: (a > 1)").
+	Details []string
+	// HasCost indicates the estimated cost of the node is known.
+	HasCost bool
+	// StartupCost is the estimated startup cost of the node.
+	StartupCost float64
+	// Cost is the estimated total cost of the node, including the cost of
+	// the node's children.
+	Cost float64
+	// HasRows indicates the estimated rows of the node is known.
+	HasRows bool
+	// Rows is the estimated number of rows produced by the node.
+	Rows float64
+	// HasActual indicates the actual time, rows, and loops of the node is
+	// known.
+	HasActual bool
+	// ActualStartupTime is the actual time (in milliseconds) to produce the
+	// node's first row.
+	ActualStartupTime float64
+	// ActualTime is the actual time (in milliseconds) for a single loop of
+	// the node, including the time of the node's children.
+	ActualTime float64
+	// ActualRows is the actual number of rows produced by a single loop of the
+	// node.
+	ActualRows float64
+	// Loops is the number of times the node was executed.
+	Loops float64
+	// Children are the child nodes.
+	Children []*Node
+}
+
+// String satisfies the [fmt.Stringer] interface.
+func (n *Node) String() string {
+	var sb strings.Builder
+	sb.WriteString(n.Name)
+	switch {
+	case n.HasCost && n.HasRows && n.StartupCost != 0:
+		fmt.Fprintf(&sb, "  (cost=%.2f..%.2f rows=%.0f)", n.StartupCost, n.Cost, n.Rows)
+	case n.HasCost && n.HasRows:
+		fmt.Fprintf(&sb, "  (cost=%.2f rows=%.0f)", n.Cost, n.Rows)
+	case n.HasCost:
+		fmt.Fprintf(&sb, "  (cost=%.2f)", n.Cost)
+	case n.HasRows:
+		fmt.Fprintf(&sb, "  (rows=%.0f)", n.Rows)
+	}
+	if !n.HasActual {
+		return sb.String()
+	}
+	if !n.HasCost && !n.HasRows {
+		sb.WriteString(" ")
+	}
+	if n.ActualStartupTime != 0 {
+		fmt.Fprintf(&sb, " (actual time=%.3f..%.3f rows=%.0f loops=%.0f)", n.ActualStartupTime, n.ActualTime, n.ActualRows, n.Loops)
+	} else {
+		fmt.Fprintf(&sb, " (actual time=%.3f rows=%.0f loops=%.0f)", n.ActualTime, n.ActualRows, n.Loops)
+	}
+	return sb.String()
+}
+
+// total returns the total measure of the node used to determine the most
+// expensive nodes: the actual time of all loops when actual is true, or the
+// estimated cost otherwise.
+func (n *Node) total(actual bool) float64 {
+	switch {
+	case actual && n.HasActual:
+		return n.ActualTime * max(n.Loops, 1)
+	case !actual && n.HasCost:
+		return n.Cost
+	}
+	return 0
+}
+
+// self returns the measure of the node, excluding its children.
+func (n *Node) self(actual bool) float64 {
+	v := n.total(actual)
+	for _, c := range n.Children {
+		v -= c.total(actual)
+	}
+	return max(v, 0)
+}
+
+// Render writes the plan as an indented tree to w. The most expensive nodes
+// (by actual time when available, otherwise by estimated cost) are marked with
+// their share of the total, and are highlighted when color is true.
+func Render(w io.Writer, plan *Plan, color bool) error {
+	expensive := Expensive(plan)
+	var render func(*Node, string, string) error
+	render = func(n *Node, prefix, childPrefix string) error {
+		line := n.String()
+		if share, ok := expensive[n]; ok {
+			line += fmt.Sprintf(" [%.1f%%]", share*100)
+			if color {
+				line = "\x1b[1;31m" + line + "\x1b[0m"
+			}
+		}
+		if _, err := fmt.Fprintln(w, prefix+line); err != nil {
+			return err
+		}
+		detailPrefix := childPrefix + "   "
+		if len(n.Children) != 0 {
+			detailPrefix = childPrefix + "│  "
+		}
+		for _, detail := range n.Details {
+			if _, err := fmt.Fprintln(w, detailPrefix+detail); err != nil {
+				return err
+			}
+		}
+		for i, c := range n.Children {
+			p, cp := "├─ ", "│  "
+			if i == len(n.Children)-1 {
+				p, cp = "└─ ", "   "
+			}
+			if err := render(c, childPrefix+p, childPrefix+cp); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, n := range plan.Nodes {
+		if err := render(n, "", ""); err != nil {
+			return err
+		}
+	}
+	for _, line := range plan.Footer {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Expensive returns the most expensive nodes of the plan, and their share of
+// the plan's total. A node is expensive when it is one of the 3 nodes with the
+// highest time (or cost) excluding its children, and its share is at least
+// 10%.
+func Expensive(plan *Plan) map[*Node]float64 {
+	var nodes []*Node
+	var walk func(*Node)
+	walk = func(n *Node) {
+		nodes = append(nodes, n)
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	for _, n := range plan.Nodes {
+		walk(n)
+	}
+	actual := false
+	for _, n := range nodes {
+		actual = actual || n.HasActual
+	}
+	var total float64
+	for _, n := range nodes {
+		total += n.self(actual)
+	}
+	m := make(map[*Node]float64)
+	if len(nodes) < 2 || total <= 0 {
+		return m
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].self(actual) > nodes[j].self(actual)
+	})
+	for _, n := range nodes[:min(len(nodes), 3)] {
+		if share := n.self(actual) / total; share >= 0.1 {
+			m[n] = share
+		}
+	}
+	return m
+}
+
+// Queryer is the common interface for querying a database.
+type Queryer interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}
+
+// QueryLines executes the query, returning the value of the last column of
+// each row, split into lines.
+func QueryLines(ctx context.Context, db Queryer, sqlstr string, bind ...interface{}) ([]string, error) {
+	rows, err := db.QueryContext(ctx, sqlstr, bind...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		for i := range vals {
+			vals[i] = new(sql.RawBytes)
+		}
+		if err := rows.Scan(vals...); err != nil {
+			return nil, err
+		}
+		s := string(*vals[len(vals)-1].(*sql.RawBytes))
+		lines = append(lines, strings.Split(strings.TrimRight(s, "\n"), "\n")...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// ParseIndented parses a plan where each line is a node, and the child nodes
+// are indented more than their parent. The parse func returns the node for
+// the (unindented) line, or false when the line is a detail of the previous
+// node.
+func ParseIndented(lines []string, parse func(string) (*Node, bool)) *Plan {
+	type entry struct {
+		indent int
+		node   *Node
+	}
+	plan := new(Plan)
+	var stack []entry
+	for _, line := range lines {
+		s := strings.TrimLeft(line, " \t")
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		indent := len(line) - len(s)
+		n, ok := parse(strings.TrimRight(s, " "))
+		switch {
+		case !ok && len(stack) != 0:
+			last := stack[len(stack)-1].node
+			last.Details = append(last.Details, strings.TrimSpace(s))
+			continue
+		case !ok:
+			plan.Footer = append(plan.Footer, strings.TrimSpace(s))
+			continue
+		}
+		for len(stack) != 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			plan.Nodes = append(plan.Nodes, n)
+		} else {
+			parent := stack[len(stack)-1].node
+			parent.Children = append(parent.Children, n)
+		}
+		stack = append(stack, entry{indent, n})
+	}
+	return plan
+}
+
+// ParseText parses a plain text plan, where each line is a node indented
+// under its parent (ie, ClickHouse).
+func ParseText(lines []string) *Plan {
+	return ParseIndented(lines, func(s string) (*Node, bool) {
+		return &Node{Name: s}, true
+	})
+}
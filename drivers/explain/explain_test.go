@@ -0,0 +1,149 @@
+package explain
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParsePostgres(t *testing.T) {
+	plan, err := ParsePostgres([]byte(`[{
+  "Plan": {
+    "Node Type": "Hash Join", "Join Type": "Left",
+    "Startup Cost": 1.5, "Total Cost": 40.0, "Plan Rows": 100,
+    "Actual Startup Time": 0.1, "Actual Total Time": 10.0, "Actual Rows": 90, "Actual Loops": 1,
+    "Hash Cond": "(t.a = u.a)",
+    "Plans": [
+      {"Node Type": "Seq Scan", "Relation Name": "t", "Alias": "t", "Startup Cost": 0, "Total Cost": 30.0, "Plan Rows": 100,
+       "Actual Startup Time": 0.01, "Actual Total Time": 8.0, "Actual Rows": 100, "Actual Loops": 1, "Filter": "(a > 1)"},
+      {"Node Type": "Hash", "Startup Cost": 1.0, "Total Cost": 1.0, "Plan Rows": 10,
+       "Actual Startup Time": 0.5, "Actual Total Time": 0.5, "Actual Rows": 10, "Actual Loops": 1,
+       "Plans": [
+         {"Node Type": "Index Scan", "Index Name": "u_pkey", "Relation Name": "u", "Alias": "x", "Total Cost": 1.0, "Plan Rows": 10,
+          "Actual Startup Time": 0.1, "Actual Total Time": 0.4, "Actual Rows": 10, "Actual Loops": 1}
+       ]}
+    ]
+  },
+  "Planning Time": 0.25,
+  "Execution Time": 10.5
+}]`))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp := `Hash Left Join  (cost=1.50..40.00 rows=100) (actual time=0.100..10.000 rows=90 loops=1) [15.0%]
+│  Hash Cond: (t.a = u.a)
+├─ Seq Scan on t  (cost=30.00 rows=100) (actual time=0.010..8.000 rows=100 loops=1) [80.0%]
+│     Filter: (a > 1)
+└─ Hash  (cost=1.00..1.00 rows=10) (actual time=0.500..0.500 rows=10 loops=1)
+   └─ Index Scan using u_pkey on u x  (cost=1.00 rows=10) (actual time=0.100..0.400 rows=10 loops=1)
+Planning Time: 0.250 ms
+Execution Time: 10.500 ms
+`
+	if s := render(t, plan); s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+}
+
+func TestParseMySQL(t *testing.T) {
+	plan, err := ParseMySQL([]byte(`{
+  "query_block": {
+    "select_id": 1,
+    "cost_info": {"query_cost": "12.50"},
+    "ordering_operation": {
+      "using_filesort": true,
+      "nested_loop": [
+        {"table": {"table_name": "t", "access_type": "ALL", "rows_produced_per_join": 10,
+                   "cost_info": {"read_cost": "1.00", "eval_cost": "1.00", "prefix_cost": "2.00"},
+                   "attached_condition": "(t.a > 1)"}},
+        {"table": {"table_name": "u", "access_type": "eq_ref", "key": "PRIMARY", "rows_produced_per_join": 10,
+                   "cost_info": {"read_cost": "9.50", "eval_cost": "1.00", "prefix_cost": "12.50"}}}
+      ]
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp := `Query Block #1  (cost=12.50)
+└─ Sort  (cost=12.50)
+   │  Using Filesort
+   └─ Nested Loop  (cost=12.50)
+      ├─ Table Scan on t  (cost=2.00 rows=10) [16.0%]
+      │     Filter: (t.a > 1)
+      └─ Index Lookup on u using PRIMARY  (cost=10.50 rows=10) [84.0%]
+`
+	if s := render(t, plan); s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+}
+
+func TestParseMySQLTree(t *testing.T) {
+	plan := ParseMySQLTree(strings.Split(`-> Limit: 5 row(s)  (actual time=0.2..3.0 rows=5 loops=1)
+    -> Filter: (t.a > 10)  (cost=1.25 rows=3) (actual time=0.03..2.5 rows=9 loops=1)
+        -> Table scan on t  (cost=1.25 rows=10) (actual time=0.02..0.5 rows=20 loops=1)
+`, "\n"))
+	exp := `Limit: 5 row(s)  (actual time=0.200..3.000 rows=5 loops=1) [16.7%]
+└─ Filter: (t.a > 10)  (cost=1.25 rows=3) (actual time=0.030..2.500 rows=9 loops=1) [66.7%]
+   └─ Table scan on t  (cost=1.25 rows=10) (actual time=0.020..0.500 rows=20 loops=1) [16.7%]
+`
+	if s := render(t, plan); s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+}
+
+func TestParseDuckDB(t *testing.T) {
+	plan, err := ParseDuckDB([]byte(`{
+  "latency": 0.004,
+  "children": [{
+    "operator_name": "EXPLAIN_ANALYZE", "operator_timing": 0.0, "operator_cardinality": 0,
+    "children": [{
+      "operator_name": "PROJECTION", "operator_timing": 0.001, "operator_cardinality": 5,
+      "extra_info": {"Projections": ["a", "b"], "Estimated Cardinality": "5"},
+      "children": [{
+        "operator_name": "SEQ_SCAN ", "operator_timing": 0.002, "operator_cardinality": 5,
+        "extra_info": {"Table": "t"}, "children": []
+      }]
+    }]
+  }]
+}`))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp := `PROJECTION  (rows=5) (actual time=3.000 rows=5 loops=1) [33.3%]
+│  Projections: a, b
+└─ SEQ_SCAN  (actual time=2.000 rows=5 loops=1) [66.7%]
+      Table: t
+Total Time: 4.000 ms
+`
+	if s := render(t, plan); s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+}
+
+func TestParseText(t *testing.T) {
+	plan := ParseText([]string{
+		"Expression ((Projection + Before ORDER BY))",
+		"  Aggregating",
+		"    Expression (Before GROUP BY)",
+		"      ReadFromMergeTree (default.t)",
+		"  Expression",
+	})
+	exp := `Expression ((Projection + Before ORDER BY))
+├─ Aggregating
+│  └─ Expression (Before GROUP BY)
+│     └─ ReadFromMergeTree (default.t)
+└─ Expression
+`
+	if s := render(t, plan); s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+}
+
+func render(t *testing.T, plan *Plan) string {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	if err := Render(buf, plan, false); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	return buf.String()
+}
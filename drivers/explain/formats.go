@@ -0,0 +1,415 @@
+package explain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParsePostgres parses a PostgreSQL EXPLAIN (FORMAT JSON) plan.
+func ParsePostgres(buf []byte) (*Plan, error) {
+	var res []map[string]interface{}
+	if err := json.Unmarshal(buf, &res); err != nil {
+		return nil, err
+	}
+	plan := new(Plan)
+	for _, m := range res {
+		if v, ok := m["Plan"].(map[string]interface{}); ok {
+			plan.Nodes = append(plan.Nodes, postgresNode(v))
+		}
+		for _, k := range []string{"Planning Time", "Execution Time"} {
+			if f, ok := m[k].(float64); ok {
+				plan.Footer = append(plan.Footer, fmt.Sprintf("%s: %.3f ms", k, f))
+			}
+		}
+	}
+	return plan, nil
+}
+
+// postgresNode converts a PostgreSQL plan node.
+func postgresNode(m map[string]interface{}) *Node {
+	str := func(k string) string {
+		s, _ := m[k].(string)
+		return s
+	}
+	// name, similar to the names of the text format
+	name := str("Node Type")
+	switch {
+	case name == "Aggregate" && str("Strategy") == "Hashed":
+		name = "HashAggregate"
+	case name == "Aggregate" && str("Strategy") == "Sorted":
+		name = "GroupAggregate"
+	case name == "Aggregate" && str("Strategy") == "Mixed":
+		name = "MixedAggregate"
+	case strings.HasSuffix(name, "Join") || name == "Nested Loop":
+		if typ := str("Join Type"); typ != "" && typ != "Inner" {
+			name = strings.TrimSuffix(name, " Join") + " " + typ + " Join"
+		}
+	}
+	if b, _ := m["Parallel Aware"].(bool); b {
+		name = "Parallel " + name
+	}
+	if s := str("Index Name"); s != "" {
+		name += " using " + s
+	}
+	if s := str("Relation Name"); s != "" {
+		name += " on " + s
+		if alias := str("Alias"); alias != "" && alias != s {
+			name += " " + alias
+		}
+	}
+	if s := str("Subplan Name"); s != "" {
+		name = s + ": " + name
+	}
+	n := &Node{Name: name}
+	// details
+	for _, k := range []string{
+		"Sort Key", "Group Key", "Index Cond", "Recheck Cond", "Hash Cond",
+		"Merge Cond", "Join Filter", "Filter", "Rows Removed by Filter",
+	} {
+		switch v := m[k].(type) {
+		case string:
+			n.Details = append(n.Details, k+": "+v)
+		case float64:
+			n.Details = append(n.Details, k+": "+strconv.FormatFloat(v, 'f', -1, 64))
+		case []interface{}:
+			var s []string
+			for _, z := range v {
+				s = append(s, fmt.Sprint(z))
+			}
+			n.Details = append(n.Details, k+": "+strings.Join(s, ", "))
+		}
+	}
+	// estimates
+	if cost, ok := m["Total Cost"].(float64); ok {
+		n.HasCost, n.Cost = true, cost
+		n.StartupCost, _ = m["Startup Cost"].(float64)
+	}
+	n.Rows, n.HasRows = m["Plan Rows"].(float64)
+	// actual
+	if t, ok := m["Actual Total Time"].(float64); ok {
+		n.HasActual, n.ActualTime = true, t
+		n.ActualStartupTime, _ = m["Actual Startup Time"].(float64)
+		n.ActualRows, _ = m["Actual Rows"].(float64)
+		n.Loops, _ = m["Actual Loops"].(float64)
+	}
+	plans, _ := m["Plans"].([]interface{})
+	for _, v := range plans {
+		if c, ok := v.(map[string]interface{}); ok {
+			n.Children = append(n.Children, postgresNode(c))
+		}
+	}
+	return n
+}
+
+// ParseMySQL parses a MySQL EXPLAIN FORMAT=JSON plan.
+func ParseMySQL(buf []byte) (*Plan, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, err
+	}
+	return &Plan{Nodes: mysqlNodes(m)}, nil
+}
+
+// mysqlOps are the MySQL plan operations, and their names.
+var mysqlOps = []struct {
+	key, name string
+}{
+	{"query_block", "Query Block"},
+	{"union_result", "Union Result"},
+	{"ordering_operation", "Sort"},
+	{"grouping_operation", "Group"},
+	{"duplicates_removal", "Duplicates Removal"},
+	{"windowing", "Window"},
+	{"buffer_result", "Buffer Result"},
+	{"nested_loop", "Nested Loop"},
+	{"table", ""},
+	{"query_specifications", ""},
+	{"materialized_from_subquery", "Materialize"},
+	{"attached_subqueries", ""},
+	{"optimized_away_subqueries", ""},
+}
+
+// mysqlNodes returns the nodes for the plan operations contained in m.
+func mysqlNodes(m map[string]interface{}) []*Node {
+	var nodes []*Node
+	for _, op := range mysqlOps {
+		switch v := m[op.key].(type) {
+		case map[string]interface{}:
+			nodes = append(nodes, mysqlNode(op.key, op.name, v))
+		case []interface{}:
+			var children []*Node
+			for _, z := range v {
+				if c, ok := z.(map[string]interface{}); ok {
+					children = append(children, mysqlNodes(c)...)
+				}
+			}
+			if op.name == "" {
+				nodes = append(nodes, children...)
+				continue
+			}
+			n := &Node{Name: op.name, Children: children}
+			if len(v) == 0 {
+				nodes = append(nodes, n)
+				continue
+			}
+			// the cost of a nested loop is the prefix cost of the last table
+			if last, ok := v[len(v)-1].(map[string]interface{}); ok {
+				if t, ok := last["table"].(map[string]interface{}); ok {
+					n.Cost, n.HasCost = mysqlCost(t, "prefix_cost")
+				}
+			}
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// mysqlNode converts a MySQL plan operation.
+func mysqlNode(key, name string, m map[string]interface{}) *Node {
+	n := &Node{Name: name}
+	switch key {
+	case "query_block":
+		if id, ok := m["select_id"].(float64); ok {
+			n.Name += fmt.Sprintf(" #%.0f", id)
+		}
+		n.Cost, n.HasCost = mysqlCost(m, "query_cost")
+	case "ordering_operation":
+		n.Cost, n.HasCost = mysqlCost(m, "sort_cost")
+	case "table":
+		n = mysqlTable(m)
+	}
+	for _, d := range []struct{ key, desc string }{
+		{"using_filesort", "Using Filesort"},
+		{"using_temporary_table", "Using Temporary Table"},
+	} {
+		if b, _ := m[d.key].(bool); b && key != "table" {
+			n.Details = append(n.Details, d.desc)
+		}
+	}
+	n.Children = mysqlNodes(m)
+	// child costs are cumulative
+	if key != "query_block" && !n.HasCost {
+		for _, c := range n.Children {
+			if c.HasCost {
+				n.Cost, n.HasCost = max(n.Cost, c.Cost), true
+			}
+		}
+	}
+	return n
+}
+
+// mysqlTable converts a MySQL plan table access.
+func mysqlTable(m map[string]interface{}) *Node {
+	str := func(k string) string {
+		s, _ := m[k].(string)
+		return s
+	}
+	name := "Table Access"
+	switch str("access_type") {
+	case "ALL":
+		name = "Table Scan"
+	case "index":
+		name = "Index Scan"
+	case "range":
+		name = "Index Range Scan"
+	case "ref", "eq_ref", "ref_or_null", "fulltext":
+		name = "Index Lookup"
+	case "const", "system":
+		name = "Constant Lookup"
+	}
+	name += " on " + str("table_name")
+	if s := str("key"); s != "" {
+		name += " using " + s
+	}
+	n := &Node{Name: name}
+	if s := str("attached_condition"); s != "" {
+		n.Details = append(n.Details, "Filter: "+s)
+	}
+	read, ok1 := mysqlCost(m, "read_cost")
+	eval, ok2 := mysqlCost(m, "eval_cost")
+	n.Cost, n.HasCost = read+eval, ok1 || ok2
+	if !n.HasCost {
+		n.Cost, n.HasCost = mysqlCost(m, "prefix_cost")
+	}
+	n.Rows, n.HasRows = m["rows_produced_per_join"].(float64)
+	return n
+}
+
+// mysqlCost returns the cost from the cost info of m.
+func mysqlCost(m map[string]interface{}, key string) (float64, bool) {
+	info, _ := m["cost_info"].(map[string]interface{})
+	switch v := info[key].(type) {
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// ParseMySQLTree parses a MySQL EXPLAIN ANALYZE (or EXPLAIN FORMAT=TREE) plan.
+func ParseMySQLTree(lines []string) *Plan {
+	return ParseIndented(lines, func(s string) (*Node, bool) {
+		if !strings.HasPrefix(s, "-> ") {
+			return nil, false
+		}
+		return parseTreeLine(strings.TrimPrefix(s, "-> ")), true
+	})
+}
+
+// parseTreeLine parses the estimates and actual values of a tree plan node
+// line.
+func parseTreeLine(s string) *Node {
+	n := new(Node)
+	if m := actualRE.FindStringSubmatchIndex(s); m != nil {
+		n.HasActual = true
+		n.ActualStartupTime, _ = strconv.ParseFloat(s[m[2]:m[3]], 64)
+		n.ActualTime, _ = strconv.ParseFloat(s[m[4]:m[5]], 64)
+		n.ActualRows, _ = strconv.ParseFloat(s[m[6]:m[7]], 64)
+		n.Loops, _ = strconv.ParseFloat(s[m[8]:m[9]], 64)
+		s = s[:m[0]] + s[m[1]:]
+	}
+	if m := costRE.FindStringSubmatchIndex(s); m != nil {
+		if m[2] != -1 {
+			n.StartupCost, _ = strconv.ParseFloat(s[m[2]:m[3]], 64)
+		}
+		n.Cost, _ = strconv.ParseFloat(s[m[4]:m[5]], 64)
+		n.Rows, _ = strconv.ParseFloat(s[m[6]:m[7]], 64)
+		n.HasCost, n.HasRows = true, true
+		s = s[:m[0]] + s[m[1]:]
+	}
+	n.Name = strings.TrimSpace(s)
+	return n
+}
+
+// costRE matches the estimates of a tree plan node.
+var costRE = regexp.MustCompile(`\s*\(cost=(?:([0-9.e+-]+)\.\.)?([0-9.e+-]+) rows=([0-9.e+-]+)(?: width=[0-9]+)?\)`)
+
+// actualRE matches the actual values of a tree plan node.
+var actualRE = regexp.MustCompile(`\s*\(actual time=([0-9.e+-]+)\.\.([0-9.e+-]+) rows=([0-9.e+-]+) loops=([0-9]+)\)`)
+
+// ParseDuckDB parses a DuckDB EXPLAIN (FORMAT JSON) or EXPLAIN (ANALYZE,
+// FORMAT JSON) plan.
+func ParseDuckDB(buf []byte) (*Plan, error) {
+	var v interface{}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return nil, err
+	}
+	plan := new(Plan)
+	switch z := v.(type) {
+	case []interface{}:
+		for _, c := range z {
+			if m, ok := c.(map[string]interface{}); ok {
+				plan.Nodes = append(plan.Nodes, duckdbNode(m))
+			}
+		}
+	case map[string]interface{}:
+		// analyzed plan, skip the EXPLAIN_ANALYZE operator
+		nodes := duckdbChildren(z)
+		if len(nodes) == 1 && nodes[0].Name == "EXPLAIN_ANALYZE" {
+			nodes = nodes[0].Children
+		}
+		plan.Nodes = nodes
+		if f, ok := z["latency"].(float64); ok {
+			plan.Footer = append(plan.Footer, fmt.Sprintf("Total Time: %.3f ms", f*1000))
+		}
+	}
+	return plan, nil
+}
+
+// duckdbChildren returns the child nodes of m.
+func duckdbChildren(m map[string]interface{}) []*Node {
+	var nodes []*Node
+	children, _ := m["children"].([]interface{})
+	for _, c := range children {
+		if z, ok := c.(map[string]interface{}); ok {
+			nodes = append(nodes, duckdbNode(z))
+		}
+	}
+	return nodes
+}
+
+// duckdbNode converts a DuckDB plan node.
+func duckdbNode(m map[string]interface{}) *Node {
+	name, _ := m["name"].(string)
+	if s, ok := m["operator_name"].(string); ok {
+		name = s
+	}
+	n := &Node{Name: strings.TrimSpace(name), Children: duckdbChildren(m)}
+	info, _ := m["extra_info"].(map[string]interface{})
+	for _, k := range sortedKeys(info) {
+		var s string
+		switch v := info[k].(type) {
+		case []interface{}:
+			var z []string
+			for _, x := range v {
+				z = append(z, fmt.Sprint(x))
+			}
+			s = strings.Join(z, ", ")
+		default:
+			s = fmt.Sprint(v)
+		}
+		if k == "Estimated Cardinality" {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				n.Rows, n.HasRows = f, true
+				continue
+			}
+		}
+		n.Details = append(n.Details, k+": "+strings.ReplaceAll(s, "\n", " "))
+	}
+	// operator timing is in seconds, and excludes the children
+	if t, ok := m["operator_timing"].(float64); ok {
+		n.HasActual, n.ActualTime, n.Loops = true, t*1000, 1
+		n.ActualRows, _ = m["operator_cardinality"].(float64)
+		for _, c := range n.Children {
+			n.ActualTime += c.ActualTime
+		}
+	}
+	return n
+}
+
+// sortedKeys returns the sorted keys of m.
+func sortedKeys(m map[string]interface{}) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ParseSQLite parses a SQLite EXPLAIN QUERY PLAN plan, by querying the plan
+// for the query.
+func ParseSQLite(ctx context.Context, db Queryer, sqlstr string, bind ...interface{}) (*Plan, error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+sqlstr, bind...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	plan := new(Plan)
+	nodes := make(map[int64]*Node)
+	for rows.Next() {
+		var id, parent, notused int64
+		var detail string
+		if err := rows.Scan(&id, &parent, &notused, &detail); err != nil {
+			return nil, err
+		}
+		n := &Node{Name: detail}
+		nodes[id] = n
+		if p, ok := nodes[parent]; ok {
+			p.Children = append(p.Children, n)
+		} else {
+			plan.Nodes = append(plan.Nodes, n)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
@@ -0,0 +1,476 @@
+package metadata
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/xo/dburl"
+	"github.com/xo/tblfmt"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/text"
+)
+
+// SchemaDiff writes the differences between the tables matching the pattern
+// in the src and dst databases, ignoring tables in the system schemas (as
+// excluded by each reader). When alter is true, the statements updating the
+// dst database to match the src database are written after the differences.
+func SchemaDiff(w io.Writer, srcURL *dburl.URL, src Reader, dstURL *dburl.URL, dst Reader, pattern string, alter bool) error {
+	for _, v := range []struct {
+		u *dburl.URL
+		r Reader
+	}{{srcURL, src}, {dstURL, dst}} {
+		_, tables := v.r.(TableReader)
+		_, columns := v.r.(ColumnReader)
+		if !tables || !columns {
+			return fmt.Errorf(text.NotSupportedByDriver, `\schemadiff`, v.u.Driver)
+		}
+	}
+	sp, tp, err := parsePattern(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to parse search pattern: %w", err)
+	}
+	systemSchemas := defaultSystemSchemas()
+	srcSchema, err := readSchema(src, systemSchemas, sp, tp)
+	if err != nil {
+		return err
+	}
+	dstSchema, err := readSchema(dst, systemSchemas, sp, tp)
+	if err != nil {
+		return err
+	}
+	srcDialect, ok := lookupDialect(srcURL.Driver)
+	if !ok {
+		srcDialect = dialects["generic"]
+	}
+	dstDialect, ok := lookupDialect(dstURL.Driver)
+	if !ok {
+		dstDialect = dialects["generic"]
+	}
+	diff := newSchemaDiff(srcSchema, dstSchema, srcDialect, dstDialect)
+	if len(diff.differences) == 0 {
+		fmt.Fprintln(w, text.NoSchemaDifferences)
+		return nil
+	}
+	params := env.Vars().Print()
+	params["title"] = "Schema differences"
+	if err := tblfmt.EncodeAll(w, NewDifferenceSet(diff.differences), params); err != nil {
+		return err
+	}
+	if !alter {
+		return nil
+	}
+	fmt.Fprintln(w)
+	return writeStatements(w, diff.statements())
+}
+
+// schemaDiff are the differences between a source and destination schema, and
+// the statements updating the destination schema to match the source.
+type schemaDiff struct {
+	src, dst    *dialect
+	d           *dumpSchema
+	differences []Difference
+	// statements, in the order they are applied
+	drops, dropTables, addTables, columns, indexes, constraints []string
+}
+
+// newSchemaDiff compares the tables of the schemas.
+func newSchemaDiff(srcSchema, dstSchema *dumpSchema, src, dst *dialect) *schemaDiff {
+	diff := &schemaDiff{
+		src: src,
+		dst: dst,
+		d:   &dumpSchema{qualify: srcSchema.qualify},
+	}
+	key := func(t *dumpTable) string {
+		if srcSchema.qualify {
+			return strings.ToLower(t.Schema + "." + t.Name)
+		}
+		return strings.ToLower(t.Name)
+	}
+	dstTables := make(map[string]*dumpTable)
+	for _, t := range dstSchema.tables {
+		dstTables[key(t)] = t
+	}
+	added := &dumpSchema{qualify: srcSchema.qualify}
+	seen := make(map[string]bool)
+	for _, t := range srcSchema.tables {
+		seen[key(t)] = true
+		dt, ok := dstTables[key(t)]
+		if !ok {
+			diff.add("added", "table", diff.tableName(t), describeTable(t), "")
+			added.tables = append(added.tables, t)
+			continue
+		}
+		diff.compareTables(t, dt)
+	}
+	for _, t := range dstSchema.tables {
+		if !seen[key(t)] {
+			diff.add("removed", "table", diff.tableName(t), "", describeTable(t))
+			diff.dropTables = append(diff.dropTables, "DROP TABLE "+diff.d.name(dst, t.Schema, t.Name)+";")
+		}
+	}
+	if len(added.tables) != 0 {
+		diff.addTables = added.statements(src, dst)
+	}
+	return diff
+}
+
+// add adds a difference.
+func (diff *schemaDiff) add(change, typ, name, src, dst string) {
+	diff.differences = append(diff.differences, Difference{
+		Change:      change,
+		Type:        typ,
+		Name:        name,
+		Source:      src,
+		Destination: dst,
+	})
+}
+
+// tableName returns the displayed name of the table.
+func (diff *schemaDiff) tableName(t *dumpTable) string {
+	if diff.d.qualify && t.Schema != "" {
+		return t.Schema + "." + t.Name
+	}
+	return t.Name
+}
+
+// statements returns the statements updating the destination schema.
+func (diff *schemaDiff) statements() []string {
+	var stmts []string
+	for _, v := range [][]string{diff.drops, diff.dropTables, diff.addTables, diff.columns, diff.indexes, diff.constraints} {
+		stmts = append(stmts, v...)
+	}
+	return stmts
+}
+
+// unsupported returns the statement as a comment, when the statement is not
+// supported by the destination dialect.
+func (diff *schemaDiff) unsupported(stmt string) string {
+	return fmt.Sprintf("-- not supported by dialect %s: %s", diff.dst.name, stmt)
+}
+
+// compareTables compares the columns, indexes and constraints of the tables.
+func (diff *schemaDiff) compareTables(st, dt *dumpTable) {
+	table := diff.d.name(diff.dst, dt.Schema, dt.Name)
+	// columns
+	dstColumns := make(map[string]Column)
+	for _, c := range dt.columns {
+		dstColumns[strings.ToLower(c.Name)] = c
+	}
+	srcColumns := make(map[string]bool)
+	for _, c := range st.columns {
+		srcColumns[strings.ToLower(c.Name)] = true
+		name := diff.tableName(dt) + "." + c.Name
+		dc, ok := dstColumns[strings.ToLower(c.Name)]
+		if !ok {
+			diff.add("added", "column", name, describeColumn(c), "")
+			def, comment := columnDefinition(c, diff.src, diff.dst)
+			diff.columns = append(diff.columns, diff.addColumn(table, def, comment))
+			continue
+		}
+		typ, null, def := !diff.sameType(c, dc), c.IsNullable != dc.IsNullable, !diff.sameDefault(c, dc)
+		if typ || null || def {
+			diff.add("changed", "column", name, describeColumn(c), describeColumn(dc))
+			diff.columns = append(diff.columns, diff.alterColumn(table, c, typ, null, def)...)
+		}
+	}
+	for _, c := range dt.columns {
+		if !srcColumns[strings.ToLower(c.Name)] {
+			diff.add("removed", "column", diff.tableName(dt)+"."+c.Name, "", describeColumn(c))
+			diff.columns = append(diff.columns, "ALTER TABLE "+table+" DROP COLUMN "+diff.dst.ident(c.Name)+";")
+		}
+	}
+	// indexes
+	indexes := func(t *dumpTable) map[string]*dumpIndex {
+		m := make(map[string]*dumpIndex)
+		for _, i := range t.indexes {
+			if len(i.columns) != 0 && !t.isConstraintIndex(i) {
+				m[strings.ToLower(i.Name)] = i
+			}
+		}
+		return m
+	}
+	srcIndexes, dstIndexes := indexes(st), indexes(dt)
+	for _, k := range slices.Sorted(maps.Keys(srcIndexes)) {
+		i := srcIndexes[k]
+		name := diff.tableName(dt) + "." + i.Name
+		di, ok := dstIndexes[k]
+		switch {
+		case !ok:
+			diff.add("added", "index", name, describeIndex(i), "")
+		case i.IsUnique != di.IsUnique || !strings.EqualFold(describeIndex(i), describeIndex(di)):
+			diff.add("changed", "index", name, describeIndex(i), describeIndex(di))
+			diff.drops = append(diff.drops, diff.dropIndex(dt, di))
+		default:
+			continue
+		}
+		diff.indexes = append(diff.indexes, diff.d.createIndex(diff.dst, dt, i))
+	}
+	for _, k := range slices.Sorted(maps.Keys(dstIndexes)) {
+		if i := dstIndexes[k]; srcIndexes[k] == nil {
+			diff.add("removed", "index", diff.tableName(dt)+"."+i.Name, "", describeIndex(i))
+			diff.drops = append(diff.drops, diff.dropIndex(dt, i))
+		}
+	}
+	// constraints
+	srcConstraints, dstConstraints := constraintKeys(st), constraintKeys(dt)
+	var add []*dumpConstraint
+	for _, k := range slices.Sorted(maps.Keys(srcConstraints)) {
+		c := srcConstraints[k]
+		name := diff.tableName(dt) + "." + c.Name
+		dc, ok := dstConstraints[k]
+		switch {
+		case !ok:
+			diff.add("added", "constraint", name, describeConstraint(c), "")
+		case !strings.EqualFold(describeConstraint(c), describeConstraint(dc)):
+			diff.add("changed", "constraint", name, describeConstraint(c), describeConstraint(dc))
+			diff.drops = append(diff.drops, diff.dropConstraint(table, dc))
+		default:
+			continue
+		}
+		add = append(add, c)
+	}
+	for _, k := range slices.Sorted(maps.Keys(dstConstraints)) {
+		if c := dstConstraints[k]; srcConstraints[k] == nil {
+			diff.add("removed", "constraint", diff.tableName(dt)+"."+c.Name, "", describeConstraint(c))
+			diff.drops = append(diff.drops, diff.dropConstraint(table, c))
+		}
+	}
+	// foreign keys last, as they may reference the other constraints
+	sort.SliceStable(add, func(i, j int) bool {
+		return constraintOrder[add[i].Type] < constraintOrder[add[j].Type]
+	})
+	for _, c := range add {
+		def := c.definition(diff.dst, func(schema, n string) string {
+			return diff.d.name(diff.dst, schema, n)
+		})
+		stmt := "ALTER TABLE " + table + " ADD " + def + ";"
+		if !diff.dst.alter {
+			stmt = diff.unsupported(stmt)
+		}
+		diff.constraints = append(diff.constraints, stmt)
+	}
+}
+
+// sameType returns true when the columns have the same data type.
+func (diff *schemaDiff) sameType(a, b Column) bool {
+	if diff.src == diff.dst {
+		return strings.EqualFold(strings.Join(strings.Fields(a.DataType), " "), strings.Join(strings.Fields(b.DataType), " "))
+	}
+	at, aargs, aok := canonicalType(a.DataType)
+	bt, bargs, bok := canonicalType(b.DataType)
+	if !aok || !bok {
+		return strings.EqualFold(a.DataType, b.DataType)
+	}
+	return at == bt && (aargs == bargs || aargs == "" || bargs == "")
+}
+
+// sameDefault returns true when the columns have the same default value.
+func (diff *schemaDiff) sameDefault(a, b Column) bool {
+	if a.Default == b.Default || diff.src == diff.dst {
+		return a.Default == b.Default
+	}
+	generic := dialects["generic"]
+	av, aok := convertDefault(a.Default, a.DataType, diff.src, generic)
+	bv, bok := convertDefault(b.Default, b.DataType, diff.dst, generic)
+	return aok && bok && strings.EqualFold(av, bv)
+}
+
+// addColumn returns the statement adding the column definition to the table.
+func (diff *schemaDiff) addColumn(table, def, comment string) string {
+	stmt := "ALTER TABLE " + table + " ADD COLUMN " + def + ";"
+	switch diff.dst.name {
+	case "sqlserver":
+		stmt = "ALTER TABLE " + table + " ADD " + def + ";"
+	case "oracle":
+		stmt = "ALTER TABLE " + table + " ADD (" + def + ");"
+	}
+	if comment != "" {
+		stmt += " -- " + comment
+	}
+	return stmt
+}
+
+// alterColumn returns the statements changing the data type, nullability, or
+// default value of the column to the source column's.
+func (diff *schemaDiff) alterColumn(table string, c Column, typ, null, def bool) []string {
+	name := diff.dst.ident(c.Name)
+	dataType := convertType(c.DataType, diff.src, diff.dst)
+	value, ok := convertDefault(c.Default, c.DataType, diff.src, diff.dst)
+	switch {
+	case c.Default == "":
+		value = ""
+	case !ok && def:
+		// the default can not be converted
+		def = false
+	}
+	var stmts []string
+	alter := func(s string) {
+		stmts = append(stmts, "ALTER TABLE "+table+" "+s+";")
+	}
+	switch diff.dst.name {
+	case "mysql":
+		d, _ := columnDefinition(c, diff.src, diff.dst)
+		alter("MODIFY COLUMN " + d)
+		return stmts
+	case "sqlite":
+		d, _ := columnDefinition(c, diff.src, diff.dst)
+		return []string{diff.unsupported("ALTER TABLE " + table + " ALTER COLUMN " + d + ";")}
+	case "sqlserver":
+		if typ || null {
+			s := "ALTER COLUMN " + name + " " + dataType + " NULL"
+			if c.IsNullable == NO {
+				s = "ALTER COLUMN " + name + " " + dataType + " NOT NULL"
+			}
+			alter(s)
+		}
+		if def {
+			stmts = append(stmts, diff.unsupported("ALTER TABLE "+table+" ALTER COLUMN "+name+" SET DEFAULT "+value+";"))
+		}
+		return stmts
+	case "oracle":
+		if typ {
+			alter("MODIFY (" + name + " " + dataType + ")")
+		}
+		if null && c.IsNullable == NO {
+			alter("MODIFY (" + name + " NOT NULL)")
+		} else if null {
+			alter("MODIFY (" + name + " NULL)")
+		}
+		if def && value == "" {
+			alter("MODIFY (" + name + " DEFAULT NULL)")
+		} else if def {
+			alter("MODIFY (" + name + " DEFAULT " + value + ")")
+		}
+		return stmts
+	}
+	if typ && diff.dst.name == "generic" {
+		alter("ALTER COLUMN " + name + " SET DATA TYPE " + dataType)
+	} else if typ {
+		alter("ALTER COLUMN " + name + " TYPE " + dataType)
+	}
+	if null && c.IsNullable == NO {
+		alter("ALTER COLUMN " + name + " SET NOT NULL")
+	} else if null {
+		alter("ALTER COLUMN " + name + " DROP NOT NULL")
+	}
+	if def && value == "" {
+		alter("ALTER COLUMN " + name + " DROP DEFAULT")
+	} else if def {
+		alter("ALTER COLUMN " + name + " SET DEFAULT " + value)
+	}
+	return stmts
+}
+
+// dropIndex returns the statement dropping the table's index.
+func (diff *schemaDiff) dropIndex(t *dumpTable, i *dumpIndex) string {
+	switch diff.dst.name {
+	case "mysql", "sqlserver":
+		return "DROP INDEX " + diff.dst.ident(i.Name) + " ON " + diff.d.name(diff.dst, t.Schema, t.Name) + ";"
+	}
+	return "DROP INDEX " + diff.d.name(diff.dst, t.Schema, i.Name) + ";"
+}
+
+// dropConstraint returns the statement dropping the table's constraint.
+func (diff *schemaDiff) dropConstraint(table string, c *dumpConstraint) string {
+	stmt := "ALTER TABLE " + table + " DROP CONSTRAINT " + diff.dst.ident(c.Name) + ";"
+	if diff.dst.name == "mysql" {
+		switch c.Type {
+		case "PRIMARY KEY":
+			stmt = "ALTER TABLE " + table + " DROP PRIMARY KEY;"
+		case "FOREIGN KEY":
+			stmt = "ALTER TABLE " + table + " DROP FOREIGN KEY " + diff.dst.ident(c.Name) + ";"
+		case "UNIQUE":
+			stmt = "ALTER TABLE " + table + " DROP INDEX " + diff.dst.ident(c.Name) + ";"
+		}
+	}
+	if !diff.dst.alter {
+		return diff.unsupported(stmt)
+	}
+	return stmt
+}
+
+// constraintKeys returns the constraints of the table, keyed so that the
+// same constraint matches across databases that name constraints
+// differently: primary keys by type, unique and foreign keys by their
+// columns, and check constraints by their name.
+func constraintKeys(t *dumpTable) map[string]*dumpConstraint {
+	m := make(map[string]*dumpConstraint)
+	for _, c := range t.constraints {
+		switch c.Type {
+		case "PRIMARY KEY":
+			m["p"] = c
+		case "UNIQUE":
+			m["u:"+strings.ToLower(strings.Join(c.columns, ","))] = c
+		case "FOREIGN KEY":
+			m["f:"+strings.ToLower(strings.Join(c.columns, ","))] = c
+		case "CHECK":
+			m["c:"+strings.ToLower(c.Name)] = c
+		}
+	}
+	return m
+}
+
+// describeTable returns a description of the table's number of columns.
+func describeTable(t *dumpTable) string {
+	if len(t.columns) == 1 {
+		return "1 column"
+	}
+	return fmt.Sprintf("%d columns", len(t.columns))
+}
+
+// describeColumn returns a description of the column's data type,
+// nullability, and default value.
+func describeColumn(c Column) string {
+	s := c.DataType
+	if c.IsNullable == NO {
+		s += " NOT NULL"
+	}
+	if c.Default != "" {
+		s += " DEFAULT " + c.Default
+	}
+	return strings.TrimSpace(s)
+}
+
+// describeIndex returns a description of the index's uniqueness and columns.
+func describeIndex(i *dumpIndex) string {
+	s := "(" + strings.Join(i.columns, ", ") + ")"
+	if i.IsUnique == YES {
+		s = "UNIQUE " + s
+	}
+	return s
+}
+
+// describeConstraint returns a description of the constraint.
+func describeConstraint(c *dumpConstraint) string {
+	switch c.Type {
+	case "CHECK":
+		return "CHECK " + normalizeCheck(c.CheckClause)
+	case "FOREIGN KEY":
+		s := "FOREIGN KEY (" + strings.Join(c.columns, ", ") + ") REFERENCES " + c.ForeignTable + " (" + strings.Join(c.foreignColumns, ", ") + ")"
+		if rule := strings.ToUpper(c.UpdateRule); rule != "" && rule != "NO ACTION" {
+			s += " ON UPDATE " + rule
+		}
+		if rule := strings.ToUpper(c.DeleteRule); rule != "" && rule != "NO ACTION" {
+			s += " ON DELETE " + rule
+		}
+		return s
+	}
+	return c.Type + " (" + strings.Join(c.columns, ", ") + ")"
+}
+
+// normalizeCheck normalizes the white space and enclosing parentheses of a
+// check clause.
+func normalizeCheck(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	for strings.HasPrefix(s, "((") && strings.HasSuffix(s, "))") {
+		s = s[1 : len(s)-1]
+	}
+	if !strings.HasPrefix(s, "(") {
+		s = "(" + s + ")"
+	}
+	return s
+}
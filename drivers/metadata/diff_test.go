@@ -0,0 +1,84 @@
+package metadata
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaDiff(t *testing.T) {
+	src := &dumpSchema{tables: []*dumpTable{
+		{
+			Table: Table{Name: "a"},
+			columns: []Column{
+				{Name: "id", DataType: "INTEGER", IsNullable: NO},
+				{Name: "name", DataType: "varchar(20)", IsNullable: NO, Default: "'x'"},
+				{Name: "created", DataType: "datetime", IsNullable: YES},
+			},
+			constraints: []*dumpConstraint{
+				{Constraint: Constraint{Table: "a", Name: "a_pkey", Type: "PRIMARY KEY"}, columns: []string{"id"}},
+				{Constraint: Constraint{Table: "a", Name: "a_name_key", Type: "UNIQUE"}, columns: []string{"name"}},
+			},
+			indexes: []*dumpIndex{
+				{Index: Index{Name: "a_created_idx", IsUnique: NO}, columns: []string{"created"}},
+			},
+		},
+		{
+			Table:   Table{Name: "b"},
+			columns: []Column{{Name: "id", DataType: "integer", IsNullable: NO}},
+		},
+	}}
+	dst := &dumpSchema{tables: []*dumpTable{
+		{
+			Table: Table{Schema: "public", Name: "a"},
+			columns: []Column{
+				{Name: "id", DataType: "integer", IsNullable: NO},
+				{Name: "name", DataType: "text", IsNullable: YES},
+				{Name: "old", DataType: "integer", IsNullable: YES},
+			},
+			constraints: []*dumpConstraint{
+				{Constraint: Constraint{Schema: "public", Table: "a", Name: "a_pkey", Type: "PRIMARY KEY"}, columns: []string{"id"}},
+			},
+			indexes: []*dumpIndex{
+				{Index: Index{Name: "a_pkey", IsUnique: YES}, columns: []string{"id"}},
+				{Index: Index{Name: "a_old_idx", IsUnique: NO}, columns: []string{"old"}},
+			},
+		},
+		{
+			Table:   Table{Schema: "public", Name: "c"},
+			columns: []Column{{Name: "id", DataType: "integer", IsNullable: NO}},
+		},
+	}}
+	diff := newSchemaDiff(src, dst, dialects["sqlite"], dialects["postgres"])
+	var differences []string
+	for _, d := range diff.differences {
+		differences = append(differences, strings.Join([]string{d.Change, d.Type, d.Name, d.Source, d.Destination}, "|"))
+	}
+	expDifferences := []string{
+		"changed|column|a.name|varchar(20) NOT NULL DEFAULT 'x'|text",
+		"added|column|a.created|datetime|",
+		"removed|column|a.old||integer",
+		"added|index|a.a_created_idx|(created)|",
+		"removed|index|a.a_old_idx||(old)",
+		"added|constraint|a.a_name_key|UNIQUE (name)|",
+		"added|table|b|1 column|",
+		"removed|table|c||1 column",
+	}
+	if s, exp := strings.Join(differences, "\n"), strings.Join(expDifferences, "\n"); s != exp {
+		t.Errorf("expected differences:\n%s\ngot:\n%s", exp, s)
+	}
+	expStatements := []string{
+		`DROP INDEX "a_old_idx";`,
+		`DROP TABLE "c";`,
+		"CREATE TABLE \"b\" (\n  \"id\" integer NOT NULL\n);",
+		`ALTER TABLE "a" ALTER COLUMN "name" TYPE varchar(20);`,
+		`ALTER TABLE "a" ALTER COLUMN "name" SET NOT NULL;`,
+		`ALTER TABLE "a" ALTER COLUMN "name" SET DEFAULT 'x';`,
+		`ALTER TABLE "a" ADD COLUMN "created" timestamp;`,
+		`ALTER TABLE "a" DROP COLUMN "old";`,
+		`CREATE INDEX "a_created_idx" ON "a" ("created");`,
+		`ALTER TABLE "a" ADD CONSTRAINT "a_name_key" UNIQUE ("name");`,
+	}
+	if s, exp := strings.Join(diff.statements(), "\n"), strings.Join(expStatements, "\n"); s != exp {
+		t.Errorf("expected statements:\n%s\ngot:\n%s", exp, s)
+	}
+}
@@ -0,0 +1,924 @@
+package metadata
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/text"
+)
+
+// dialect is a SQL dialect that DDL statements are written in.
+type dialect struct {
+	name string
+	// quote are the opening and closing identifier quotes
+	quote [2]string
+	// schemas, sequences, and alter indicate support for CREATE SCHEMA, CREATE
+	// SEQUENCE, and ALTER TABLE ... ADD CONSTRAINT
+	schemas, sequences, alter bool
+	// ifNotExists indicates support for CREATE SCHEMA IF NOT EXISTS
+	ifNotExists bool
+	// booleans indicates TRUE and FALSE literals are supported
+	booleans bool
+	// onUpdate indicates ON UPDATE actions of foreign keys are supported
+	onUpdate bool
+	// types are the formats of the canonical types, with and without
+	// arguments
+	types map[string][2]string
+}
+
+// ansiTypes are the formats of the canonical types in the generic dialect.
+var ansiTypes = map[string][2]string{
+	"integer":     {"", "integer"},
+	"smallint":    {"", "smallint"},
+	"bigint":      {"", "bigint"},
+	"boolean":     {"", "boolean"},
+	"real":        {"", "real"},
+	"double":      {"", "double precision"},
+	"numeric":     {"numeric(%s)", "numeric"},
+	"varchar":     {"varchar(%s)", "varchar(255)"},
+	"char":        {"char(%s)", "char(1)"},
+	"text":        {"", "clob"},
+	"blob":        {"", "blob"},
+	"date":        {"", "date"},
+	"time":        {"", "time"},
+	"timestamp":   {"", "timestamp"},
+	"timestamptz": {"", "timestamp with time zone"},
+	"json":        {"", "clob"},
+	"uuid":        {"", "char(36)"},
+}
+
+// types returns the generic type formats with the overrides applied.
+func types(overrides map[string][2]string) map[string][2]string {
+	m := make(map[string][2]string, len(ansiTypes))
+	for k, v := range ansiTypes {
+		m[k] = v
+	}
+	for k, v := range overrides {
+		m[k] = v
+	}
+	return m
+}
+
+// dialects are the known dialects.
+var dialects = map[string]*dialect{
+	"generic": {
+		name:      "generic",
+		quote:     [2]string{`"`, `"`},
+		schemas:   true,
+		sequences: true,
+		alter:     true,
+		booleans:  true,
+		onUpdate:  true,
+		types:     ansiTypes,
+	},
+	"postgres": {
+		name:        "postgres",
+		quote:       [2]string{`"`, `"`},
+		schemas:     true,
+		sequences:   true,
+		alter:       true,
+		ifNotExists: true,
+		booleans:    true,
+		onUpdate:    true,
+		types: types(map[string][2]string{
+			"varchar":     {"varchar(%s)", "varchar"},
+			"char":        {"char(%s)", "char"},
+			"text":        {"", "text"},
+			"blob":        {"", "bytea"},
+			"timestamptz": {"", "timestamptz"},
+			"json":        {"", "jsonb"},
+			"uuid":        {"", "uuid"},
+		}),
+	},
+	"mysql": {
+		name:        "mysql",
+		quote:       [2]string{"`", "`"},
+		schemas:     true,
+		alter:       true,
+		ifNotExists: true,
+		booleans:    true,
+		onUpdate:    true,
+		types: types(map[string][2]string{
+			"integer":     {"", "int"},
+			"real":        {"", "float"},
+			"double":      {"", "double"},
+			"numeric":     {"decimal(%s)", "decimal"},
+			"text":        {"", "longtext"},
+			"blob":        {"", "longblob"},
+			"timestamp":   {"", "datetime"},
+			"timestamptz": {"", "timestamp"},
+			"json":        {"", "json"},
+		}),
+	},
+	"sqlite": {
+		name:     "sqlite",
+		quote:    [2]string{`"`, `"`},
+		booleans: true,
+		onUpdate: true,
+		types: types(map[string][2]string{
+			"double":      {"", "double"},
+			"varchar":     {"varchar(%s)", "varchar"},
+			"char":        {"char(%s)", "char"},
+			"text":        {"", "text"},
+			"timestamptz": {"", "timestamp"},
+			"json":        {"", "text"},
+			"uuid":        {"", "text"},
+		}),
+	},
+	"sqlserver": {
+		name:      "sqlserver",
+		quote:     [2]string{"[", "]"},
+		schemas:   true,
+		sequences: true,
+		alter:     true,
+		onUpdate:  true,
+		types: types(map[string][2]string{
+			"integer":     {"", "int"},
+			"boolean":     {"", "bit"},
+			"double":      {"", "float"},
+			"numeric":     {"decimal(%s)", "decimal"},
+			"varchar":     {"nvarchar(%s)", "nvarchar(max)"},
+			"char":        {"nchar(%s)", "nchar(1)"},
+			"text":        {"", "nvarchar(max)"},
+			"blob":        {"", "varbinary(max)"},
+			"timestamp":   {"", "datetime2"},
+			"timestamptz": {"", "datetimeoffset"},
+			"json":        {"", "nvarchar(max)"},
+			"uuid":        {"", "uniqueidentifier"},
+		}),
+	},
+	"oracle": {
+		name:      "oracle",
+		quote:     [2]string{`"`, `"`},
+		sequences: true,
+		alter:     true,
+		types: types(map[string][2]string{
+			"integer":  {"", "number(10)"},
+			"smallint": {"", "number(5)"},
+			"bigint":   {"", "number(19)"},
+			"boolean":  {"", "number(1)"},
+			"real":     {"", "binary_float"},
+			"double":   {"", "binary_double"},
+			"numeric":  {"number(%s)", "number"},
+			"varchar":  {"varchar2(%s)", "varchar2(4000)"},
+			"time":     {"", "timestamp"},
+			"json":     {"", "clob"},
+			"uuid":     {"", "varchar2(36)"},
+		}),
+	},
+	"duckdb": {
+		name:        "duckdb",
+		quote:       [2]string{`"`, `"`},
+		schemas:     true,
+		sequences:   true,
+		ifNotExists: true,
+		booleans:    true,
+		onUpdate:    true,
+		types: types(map[string][2]string{
+			"double":      {"", "double"},
+			"numeric":     {"decimal(%s)", "decimal"},
+			"varchar":     {"", "varchar"},
+			"char":        {"", "varchar"},
+			"text":        {"", "varchar"},
+			"timestamptz": {"", "timestamptz"},
+			"json":        {"", "json"},
+			"uuid":        {"", "uuid"},
+		}),
+	},
+}
+
+// dialectAliases are the driver names and aliases of the known dialects.
+var dialectAliases = map[string]string{
+	"pgx":           "postgres",
+	"pq":            "postgres",
+	"pg":            "postgres",
+	"postgresql":    "postgres",
+	"cockroachdb":   "postgres",
+	"redshift":      "postgres",
+	"mymysql":       "mysql",
+	"my":            "mysql",
+	"mariadb":       "mysql",
+	"tidb":          "mysql",
+	"vitess":        "mysql",
+	"memsql":        "mysql",
+	"sqlite3":       "sqlite",
+	"moderncsqlite": "sqlite",
+	"sq":            "sqlite",
+	"mssql":         "sqlserver",
+	"ms":            "sqlserver",
+	"azuresql":      "sqlserver",
+	"godror":        "oracle",
+	"or":            "oracle",
+	"ora":           "oracle",
+	"dk":            "duckdb",
+}
+
+// lookupDialect returns the dialect with the name or alias.
+func lookupDialect(name string) (*dialect, bool) {
+	name = strings.ToLower(name)
+	if s, ok := dialectAliases[name]; ok {
+		name = s
+	}
+	d, ok := dialects[name]
+	return d, ok
+}
+
+// ident quotes the identifier.
+func (d *dialect) ident(name string) string {
+	return d.quote[0] + strings.ReplaceAll(name, d.quote[1], d.quote[1]+d.quote[1]) + d.quote[1]
+}
+
+// idents quotes the identifiers, joining them with a comma.
+func (d *dialect) idents(names []string) string {
+	v := make([]string, len(names))
+	for i, name := range names {
+		v[i] = d.ident(name)
+	}
+	return strings.Join(v, ", ")
+}
+
+// typeSynonyms are the canonical types of the data types of the different
+// databases.
+var typeSynonyms = map[string]string{
+	"int": "integer", "integer": "integer", "int4": "integer", "mediumint": "integer", "serial": "integer", "serial4": "integer",
+	"smallint": "smallint", "int2": "smallint", "tinyint": "smallint", "smallserial": "smallint", "serial2": "smallint",
+	"bigint": "bigint", "int8": "bigint", "bigserial": "bigint", "serial8": "bigint",
+	"bool": "boolean", "boolean": "boolean", "bit": "boolean",
+	"real": "real", "float4": "real", "binary_float": "real",
+	"double": "double", "double precision": "double", "float8": "double", "float": "double", "binary_double": "double",
+	"numeric": "numeric", "decimal": "numeric", "number": "numeric",
+	"varchar": "varchar", "character varying": "varchar", "nvarchar": "varchar", "varchar2": "varchar", "nvarchar2": "varchar",
+	"char": "char", "character": "char", "nchar": "char", "bpchar": "char",
+	"text": "text", "clob": "text", "nclob": "text", "ntext": "text", "tinytext": "text", "mediumtext": "text", "longtext": "text",
+	"blob": "blob", "bytea": "blob", "binary": "blob", "varbinary": "blob", "image": "blob", "tinyblob": "blob", "mediumblob": "blob", "longblob": "blob",
+	"date": "date",
+	"time": "time", "time without time zone": "time",
+	"timestamp": "timestamp", "timestamp without time zone": "timestamp", "datetime": "timestamp", "datetime2": "timestamp", "smalldatetime": "timestamp",
+	"timestamptz": "timestamptz", "timestamp with time zone": "timestamptz", "datetimeoffset": "timestamptz",
+	"json": "json", "jsonb": "json",
+	"uuid": "uuid", "uniqueidentifier": "uuid",
+}
+
+// typeRE matches a data type and its arguments.
+var typeRE = regexp.MustCompile(`^([^(]*?)\s*(?:\(([^)]*)\))?\s*([^()]*)$`)
+
+// canonicalType returns the canonical type and arguments of the data type.
+func canonicalType(typ string) (string, string, bool) {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if typ == "" {
+		// untyped sqlite columns
+		return "text", "", true
+	}
+	m := typeRE.FindStringSubmatch(typ)
+	if m == nil {
+		return "", "", false
+	}
+	name, args, suffix := m[1], strings.ReplaceAll(m[2], " ", ""), strings.TrimSpace(strings.ReplaceAll(m[3], "unsigned", ""))
+	if suffix != "" {
+		// ie, timestamp(6) with time zone
+		name += " " + suffix
+	}
+	name = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(name), "unsigned"))
+	switch {
+	case name == "tinyint" && args == "1", name == "bit" && (args == "" || args == "1"):
+		return "boolean", "", true
+	case name == "bit":
+		return "", "", false
+	}
+	s, ok := typeSynonyms[name]
+	switch {
+	case !ok:
+		return "", "", false
+	case (s == "varchar" || s == "blob") && (args == "max" || args == "-1"):
+		s, args = "text", ""
+	}
+	return s, args, true
+}
+
+// convertType converts the data type from the source to the destination
+// dialect. Unknown data types are not converted.
+func convertType(typ string, src, dst *dialect) string {
+	if src == dst {
+		return typ
+	}
+	s, args, ok := canonicalType(typ)
+	if !ok {
+		return typ
+	}
+	f := dst.types[s]
+	if args != "" && f[0] != "" {
+		return fmt.Sprintf(f[0], args)
+	}
+	return f[1]
+}
+
+var (
+	// castRE matches a trailing PostgreSQL cast.
+	castRE = regexp.MustCompile(`::[a-zA-Z ]+(?:\([0-9, ]*\))?(?:\[\])?$`)
+	// numberRE matches a numeric literal.
+	numberRE = regexp.MustCompile(`^[-+]?[0-9]+(?:\.[0-9]*)?(?:[eE][-+]?[0-9]+)?$`)
+	// stringRE matches a string literal.
+	stringRE = regexp.MustCompile(`^[nN]?'(?:[^']|'')*'$`)
+	// nextvalRE matches the sequence of a nextval default.
+	nextvalRE = regexp.MustCompile(`(?i)nextval\('([^']+)'`)
+)
+
+// convertDefault converts the default value of a column with the data type
+// from the source to the destination dialect, returning false when the value
+// can not be converted.
+func convertDefault(def, typ string, src, dst *dialect) (string, bool) {
+	if src == dst {
+		return def, true
+	}
+	s := strings.TrimSpace(def)
+	for strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	s = castRE.ReplaceAllString(s, "")
+	canonical, _, _ := canonicalType(typ)
+	boolean := func(b bool) string {
+		switch {
+		case dst.booleans && b:
+			return "TRUE"
+		case dst.booleans:
+			return "FALSE"
+		case b:
+			return "1"
+		}
+		return "0"
+	}
+	switch u := strings.ToUpper(s); {
+	case u == "NULL":
+		return "NULL", true
+	case u == "TRUE" || u == "FALSE":
+		return boolean(u == "TRUE"), true
+	case canonical == "boolean" && (s == "0" || s == "1" || s == "'0'" || s == "'1'" || s == "b'0'" || s == "b'1'"):
+		return boolean(strings.Contains(s, "1")), true
+	case numberRE.MatchString(s):
+		return s, true
+	case stringRE.MatchString(s):
+		return strings.TrimLeft(s, "nN"), true
+	case u == "CURRENT_DATE" || u == "CURRENT_TIME" || u == "CURRENT_TIMESTAMP":
+		return u, true
+	case u == "NOW()" || u == "CURRENT_TIMESTAMP()" || u == "GETDATE()" || u == "SYSDATE" || u == "LOCALTIMESTAMP" || u == "SYSDATETIME()":
+		return "CURRENT_TIMESTAMP", true
+	}
+	return "", false
+}
+
+// dumpTable is a table with its columns, constraints, indexes, and triggers.
+type dumpTable struct {
+	Table
+	columns     []Column
+	constraints []*dumpConstraint
+	indexes     []*dumpIndex
+	triggers    []Trigger
+}
+
+// dumpConstraint is a constraint with its columns.
+type dumpConstraint struct {
+	Constraint
+	columns, foreignColumns []string
+	// deferred indicates the constraint is added after all tables were
+	// created, as it is part of a foreign key cycle
+	deferred bool
+}
+
+// dumpIndex is an index with its columns.
+type dumpIndex struct {
+	Index
+	columns []string
+}
+
+// dumpSchema is the model of the dumped database objects.
+type dumpSchema struct {
+	// qualify indicates the names are qualified with the schema
+	qualify   bool
+	schemas   []string
+	sequences []Sequence
+	tables    []*dumpTable
+}
+
+// Dump writes the CREATE statements of the schemas, sequences, and tables
+// matching the pattern, along with the tables' constraints, indexes, and
+// triggers, in the dialect (or in the dialect of the driver, when empty).
+// Triggers are only written as-is when the dialect is the driver's dialect,
+// and are otherwise written as comments, as their definitions can not be
+// converted.
+func (w DefaultWriter) Dump(u *dburl.URL, pattern, dialectName string) error {
+	if _, ok := w.r.(TableReader); !ok {
+		return fmt.Errorf(text.NotSupportedByDriver, `\dump`, u.Driver)
+	}
+	if _, ok := w.r.(ColumnReader); !ok {
+		return fmt.Errorf(text.NotSupportedByDriver, `\dump`, u.Driver)
+	}
+	src, ok := lookupDialect(u.Driver)
+	if !ok {
+		src = dialects["generic"]
+	}
+	dst := src
+	if dialectName != "" {
+		if dst, ok = lookupDialect(dialectName); !ok {
+			return fmt.Errorf(text.UnknownDialect, dialectName)
+		}
+	}
+	sp, tp, err := parsePattern(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to parse search pattern: %w", err)
+	}
+	d, err := readSchema(w.r, w.systemSchemas, sp, tp)
+	if err != nil {
+		return err
+	}
+	if len(d.tables) == 0 && len(d.sequences) == 0 {
+		fmt.Fprintf(w.w, text.RelationNotFound, pattern)
+		fmt.Fprintln(w.w)
+		return nil
+	}
+	return d.write(w.w, src, dst)
+}
+
+// readSchema reads the tables, and their related objects, matching the schema
+// and table patterns, ignoring tables in the system schemas.
+func readSchema(reader Reader, systemSchemas map[string]struct{}, sp, tp string) (*dumpSchema, error) {
+	d := &dumpSchema{qualify: sp != ""}
+	filter := Filter{Schema: sp, Parent: tp, OnlyVisible: sp == ""}
+	type key struct{ schema, table string }
+	// tables
+	res, err := reader.(TableReader).Tables(Filter{Schema: sp, Name: tp, Types: []string{"TABLE", "BASE TABLE"}, OnlyVisible: sp == ""})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer res.Close()
+	tables := make(map[key]*dumpTable)
+	schemas := make(map[string]bool)
+	for res.Next() {
+		t := res.Get()
+		if _, ok := systemSchemas[t.Schema]; ok {
+			continue
+		}
+		dt := &dumpTable{Table: *t}
+		d.tables = append(d.tables, dt)
+		tables[key{t.Schema, t.Name}] = dt
+		if t.Schema != "" && !schemas[t.Schema] {
+			schemas[t.Schema] = true
+			d.schemas = append(d.schemas, t.Schema)
+		}
+	}
+	sort.Strings(d.schemas)
+	sort.SliceStable(d.tables, func(i, j int) bool {
+		if d.tables[i].Schema != d.tables[j].Schema {
+			return d.tables[i].Schema < d.tables[j].Schema
+		}
+		return d.tables[i].Name < d.tables[j].Name
+	})
+	// columns, read per table as readers may not support listing the
+	// columns of all tables
+	for _, t := range d.tables {
+		cols, err := reader.(ColumnReader).Columns(Filter{Schema: t.Schema, Parent: t.Name})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list columns for table %s: %w", t.Name, err)
+		}
+		for cols.Next() {
+			if c := cols.Get(); c.Schema == t.Schema && c.Table == t.Name {
+				t.columns = append(t.columns, *c)
+			}
+		}
+		cols.Close()
+		sort.SliceStable(t.columns, func(i, j int) bool {
+			return t.columns[i].OrdinalPosition < t.columns[j].OrdinalPosition
+		})
+	}
+	// constraints
+	if r, ok := reader.(ConstraintReader); ok {
+		res, err := r.Constraints(filter)
+		if err != nil && err != text.ErrNotSupported {
+			return nil, fmt.Errorf("failed to list constraints: %w", err)
+		}
+		constraints := make(map[string]*dumpConstraint)
+		for res != nil && res.Next() {
+			c := res.Get()
+			t, ok := tables[key{c.Schema, c.Table}]
+			if !ok || c.Type == "CHECK" && (c.CheckClause == "" || strings.HasSuffix(c.CheckClause, " IS NOT NULL")) {
+				continue
+			}
+			dc := &dumpConstraint{Constraint: *c}
+			t.constraints = append(t.constraints, dc)
+			constraints[c.Schema+"\x00"+c.Table+"\x00"+c.Name] = dc
+		}
+		if res != nil {
+			res.Close()
+		}
+		if err := readConstraintColumns(reader, filter, constraints); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range d.tables {
+		sort.SliceStable(t.constraints, func(i, j int) bool {
+			return constraintOrder[t.constraints[i].Type] < constraintOrder[t.constraints[j].Type]
+		})
+	}
+	// indexes
+	if r, ok := reader.(IndexReader); ok {
+		res, err := r.Indexes(filter)
+		if err != nil && err != text.ErrNotSupported {
+			return nil, fmt.Errorf("failed to list indexes: %w", err)
+		}
+		indexes := make(map[string]*dumpIndex)
+		for res != nil && res.Next() {
+			i := res.Get()
+			t, ok := tables[key{i.Schema, i.Table}]
+			if !ok || i.IsPrimary == YES {
+				continue
+			}
+			di := &dumpIndex{Index: *i}
+			t.indexes = append(t.indexes, di)
+			indexes[i.Schema+"\x00"+i.Table+"\x00"+i.Name] = di
+		}
+		if res != nil {
+			res.Close()
+		}
+		if r, ok := reader.(IndexColumnReader); ok && len(indexes) != 0 {
+			res, err := r.IndexColumns(filter)
+			if err != nil && err != text.ErrNotSupported {
+				return nil, fmt.Errorf("failed to list index columns: %w", err)
+			}
+			for res != nil && res.Next() {
+				c := res.Get()
+				if i, ok := indexes[c.Schema+"\x00"+c.Table+"\x00"+c.IndexName]; ok {
+					i.columns = append(i.columns, c.Name)
+				}
+			}
+			if res != nil {
+				res.Close()
+			}
+		}
+	}
+	// triggers
+	if r, ok := reader.(TriggerReader); ok {
+		res, err := r.Triggers(filter)
+		if err != nil && err != text.ErrNotSupported {
+			return nil, fmt.Errorf("failed to list triggers: %w", err)
+		}
+		for res != nil && res.Next() {
+			tr := res.Get()
+			if t, ok := tables[key{tr.Schema, tr.Table}]; ok {
+				t.triggers = append(t.triggers, *tr)
+			}
+		}
+		if res != nil {
+			res.Close()
+		}
+	}
+	// sequences
+	if err := readSequences(reader, systemSchemas, d, sp, tp); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// constraintOrder is the order of the constraint types in CREATE TABLE
+// statements.
+var constraintOrder = map[string]int{
+	"PRIMARY KEY": 0,
+	"UNIQUE":      1,
+	"CHECK":       2,
+	"FOREIGN KEY": 3,
+}
+
+// readConstraintColumns reads the columns of the constraints.
+func readConstraintColumns(reader Reader, filter Filter, constraints map[string]*dumpConstraint) error {
+	r, ok := reader.(ConstraintColumnReader)
+	if !ok || len(constraints) == 0 {
+		return nil
+	}
+	res, err := r.ConstraintColumns(filter)
+	if err != nil && err != text.ErrNotSupported {
+		return fmt.Errorf("failed to list constraint columns: %w", err)
+	}
+	if res == nil {
+		return nil
+	}
+	defer res.Close()
+	var cols []*ConstraintColumn
+	for res.Next() {
+		cols = append(cols, res.Get())
+	}
+	sort.SliceStable(cols, func(i, j int) bool {
+		return cols[i].OrdinalPosition < cols[j].OrdinalPosition
+	})
+	for _, c := range cols {
+		dc, ok := constraints[c.Schema+"\x00"+c.Table+"\x00"+c.Constraint]
+		switch {
+		case !ok,
+			// columns of the referenced table
+			dc.Type == "FOREIGN KEY" && c.ForeignName == "",
+			dc.Type != "FOREIGN KEY" && slices.Contains(dc.columns, c.Name):
+			continue
+		}
+		dc.columns = append(dc.columns, c.Name)
+		if dc.Type == "FOREIGN KEY" {
+			dc.foreignColumns = append(dc.foreignColumns, c.ForeignName)
+		}
+	}
+	return nil
+}
+
+// readSequences reads the sequences matching the patterns, and the
+// sequences used by the default values of the columns.
+func readSequences(reader Reader, systemSchemas map[string]struct{}, d *dumpSchema, sp, tp string) error {
+	r, ok := reader.(SequenceReader)
+	if !ok {
+		return nil
+	}
+	seen := make(map[string]bool)
+	read := func(f Filter) error {
+		res, err := r.Sequences(f)
+		if err != nil && err != text.ErrNotSupported {
+			return fmt.Errorf("failed to list sequences: %w", err)
+		}
+		if res == nil {
+			return nil
+		}
+		defer res.Close()
+		for res.Next() {
+			s := res.Get()
+			if _, ok := systemSchemas[s.Schema]; ok || seen[s.Schema+"."+s.Name] {
+				continue
+			}
+			seen[s.Schema+"."+s.Name] = true
+			d.sequences = append(d.sequences, *s)
+		}
+		return nil
+	}
+	if err := read(Filter{Schema: sp, Name: tp, OnlyVisible: sp == ""}); err != nil {
+		return err
+	}
+	for _, t := range d.tables {
+		for _, c := range t.columns {
+			m := nextvalRE.FindStringSubmatch(c.Default)
+			if m == nil {
+				continue
+			}
+			s, n := t.Schema, strings.ReplaceAll(m[1], `"`, "")
+			if i := strings.LastIndexByte(n, '.'); i != -1 {
+				s, n = n[:i], n[i+1:]
+			}
+			if seen[s+"."+n] {
+				continue
+			}
+			if err := read(Filter{Schema: s, Name: n}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// write writes the CREATE statements of the objects in the dst dialect,
+// converting data types and default values from the src dialect.
+func (d *dumpSchema) write(w io.Writer, src, dst *dialect) error {
+	return writeStatements(w, d.statements(src, dst))
+}
+
+// writeStatements writes the statements, separated by an empty line.
+func writeStatements(w io.Writer, stmts []string) error {
+	for i, stmt := range stmts {
+		if i != 0 {
+			stmt = "\n" + stmt
+		}
+		if _, err := fmt.Fprintln(w, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// name returns the quoted name of the object, qualified with the schema when
+// the names are qualified.
+func (d *dumpSchema) name(dst *dialect, schema, n string) string {
+	if d.qualify && schema != "" && dst.schemas {
+		return dst.ident(schema) + "." + dst.ident(n)
+	}
+	return dst.ident(n)
+}
+
+// statements returns the CREATE statements of the objects in the dst dialect.
+func (d *dumpSchema) statements(src, dst *dialect) []string {
+	name := func(schema, n string) string {
+		return d.name(dst, schema, n)
+	}
+	var stmts []string
+	// schemas
+	if d.qualify && dst.schemas {
+		for _, s := range d.schemas {
+			ifNotExists := ""
+			if dst.ifNotExists {
+				ifNotExists = "IF NOT EXISTS "
+			}
+			stmts = append(stmts, "CREATE SCHEMA "+ifNotExists+dst.ident(s)+";")
+		}
+	}
+	// sequences
+	for _, s := range d.sequences {
+		if !dst.sequences {
+			stmts = append(stmts, fmt.Sprintf("-- sequence %s not supported by dialect %s", name(s.Schema, s.Name), dst.name))
+			continue
+		}
+		stmt := "CREATE SEQUENCE " + name(s.Schema, s.Name)
+		for _, v := range [][2]string{
+			{"INCREMENT BY", s.Increment},
+			{"MINVALUE", s.Min},
+			{"MAXVALUE", s.Max},
+			{"START WITH", s.Start},
+		} {
+			if v[1] != "" {
+				stmt += "\n  " + v[0] + " " + v[1]
+			}
+		}
+		if s.Cycles == YES {
+			stmt += "\n  CYCLE"
+		}
+		stmts = append(stmts, stmt+";")
+	}
+	// tables, in the order of their foreign keys
+	var alters []string
+	for _, t := range d.sorted() {
+		var lines []string
+		for _, c := range t.columns {
+			def, comment := columnDefinition(c, src, dst)
+			if comment != "" {
+				def += " -- " + comment
+			}
+			lines = append(lines, "  "+def)
+		}
+		for _, c := range t.constraints {
+			def := c.definition(dst, name)
+			switch {
+			case def == "":
+			case c.deferred && dst.alter:
+				alters = append(alters, "ALTER TABLE "+name(t.Schema, t.Name)+" ADD "+def+";")
+			default:
+				lines = append(lines, "  "+def)
+			}
+		}
+		stmts = append(stmts, "CREATE TABLE "+name(t.Schema, t.Name)+" (\n"+joinColumnLines(lines)+"\n);")
+		for _, i := range t.indexes {
+			if len(i.columns) != 0 && !t.isConstraintIndex(i) {
+				stmts = append(stmts, d.createIndex(dst, t, i))
+			}
+		}
+	}
+	stmts = append(stmts, alters...)
+	// triggers
+	for _, t := range d.tables {
+		for _, tr := range t.triggers {
+			def := strings.TrimRight(strings.TrimSpace(tr.Definition), ";")
+			if src == dst && hasCreatePrefix(def) {
+				stmts = append(stmts, def+";")
+				continue
+			}
+			stmt := fmt.Sprintf("-- trigger %s on %s not converted to dialect %s:", dst.ident(tr.Name), name(t.Schema, t.Name), dst.name)
+			for _, line := range strings.Split(def, "\n") {
+				stmt += "\n-- " + line
+			}
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// createIndex returns the CREATE INDEX statement of the table's index.
+func (d *dumpSchema) createIndex(dst *dialect, t *dumpTable, i *dumpIndex) string {
+	unique := ""
+	if i.IsUnique == YES {
+		unique = "UNIQUE "
+	}
+	return "CREATE " + unique + "INDEX " + dst.ident(i.Name) + " ON " + d.name(dst, t.Schema, t.Name) + " (" + dst.idents(i.columns) + ");"
+}
+
+// columnDefinition returns the definition of the column in the dst dialect,
+// and a comment with the default value when it can not be converted.
+func columnDefinition(c Column, src, dst *dialect) (string, string) {
+	def := dst.ident(c.Name)
+	if typ := convertType(c.DataType, src, dst); typ != "" {
+		def += " " + typ
+	}
+	if c.IsNullable == NO {
+		def += " NOT NULL"
+	}
+	if c.Default == "" {
+		return def, ""
+	}
+	if v, ok := convertDefault(c.Default, c.DataType, src, dst); ok {
+		return def + " DEFAULT " + v, ""
+	}
+	return def, "DEFAULT " + c.Default
+}
+
+// joinColumnLines joins the column and constraint lines of a CREATE TABLE
+// statement, placing the separating commas before any trailing comments.
+func joinColumnLines(lines []string) string {
+	for i := 0; i < len(lines)-1; i++ {
+		if j := strings.Index(lines[i], " -- "); j != -1 {
+			lines[i] = lines[i][:j] + "," + lines[i][j:]
+		} else {
+			lines[i] += ","
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// definition returns the constraint definition, or an empty string when the
+// constraint has no columns.
+func (c *dumpConstraint) definition(dst *dialect, name func(string, string) string) string {
+	def := "CONSTRAINT " + dst.ident(c.Name) + " "
+	switch c.Type {
+	case "CHECK":
+		return def + "CHECK (" + c.CheckClause + ")"
+	case "PRIMARY KEY", "UNIQUE":
+		if len(c.columns) == 0 {
+			return ""
+		}
+		return def + c.Type + " (" + dst.idents(c.columns) + ")"
+	case "FOREIGN KEY":
+		if len(c.columns) == 0 {
+			return ""
+		}
+		schema := c.ForeignSchema
+		if schema == "" {
+			schema = c.Schema
+		}
+		def += "FOREIGN KEY (" + dst.idents(c.columns) + ") REFERENCES " + name(schema, c.ForeignTable) + " (" + dst.idents(c.foreignColumns) + ")"
+		if rule := strings.ToUpper(c.UpdateRule); rule != "" && rule != "NO ACTION" && dst.onUpdate {
+			def += " ON UPDATE " + rule
+		}
+		if rule := strings.ToUpper(c.DeleteRule); rule != "" && rule != "NO ACTION" {
+			def += " ON DELETE " + rule
+		}
+		return def
+	}
+	return ""
+}
+
+// isConstraintIndex returns true when the index has the same columns as a
+// primary key or unique constraint of the table, as it is created by the
+// constraint.
+func (t *dumpTable) isConstraintIndex(i *dumpIndex) bool {
+	for _, c := range t.constraints {
+		if (c.Type == "PRIMARY KEY" || c.Type == "UNIQUE") && i.IsUnique == YES && slices.Equal(c.columns, i.columns) {
+			return true
+		}
+	}
+	return false
+}
+
+// sorted returns the tables ordered so that tables are created before the
+// tables referencing them. Foreign keys of a reference cycle are marked as
+// deferred.
+func (d *dumpSchema) sorted() []*dumpTable {
+	tables := make(map[string]*dumpTable, len(d.tables))
+	for _, t := range d.tables {
+		tables[t.Schema+"."+t.Name] = t
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[*dumpTable]int)
+	var order []*dumpTable
+	var visit func(*dumpTable)
+	visit = func(t *dumpTable) {
+		state[t] = visiting
+		for _, c := range t.constraints {
+			if c.Type != "FOREIGN KEY" {
+				continue
+			}
+			schema := c.ForeignSchema
+			if schema == "" {
+				schema = t.Schema
+			}
+			ref, ok := tables[schema+"."+c.ForeignTable]
+			switch {
+			case !ok || ref == t:
+			case state[ref] == visiting:
+				c.deferred = true
+			case state[ref] == 0:
+				visit(ref)
+			}
+		}
+		state[t] = visited
+		order = append(order, t)
+	}
+	for _, t := range d.tables {
+		if state[t] == 0 {
+			visit(t)
+		}
+	}
+	return order
+}
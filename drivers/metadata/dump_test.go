@@ -0,0 +1,122 @@
+package metadata
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestConvertType(t *testing.T) {
+	tests := []struct {
+		typ, dialect, exp string
+	}{
+		{"integer", "mysql", "int"},
+		{"int(11) unsigned", "postgres", "integer"},
+		{"tinyint(1)", "postgres", "boolean"},
+		{"character varying(20)", "mysql", "varchar(20)"},
+		{"character varying", "mysql", "varchar(255)"},
+		{"nvarchar(max)", "postgres", "text"},
+		{"NUMERIC(10, 2)", "oracle", "number(10,2)"},
+		{"timestamp with time zone", "sqlserver", "datetimeoffset"},
+		{"timestamp(6) with time zone", "mysql", "timestamp"},
+		{"bytea", "sqlite", "blob"},
+		{"jsonb", "duckdb", "json"},
+		{"uuid", "mysql", "char(36)"},
+		{"", "postgres", "text"},
+		{"geometry", "postgres", "geometry"},
+	}
+	for _, test := range tests {
+		if s := convertType(test.typ, dialects["generic"], dialects[test.dialect]); s != test.exp {
+			t.Errorf("%q to %s: expected %q, got: %q", test.typ, test.dialect, test.exp, s)
+		}
+	}
+	if s := convertType("int(11) unsigned", dialects["mysql"], dialects["mysql"]); s != "int(11) unsigned" {
+		t.Errorf("expected type to be unchanged, got: %q", s)
+	}
+}
+
+func TestConvertDefault(t *testing.T) {
+	tests := []struct {
+		def, typ, dialect, exp string
+		ok                     bool
+	}{
+		{"'abc'::character varying", "varchar", "mysql", "'abc'", true},
+		{"((0))", "int", "postgres", "0", true},
+		{"now()", "timestamp", "sqlite", "CURRENT_TIMESTAMP", true},
+		{"(datetime('now'))", "timestamp", "postgres", "", false},
+		{"1", "tinyint(1)", "postgres", "TRUE", true},
+		{"true", "boolean", "sqlserver", "1", true},
+		{"nextval('t_id_seq'::regclass)", "integer", "mysql", "", false},
+	}
+	for _, test := range tests {
+		s, ok := convertDefault(test.def, test.typ, dialects["generic"], dialects[test.dialect])
+		if s != test.exp || ok != test.ok {
+			t.Errorf("%q to %s: expected %q %t, got: %q %t", test.def, test.dialect, test.exp, test.ok, s, ok)
+		}
+	}
+}
+
+func TestDumpSchema(t *testing.T) {
+	a := &dumpTable{
+		Table: Table{Schema: "public", Name: "a"},
+		columns: []Column{
+			{Name: "id", DataType: "integer", IsNullable: NO, Default: "nextval('a_id_seq'::regclass)"},
+			{Name: "b_id", DataType: "integer", IsNullable: YES},
+		},
+		constraints: []*dumpConstraint{
+			{Constraint: Constraint{Schema: "public", Table: "a", Name: "a_pkey", Type: "PRIMARY KEY"}, columns: []string{"id"}},
+			{Constraint: Constraint{Schema: "public", Table: "a", Name: "a_b_id_fkey", Type: "FOREIGN KEY", ForeignSchema: "public", ForeignTable: "b", UpdateRule: "NO ACTION", DeleteRule: "CASCADE"}, columns: []string{"b_id"}, foreignColumns: []string{"id"}},
+		},
+		indexes: []*dumpIndex{
+			{Index: Index{Name: "a_pkey", IsUnique: YES}, columns: []string{"id"}},
+			{Index: Index{Name: "a_b_id_idx", IsUnique: NO}, columns: []string{"b_id"}},
+		},
+	}
+	b := &dumpTable{
+		Table: Table{Schema: "public", Name: "b"},
+		columns: []Column{
+			{Name: "id", DataType: "integer", IsNullable: NO},
+			{Name: "a_id", DataType: "integer", IsNullable: YES},
+			{Name: "name", DataType: "character varying(10)", IsNullable: YES, Default: "'x'::character varying"},
+		},
+		constraints: []*dumpConstraint{
+			{Constraint: Constraint{Schema: "public", Table: "b", Name: "b_pkey", Type: "PRIMARY KEY"}, columns: []string{"id"}},
+			{Constraint: Constraint{Schema: "public", Table: "b", Name: "b_a_id_fkey", Type: "FOREIGN KEY", ForeignSchema: "public", ForeignTable: "a"}, columns: []string{"a_id"}, foreignColumns: []string{"id"}},
+			{Constraint: Constraint{Schema: "public", Table: "b", Name: "b_name_check", Type: "CHECK", CheckClause: "(name <> '')"}},
+		},
+		triggers: []Trigger{
+			{Schema: "public", Table: "b", Name: "b_trigger", Definition: "CREATE TRIGGER b_trigger BEFORE INSERT ON public.b FOR EACH ROW EXECUTE FUNCTION f()"},
+		},
+	}
+	d := &dumpSchema{
+		qualify:   true,
+		schemas:   []string{"public"},
+		sequences: []Sequence{{Schema: "public", Name: "a_id_seq", Start: "1", Increment: "1"}},
+		tables:    []*dumpTable{a, b},
+	}
+	buf := new(bytes.Buffer)
+	if err := d.write(buf, dialects["postgres"], dialects["mysql"]); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp := "CREATE SCHEMA IF NOT EXISTS `public`;\n\n" +
+		"-- sequence `public`.`a_id_seq` not supported by dialect mysql\n\n" +
+		"CREATE TABLE `public`.`b` (\n" +
+		"  `id` int NOT NULL,\n" +
+		"  `a_id` int,\n" +
+		"  `name` varchar(10) DEFAULT 'x',\n" +
+		"  CONSTRAINT `b_pkey` PRIMARY KEY (`id`),\n" +
+		"  CONSTRAINT `b_name_check` CHECK ((name <> ''))\n" +
+		");\n\n" +
+		"CREATE TABLE `public`.`a` (\n" +
+		"  `id` int NOT NULL, -- DEFAULT nextval('a_id_seq'::regclass)\n" +
+		"  `b_id` int,\n" +
+		"  CONSTRAINT `a_pkey` PRIMARY KEY (`id`),\n" +
+		"  CONSTRAINT `a_b_id_fkey` FOREIGN KEY (`b_id`) REFERENCES `public`.`b` (`id`) ON DELETE CASCADE\n" +
+		");\n\n" +
+		"CREATE INDEX `a_b_id_idx` ON `public`.`a` (`b_id`);\n\n" +
+		"ALTER TABLE `public`.`b` ADD CONSTRAINT `b_a_id_fkey` FOREIGN KEY (`a_id`) REFERENCES `public`.`a` (`id`);\n\n" +
+		"-- trigger `b_trigger` on `public`.`b` not converted to dialect mysql:\n" +
+		"-- CREATE TRIGGER b_trigger BEFORE INSERT ON public.b FOR EACH ROW EXECUTE FUNCTION f()\n"
+	if s := buf.String(); s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+}
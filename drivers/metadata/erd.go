@@ -0,0 +1,443 @@
+package metadata
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/text"
+)
+
+// erdFormats are the entity-relationship diagram formats.
+var erdFormats = map[string]func(io.Writer, *dumpSchema) error{
+	"mermaid":  writeMermaid,
+	"dot":      writeDOT,
+	"plantuml": writePlantUML,
+	"svg":      writeSVG,
+}
+
+// erdFormatAliases are the aliases and file extensions of the
+// entity-relationship diagram formats.
+var erdFormatAliases = map[string]string{
+	"mmd":      "mermaid",
+	"gv":       "dot",
+	"graphviz": "dot",
+	"puml":     "plantuml",
+	"pu":       "plantuml",
+	"uml":      "plantuml",
+}
+
+// ERDFormat returns the entity-relationship diagram format for the name or
+// file extension.
+func ERDFormat(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimPrefix(name, "."))
+	if s, ok := erdFormatAliases[name]; ok {
+		name = s
+	}
+	_, ok := erdFormats[name]
+	return name, ok
+}
+
+// ERD writes an entity-relationship diagram of the tables matching the
+// pattern, and their foreign key relationships, in the format (mermaid, dot,
+// plantuml, or svg) to out, ignoring tables in the system schemas. The
+// diagram is written to out instead of the writer's output, as it may be
+// written to a file or rendered as an image.
+func (w DefaultWriter) ERD(u *dburl.URL, out io.Writer, pattern, format string) error {
+	_, tables := w.r.(TableReader)
+	_, columns := w.r.(ColumnReader)
+	if !tables || !columns {
+		return fmt.Errorf(text.NotSupportedByDriver, `\erd`, u.Driver)
+	}
+	if format == "" {
+		format = "mermaid"
+	}
+	name, ok := ERDFormat(format)
+	if !ok {
+		return fmt.Errorf(text.UnknownDiagramFormat, format)
+	}
+	sp, tp, err := parsePattern(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to parse search pattern: %w", err)
+	}
+	d, err := readSchema(w.r, w.systemSchemas, sp, tp)
+	if err != nil {
+		return err
+	}
+	if len(d.tables) == 0 {
+		fmt.Fprintf(out, text.RelationNotFound, pattern)
+		fmt.Fprintln(out)
+		return nil
+	}
+	return erdFormats[name](out, d)
+}
+
+// erdRelation is a foreign key relationship between two tables.
+type erdRelation struct {
+	table, foreign *dumpTable
+	c              *dumpConstraint
+	// optional is true when the foreign key columns are nullable.
+	optional bool
+	// unique is true when the foreign key columns are unique in the table.
+	unique bool
+}
+
+// cardinality returns the crow's foot notation of the relationship, from the
+// referenced table to the table, as used by Mermaid and PlantUML.
+func (r erdRelation) cardinality() string {
+	s := "||--"
+	if r.optional {
+		s = "|o--"
+	}
+	if r.unique {
+		return s + "o|"
+	}
+	return s + "o{"
+}
+
+// relations returns the foreign key relationships between the tables.
+func (d *dumpSchema) relations() []erdRelation {
+	tables := make(map[string]*dumpTable, len(d.tables))
+	for _, t := range d.tables {
+		tables[t.Schema+"."+t.Name] = t
+	}
+	var relations []erdRelation
+	for _, t := range d.tables {
+		for _, c := range t.constraints {
+			if c.Type != "FOREIGN KEY" {
+				continue
+			}
+			schema := c.ForeignSchema
+			if schema == "" {
+				schema = t.Schema
+			}
+			ref, ok := tables[schema+"."+c.ForeignTable]
+			if !ok {
+				continue
+			}
+			r := erdRelation{table: t, foreign: ref, c: c}
+			for _, col := range c.columns {
+				if t.column(col).IsNullable == YES {
+					r.optional = true
+				}
+			}
+			for _, uc := range t.constraints {
+				if (uc.Type == "PRIMARY KEY" || uc.Type == "UNIQUE") && len(c.columns) != 0 && slices.Equal(uc.columns, c.columns) {
+					r.unique = true
+				}
+			}
+			relations = append(relations, r)
+		}
+	}
+	return relations
+}
+
+// column returns the named column of the table.
+func (t *dumpTable) column(name string) Column {
+	for _, c := range t.columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return Column{}
+}
+
+// keys returns the key markers (PK, FK, UK) of the column.
+func (t *dumpTable) keys(name string) []string {
+	var pk, fk, uk bool
+	for _, c := range t.constraints {
+		if !slices.Contains(c.columns, name) {
+			continue
+		}
+		switch c.Type {
+		case "PRIMARY KEY":
+			pk = true
+		case "FOREIGN KEY":
+			fk = true
+		case "UNIQUE":
+			uk = true
+		}
+	}
+	var keys []string
+	if pk {
+		keys = append(keys, "PK")
+	}
+	if fk {
+		keys = append(keys, "FK")
+	}
+	if uk && !pk {
+		keys = append(keys, "UK")
+	}
+	return keys
+}
+
+// erdName returns the display name of the table.
+func (d *dumpSchema) erdName(t *dumpTable) string {
+	if d.qualify && t.Schema != "" {
+		return t.Schema + "." + t.Name
+	}
+	return t.Name
+}
+
+// erdIdentRE matches characters not allowed in diagram identifiers.
+var erdIdentRE = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// erdIdent returns the diagram identifier of the table.
+func (d *dumpSchema) erdIdent(t *dumpTable) string {
+	return erdIdentRE.ReplaceAllString(d.erdName(t), "_")
+}
+
+// mermaidTypeRE matches characters not allowed in Mermaid attribute types.
+var mermaidTypeRE = regexp.MustCompile(`[^A-Za-z0-9_()\[\]-]+`)
+
+// writeMermaid writes the diagram as a Mermaid erDiagram.
+func writeMermaid(w io.Writer, d *dumpSchema) error {
+	fmt.Fprintln(w, "erDiagram")
+	for _, t := range d.tables {
+		entity := d.erdIdent(t)
+		if name := d.erdName(t); name != entity {
+			entity += "[" + strconv.Quote(name) + "]"
+		}
+		fmt.Fprintf(w, "  %s {\n", entity)
+		for _, c := range t.columns {
+			typ := mermaidTypeRE.ReplaceAllString(strings.TrimSpace(c.DataType), "_")
+			if typ == "" {
+				typ = "unknown"
+			}
+			fmt.Fprintf(w, "    %s %s", typ, erdIdentRE.ReplaceAllString(c.Name, "_"))
+			if keys := t.keys(c.Name); len(keys) != 0 {
+				fmt.Fprint(w, " "+strings.Join(keys, ", "))
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, "  }")
+	}
+	for _, r := range d.relations() {
+		fmt.Fprintf(w, "  %s %s %s : %s\n", d.erdIdent(r.foreign), r.cardinality(), d.erdIdent(r.table), strconv.Quote(r.c.Name))
+	}
+	return nil
+}
+
+// writeDOT writes the diagram as a Graphviz DOT digraph.
+func writeDOT(w io.Writer, d *dumpSchema) error {
+	fmt.Fprintln(w, "digraph erd {")
+	fmt.Fprintln(w, "  graph [rankdir=RL];")
+	fmt.Fprintln(w, `  node [shape=plaintext, fontname="Helvetica"];`)
+	fmt.Fprintln(w, `  edge [fontname="Helvetica", fontsize=10, arrowhead=normal, arrowtail=odot, dir=both];`)
+	for _, t := range d.tables {
+		fmt.Fprintf(w, "  %s [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\" cellpadding=\"4\">\n", strconv.Quote(d.erdName(t)))
+		fmt.Fprintf(w, "    <tr><td bgcolor=\"lightgrey\"><b>%s</b></td></tr>\n", html.EscapeString(d.erdName(t)))
+		for _, c := range t.columns {
+			s := strings.TrimSpace(c.Name + " " + c.DataType)
+			if keys := t.keys(c.Name); len(keys) != 0 {
+				s += " (" + strings.Join(keys, ", ") + ")"
+			}
+			fmt.Fprintf(w, "    <tr><td port=%s align=\"left\">%s</td></tr>\n", strconv.Quote(c.Name), html.EscapeString(s))
+		}
+		fmt.Fprintln(w, "  </table>>];")
+	}
+	for _, r := range d.relations() {
+		from, to := strconv.Quote(d.erdName(r.table)), strconv.Quote(d.erdName(r.foreign))
+		if len(r.c.columns) != 0 {
+			from += ":" + strconv.Quote(r.c.columns[0])
+		}
+		if len(r.c.foreignColumns) != 0 {
+			to += ":" + strconv.Quote(r.c.foreignColumns[0])
+		}
+		fmt.Fprintf(w, "  %s -> %s [label=%s];\n", from, to, strconv.Quote(r.c.Name))
+	}
+	fmt.Fprintln(w, "}")
+	return nil
+}
+
+// writePlantUML writes the diagram as PlantUML entities.
+func writePlantUML(w io.Writer, d *dumpSchema) error {
+	fmt.Fprintln(w, "@startuml")
+	fmt.Fprintln(w, "hide circle")
+	fmt.Fprintln(w, "skinparam linetype ortho")
+	for _, t := range d.tables {
+		fmt.Fprintf(w, "\nentity %s as %s {\n", strconv.Quote(d.erdName(t)), d.erdIdent(t))
+		var keys, other []string
+		for _, c := range t.columns {
+			s := "  "
+			if c.IsNullable == NO {
+				s += "* "
+			}
+			s += c.Name + " : " + c.DataType
+			k := t.keys(c.Name)
+			for _, key := range k {
+				s += " <<" + key + ">>"
+			}
+			if slices.Contains(k, "PK") {
+				keys = append(keys, s)
+			} else {
+				other = append(other, s)
+			}
+		}
+		for _, s := range keys {
+			fmt.Fprintln(w, s)
+		}
+		if len(keys) != 0 {
+			fmt.Fprintln(w, "  --")
+		}
+		for _, s := range other {
+			fmt.Fprintln(w, s)
+		}
+		fmt.Fprintln(w, "}")
+	}
+	if relations := d.relations(); len(relations) != 0 {
+		fmt.Fprintln(w)
+		for _, r := range relations {
+			fmt.Fprintf(w, "%s %s %s : %s\n", d.erdIdent(r.foreign), r.cardinality(), d.erdIdent(r.table), r.c.Name)
+		}
+	}
+	fmt.Fprintln(w, "@enduml")
+	return nil
+}
+
+// svg layout dimensions.
+const (
+	svgCharWidth = 7.5
+	svgRowHeight = 18
+	svgPadding   = 8
+	svgMargin    = 20
+	svgGapX      = 80
+	svgGapY      = 30
+)
+
+// svgBox is the position and size of a table in the svg diagram.
+type svgBox struct {
+	x, y, w, h float64
+}
+
+// row returns the vertical center of the column row, or of the header when
+// the column is not found.
+func (b svgBox) row(t *dumpTable, name string) float64 {
+	for i, c := range t.columns {
+		if c.Name == name {
+			return b.y + float64(i+1)*svgRowHeight + svgRowHeight/2 + 4
+		}
+	}
+	return b.y + svgRowHeight/2 + 2
+}
+
+// layers returns the tables grouped by their depth in the foreign key graph,
+// with the referenced tables first.
+func (d *dumpSchema) layers(relations []erdRelation) [][]*dumpTable {
+	parents := make(map[*dumpTable][]*dumpTable)
+	for _, r := range relations {
+		if r.table != r.foreign {
+			parents[r.table] = append(parents[r.table], r.foreign)
+		}
+	}
+	depth := make(map[*dumpTable]int)
+	visiting := make(map[*dumpTable]bool)
+	var visit func(*dumpTable) int
+	visit = func(t *dumpTable) int {
+		if n, ok := depth[t]; ok {
+			return n
+		}
+		visiting[t] = true
+		n := 0
+		for _, p := range parents[t] {
+			if !visiting[p] {
+				n = max(n, visit(p)+1)
+			}
+		}
+		visiting[t] = false
+		depth[t] = n
+		return n
+	}
+	var layers [][]*dumpTable
+	for _, t := range d.tables {
+		n := visit(t)
+		for len(layers) <= n {
+			layers = append(layers, nil)
+		}
+		layers[n] = append(layers[n], t)
+	}
+	return layers
+}
+
+// writeSVG writes the diagram as a svg image, with the tables laid out in
+// columns by their depth in the foreign key graph.
+func writeSVG(w io.Writer, d *dumpSchema) error {
+	relations := d.relations()
+	boxes := make(map[*dumpTable]svgBox)
+	x, width, height := float64(svgMargin), float64(0), float64(0)
+	for _, layer := range d.layers(relations) {
+		y, lw := float64(svgMargin), float64(0)
+		for _, t := range layer {
+			n := utf8.RuneCountInString(d.erdName(t))
+			for _, c := range t.columns {
+				s := c.Name + " " + c.DataType
+				if keys := t.keys(c.Name); len(keys) != 0 {
+					s += "  " + strings.Join(keys, ",")
+				}
+				n = max(n, utf8.RuneCountInString(s))
+			}
+			b := svgBox{x: x, y: y, w: float64(n)*svgCharWidth + 2*svgPadding, h: float64(len(t.columns)+1)*svgRowHeight + 8}
+			boxes[t] = b
+			y += b.h + svgGapY
+			lw = max(lw, b.w)
+		}
+		height = max(height, y-svgGapY+svgMargin)
+		x += lw + svgGapX
+		// leave room for relations looping around the last column
+		width = x - svgGapX/2
+	}
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g" font-family="Menlo, Consolas, 'DejaVu Sans Mono', 'Liberation Mono', monospace" font-size="12">`+"\n", width, height, width, height)
+	fmt.Fprintln(w, `  <defs><marker id="arrow" markerWidth="10" markerHeight="10" refX="9" refY="5" orient="auto"><path d="M0,1 L9,5 L0,9 z" fill="#555"/></marker></defs>`)
+	fmt.Fprintf(w, `  <rect width="%g" height="%g" fill="white"/>`+"\n", width, height)
+	// relations
+	for _, r := range relations {
+		from, to := boxes[r.table], boxes[r.foreign]
+		var col, ref string
+		if len(r.c.columns) != 0 {
+			col = r.c.columns[0]
+		}
+		if len(r.c.foreignColumns) != 0 {
+			ref = r.c.foreignColumns[0]
+		}
+		x1, y1, y2 := from.x, from.row(r.table, col), to.row(r.foreign, ref)
+		// connect the facing sides of the tables, or loop around the right
+		// side of tables in the same column
+		x2, c1, c2 := to.x+to.w, x1-svgGapX/2, to.x+to.w+svgGapX/2
+		switch {
+		case to.x > from.x:
+			x1, x2 = from.x+from.w, to.x
+			c1, c2 = x1+svgGapX/2, x2-svgGapX/2
+		case to.x == from.x:
+			x1 = from.x + from.w
+			c1, c2 = x1+svgGapX/2, x2+svgGapX/2
+		}
+		path := fmt.Sprintf("M%g,%g C%g,%g %g,%g %g,%g", x1, y1, c1, y1, c2, y2, x2, y2)
+		dash := ""
+		if r.optional {
+			dash = ` stroke-dasharray="4,3"`
+		}
+		fmt.Fprintf(w, `  <path d="%s" fill="none" stroke="#555"%s marker-end="url(#arrow)"/>`+"\n", path, dash)
+	}
+	// tables
+	for _, t := range d.tables {
+		b := boxes[t]
+		fmt.Fprintf(w, `  <rect x="%g" y="%g" width="%g" height="%g" fill="white" stroke="#333"/>`+"\n", b.x, b.y, b.w, b.h)
+		fmt.Fprintf(w, `  <rect x="%g" y="%g" width="%g" height="%d" fill="#ddd" stroke="#333"/>`+"\n", b.x, b.y, b.w, svgRowHeight+4)
+		fmt.Fprintf(w, `  <text x="%g" y="%g" font-weight="bold">%s</text>`+"\n", b.x+svgPadding, b.y+svgRowHeight-3, html.EscapeString(d.erdName(t)))
+		for i, c := range t.columns {
+			y := b.y + float64(i+2)*svgRowHeight
+			fmt.Fprintf(w, `  <text x="%g" y="%g">%s <tspan fill="#666">%s</tspan></text>`+"\n", b.x+svgPadding, y, html.EscapeString(c.Name), html.EscapeString(c.DataType))
+			if keys := t.keys(c.Name); len(keys) != 0 {
+				fmt.Fprintf(w, `  <text x="%g" y="%g" text-anchor="end" fill="#a50">%s</text>`+"\n", b.x+b.w-svgPadding, y, strings.Join(keys, ","))
+			}
+		}
+	}
+	fmt.Fprintln(w, "</svg>")
+	return nil
+}
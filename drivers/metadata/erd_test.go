@@ -0,0 +1,119 @@
+package metadata
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestERD(t *testing.T) {
+	a := &dumpTable{
+		Table: Table{Schema: "public", Name: "authors"},
+		columns: []Column{
+			{Name: "id", DataType: "integer", IsNullable: NO},
+			{Name: "name", DataType: "character varying(100)", IsNullable: YES},
+		},
+		constraints: []*dumpConstraint{
+			{Constraint: Constraint{Name: "authors_pkey", Type: "PRIMARY KEY"}, columns: []string{"id"}},
+		},
+	}
+	b := &dumpTable{
+		Table: Table{Schema: "public", Name: "books"},
+		columns: []Column{
+			{Name: "id", DataType: "integer", IsNullable: NO},
+			{Name: "author_id", DataType: "integer", IsNullable: NO},
+			{Name: "parent_id", DataType: "integer", IsNullable: YES},
+		},
+		constraints: []*dumpConstraint{
+			{Constraint: Constraint{Name: "books_pkey", Type: "PRIMARY KEY"}, columns: []string{"id"}},
+			{Constraint: Constraint{Name: "books_author_id_fkey", Type: "FOREIGN KEY", ForeignSchema: "public", ForeignTable: "authors"}, columns: []string{"author_id"}, foreignColumns: []string{"id"}},
+			{Constraint: Constraint{Name: "books_parent_id_fkey", Type: "FOREIGN KEY", ForeignTable: "books"}, columns: []string{"parent_id"}, foreignColumns: []string{"id"}},
+		},
+	}
+	d := &dumpSchema{
+		qualify: true,
+		tables:  []*dumpTable{a, b},
+	}
+	tests := []struct {
+		format, exp string
+	}{
+		{"mermaid", `erDiagram
+  public_authors["public.authors"] {
+    integer id PK
+    character_varying(100) name
+  }
+  public_books["public.books"] {
+    integer id PK
+    integer author_id FK
+    integer parent_id FK
+  }
+  public_authors ||--o{ public_books : "books_author_id_fkey"
+  public_books |o--o{ public_books : "books_parent_id_fkey"
+`},
+		{"dot", `digraph erd {
+  graph [rankdir=RL];
+  node [shape=plaintext, fontname="Helvetica"];
+  edge [fontname="Helvetica", fontsize=10, arrowhead=normal, arrowtail=odot, dir=both];
+  "public.authors" [label=<<table border="0" cellborder="1" cellspacing="0" cellpadding="4">
+    <tr><td bgcolor="lightgrey"><b>public.authors</b></td></tr>
+    <tr><td port="id" align="left">id integer (PK)</td></tr>
+    <tr><td port="name" align="left">name character varying(100)</td></tr>
+  </table>>];
+  "public.books" [label=<<table border="0" cellborder="1" cellspacing="0" cellpadding="4">
+    <tr><td bgcolor="lightgrey"><b>public.books</b></td></tr>
+    <tr><td port="id" align="left">id integer (PK)</td></tr>
+    <tr><td port="author_id" align="left">author_id integer (FK)</td></tr>
+    <tr><td port="parent_id" align="left">parent_id integer (FK)</td></tr>
+  </table>>];
+  "public.books":"author_id" -> "public.authors":"id" [label="books_author_id_fkey"];
+  "public.books":"parent_id" -> "public.books":"id" [label="books_parent_id_fkey"];
+}
+`},
+		{"puml", `@startuml
+hide circle
+skinparam linetype ortho
+
+entity "public.authors" as public_authors {
+  * id : integer <<PK>>
+  --
+  name : character varying(100)
+}
+
+entity "public.books" as public_books {
+  * id : integer <<PK>>
+  --
+  * author_id : integer <<FK>>
+  parent_id : integer <<FK>>
+}
+
+public_authors ||--o{ public_books : books_author_id_fkey
+public_books |o--o{ public_books : books_parent_id_fkey
+@enduml
+`},
+	}
+	for _, test := range tests {
+		format, ok := ERDFormat(test.format)
+		if !ok {
+			t.Fatalf("expected format %q to be valid", test.format)
+		}
+		buf := new(bytes.Buffer)
+		if err := erdFormats[format](buf, d); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if s := buf.String(); s != test.exp {
+			t.Errorf("%s expected:\n%s\ngot:\n%s", test.format, test.exp, s)
+		}
+	}
+	buf := new(bytes.Buffer)
+	if err := writeSVG(buf, d); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for _, s := range []string{"<svg ", ">public.books</text>", `stroke-dasharray="4,3"`} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected svg to contain %q", s)
+		}
+	}
+	if _, ok := ERDFormat("txt"); ok {
+		t.Errorf("expected format txt to be invalid")
+	}
+}
@@ -999,6 +999,100 @@ func (s InformationSchema) PrivilegeSummaries(f metadata.Filter) (*metadata.Priv
 	return metadata.NewPrivilegeSummarySet(results), nil
 }
 
+// Definitions of functions and views from selected catalog (or all, if empty),
+// matching schemas, names and types
+func (s InformationSchema) Definitions(f metadata.Filter) (*metadata.DefinitionSet, error) {
+	var funcTypes []string
+	var views bool
+	for _, t := range f.Types {
+		switch t {
+		case "VIEW":
+			views = true
+		case "FUNCTION", "PROCEDURE":
+			funcTypes = append(funcTypes, t)
+		}
+	}
+	if len(f.Types) == 0 {
+		funcTypes, views = []string{"FUNCTION", "PROCEDURE"}, true
+	}
+	if !s.hasFunctions {
+		funcTypes = nil
+	}
+	if len(funcTypes) == 0 && !views {
+		return nil, text.ErrNotSupported
+	}
+
+	var qstrs []string
+	vals := []interface{}{}
+	if len(funcTypes) != 0 {
+		columns := []string{
+			"routine_catalog",
+			"routine_schema",
+			"routine_name",
+			"COALESCE(routine_type, '')",
+			"COALESCE(routine_definition, '')",
+		}
+		filter := f
+		filter.Types = funcTypes
+		conds, v := s.conditions(1, filter, formats{
+			catalog:    "routine_catalog LIKE %s",
+			schema:     "routine_schema LIKE %s",
+			notSchemas: "routine_schema NOT IN (%s)",
+			name:       "routine_name LIKE %s",
+			types:      "routine_type IN (%s)",
+		})
+		qstr := "SELECT\n  " + strings.Join(columns, ",\n  ") + " FROM information_schema.routines"
+		if len(conds) != 0 {
+			qstr += "\nWHERE " + strings.Join(conds, " AND ")
+		}
+		qstrs, vals = append(qstrs, qstr), append(vals, v...)
+	}
+	if views {
+		columns := []string{
+			"table_catalog",
+			"table_schema",
+			"table_name",
+			"'VIEW'",
+			"COALESCE(view_definition, '')",
+		}
+		filter := f
+		filter.Types = nil
+		conds, v := s.conditions(len(vals)+1, filter, formats{
+			catalog:    "table_catalog LIKE %s",
+			schema:     "table_schema LIKE %s",
+			notSchemas: "table_schema NOT IN (%s)",
+			name:       "table_name LIKE %s",
+		})
+		qstr := "SELECT\n  " + strings.Join(columns, ",\n  ") + " FROM information_schema.views"
+		if len(conds) != 0 {
+			qstr += "\nWHERE " + strings.Join(conds, " AND ")
+		}
+		qstrs, vals = append(qstrs, qstr), append(vals, v...)
+	}
+	rows, closeRows, err := s.query(strings.Join(qstrs, "\nUNION ALL\n"), nil, "1, 2, 3", vals...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return metadata.NewDefinitionSet([]metadata.Definition{}), nil
+		}
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Definition{}
+	for rows.Next() {
+		rec := metadata.Definition{}
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.Name, &rec.Type, &rec.Definition)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewDefinitionSet(results), nil
+}
+
 func (s InformationSchema) conditions(baseParam int, filter metadata.Filter, formats formats) ([]string, []interface{}) {
 	conds := []string{}
 	vals := []interface{}{}
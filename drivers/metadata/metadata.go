@@ -1,6 +1,7 @@
 package metadata
 
 import (
+	"io"
 	"strings"
 
 	"github.com/xo/dburl"
@@ -23,6 +24,13 @@ type ExtendedReader interface {
 	FunctionColumnReader
 	SequenceReader
 	PrivilegeSummaryReader
+	DefinitionReader
+	ReplacementDefinitionReader
+	RoleReader
+	TypeReader
+	ExtensionReader
+	ExtensionObjectReader
+	SettingsReader
 }
 
 // BasicReader of common database metadata like schemas, tables and columns.
@@ -116,6 +124,49 @@ type PrivilegeSummaryReader interface {
 	PrivilegeSummaries(Filter) (*PrivilegeSummarySet, error)
 }
 
+// DefinitionReader lists function and view definitions.
+type DefinitionReader interface {
+	Reader
+	Definitions(Filter) (*DefinitionSet, error)
+}
+
+// ReplacementDefinitionReader returns the statements replacing an existing
+// function or view with its definition.
+type ReplacementDefinitionReader interface {
+	Reader
+	ReplacementDefinition(*Definition) (string, error)
+}
+
+// RoleReader lists database roles and users.
+type RoleReader interface {
+	Reader
+	Roles(Filter) (*RoleSet, error)
+}
+
+// TypeReader lists data types.
+type TypeReader interface {
+	Reader
+	Types(Filter) (*TypeSet, error)
+}
+
+// ExtensionReader lists installed database extensions.
+type ExtensionReader interface {
+	Reader
+	Extensions(Filter) (*ExtensionSet, error)
+}
+
+// ExtensionObjectReader lists objects belonging to extensions.
+type ExtensionObjectReader interface {
+	Reader
+	ExtensionObjects(Filter) (*ExtensionObjectSet, error)
+}
+
+// SettingsReader lists server configuration parameters.
+type SettingsReader interface {
+	Reader
+	Settings(Filter) (*SettingSet, error)
+}
+
 // Reader of any database metadata in a structured format.
 type Reader interface{}
 
@@ -157,9 +208,23 @@ type Writer interface {
 	// ListIndexes \di
 	ListIndexes(*dburl.URL, string, bool, bool) error
 	// ShowStats \ss
-	ShowStats(*dburl.URL, string, string, bool, int) error
+	ShowStats(*dburl.URL, string, string, bool, int, string, bool) error
 	// ListPrivilegeSummaries \dp
 	ListPrivilegeSummaries(*dburl.URL, string, bool) error
+	// ShowDefinition \sf, \sv
+	ShowDefinition(*dburl.URL, string, string, bool) error
+	// ListRoles \du, \dg
+	ListRoles(*dburl.URL, string, bool, bool) error
+	// ListTypes \dT
+	ListTypes(*dburl.URL, string, bool, bool) error
+	// ListExtensions \dx
+	ListExtensions(*dburl.URL, string, bool) error
+	// ListSettings \dconfig
+	ListSettings(*dburl.URL, string, bool) error
+	// Dump \dump
+	Dump(*dburl.URL, string, string) error
+	// ERD \erd
+	ERD(*dburl.URL, io.Writer, string, string) error
 }
 
 type CatalogSet struct {
@@ -1161,3 +1226,344 @@ func NewTriggerSet(t []Trigger) *TriggerSet {
 func (t TriggerSet) Get() *Trigger {
 	return t.results[t.current-1].(*Trigger)
 }
+
+// Definition is the source definition of a function, procedure, or view.
+type Definition struct {
+	Catalog string
+	Schema  string
+	Name    string
+	// Type is the object type, ie FUNCTION, PROCEDURE, VIEW, or MATERIALIZED
+	// VIEW.
+	Type string
+	// ArgTypes are the argument types of a function or procedure, used to
+	// distinguish overloaded functions.
+	ArgTypes string
+	// Definition is the complete CREATE statement when provided by the
+	// database, otherwise the body of the function or the query of the view.
+	Definition string
+}
+
+func (d Definition) Values() []interface{} {
+	return []interface{}{
+		d.Catalog,
+		d.Schema,
+		d.Name,
+		d.Type,
+		d.ArgTypes,
+		d.Definition,
+	}
+}
+
+type DefinitionSet struct {
+	resultSet
+}
+
+func NewDefinitionSet(v []Definition) *DefinitionSet {
+	r := make([]Result, len(v))
+	for i := range v {
+		r[i] = &v[i]
+	}
+	return &DefinitionSet{
+		resultSet: resultSet{
+			results: r,
+			columns: []string{
+				"Catalog",
+				"Schema",
+				"Name",
+				"Type",
+				"Argument data types",
+				"Definition",
+			},
+		},
+	}
+}
+
+func (d DefinitionSet) Get() *Definition {
+	return d.results[d.current-1].(*Definition)
+}
+
+// Role is a database role or user.
+type Role struct {
+	Name string
+	// Attributes of the role, ie Superuser, Create role, or Cannot login.
+	Attributes []string
+	// MemberOf are the names of the roles the role is a member of.
+	MemberOf    []string
+	Description string
+}
+
+func (r Role) Values() []interface{} {
+	return []interface{}{
+		r.Name,
+		strings.Join(r.Attributes, ", "),
+		"{" + strings.Join(r.MemberOf, ",") + "}",
+		r.Description,
+	}
+}
+
+type RoleSet struct {
+	resultSet
+}
+
+func NewRoleSet(v []Role) *RoleSet {
+	r := make([]Result, len(v))
+	for i := range v {
+		r[i] = &v[i]
+	}
+	return &RoleSet{
+		resultSet: resultSet{
+			results: r,
+			columns: []string{
+				"Role name",
+				"Attributes",
+				"Member of",
+				"Description",
+			},
+		},
+	}
+}
+
+func (r RoleSet) Get() *Role {
+	return r.results[r.current-1].(*Role)
+}
+
+// Type is a data type.
+type Type struct {
+	Catalog string
+	Schema  string
+	Name    string
+	// Type is the kind of the type, ie base, domain, enum, composite, or
+	// range.
+	Type string
+	// Size is the size of the type in bytes, or var when variable.
+	Size string
+	// Elements are the labels of an enum, the attributes of a composite
+	// type, or the underlying type of a domain.
+	Elements    []string
+	Description string
+}
+
+func (t Type) Values() []interface{} {
+	return []interface{}{
+		t.Catalog,
+		t.Schema,
+		t.Name,
+		t.Type,
+		t.Size,
+		strings.Join(t.Elements, "\n"),
+		t.Description,
+	}
+}
+
+type TypeSet struct {
+	resultSet
+}
+
+func NewTypeSet(v []Type) *TypeSet {
+	r := make([]Result, len(v))
+	for i := range v {
+		r[i] = &v[i]
+	}
+	return &TypeSet{
+		resultSet: resultSet{
+			results: r,
+			columns: []string{
+				"Catalog",
+				"Schema",
+				"Name",
+				"Type",
+				"Size",
+				"Elements",
+				"Description",
+			},
+		},
+	}
+}
+
+func (t TypeSet) Get() *Type {
+	return t.results[t.current-1].(*Type)
+}
+
+// Extension is an installed database extension.
+type Extension struct {
+	Name        string
+	Version     string
+	Schema      string
+	Description string
+}
+
+func (e Extension) Values() []interface{} {
+	return []interface{}{
+		e.Name,
+		e.Version,
+		e.Schema,
+		e.Description,
+	}
+}
+
+type ExtensionSet struct {
+	resultSet
+}
+
+func NewExtensionSet(v []Extension) *ExtensionSet {
+	r := make([]Result, len(v))
+	for i := range v {
+		r[i] = &v[i]
+	}
+	return &ExtensionSet{
+		resultSet: resultSet{
+			results: r,
+			columns: []string{
+				"Name",
+				"Version",
+				"Schema",
+				"Description",
+			},
+		},
+	}
+}
+
+func (e ExtensionSet) Get() *Extension {
+	return e.results[e.current-1].(*Extension)
+}
+
+// ExtensionObject is an object belonging to an extension.
+type ExtensionObject struct {
+	Extension string
+	// Description of the object, ie: function foo(integer).
+	Description string
+}
+
+func (o ExtensionObject) Values() []interface{} {
+	return []interface{}{
+		o.Extension,
+		o.Description,
+	}
+}
+
+type ExtensionObjectSet struct {
+	resultSet
+}
+
+func NewExtensionObjectSet(v []ExtensionObject) *ExtensionObjectSet {
+	r := make([]Result, len(v))
+	for i := range v {
+		r[i] = &v[i]
+	}
+	return &ExtensionObjectSet{
+		resultSet: resultSet{
+			results: r,
+			columns: []string{
+				"Extension",
+				"Object description",
+			},
+		},
+	}
+}
+
+func (o ExtensionObjectSet) Get() *ExtensionObject {
+	return o.results[o.current-1].(*ExtensionObject)
+}
+
+// Setting is a server configuration parameter.
+type Setting struct {
+	Name  string
+	Value string
+	// Unit of the value, if any.
+	Unit string
+	// Source of the value, ie default, configuration file, or session.
+	Source string
+	// RestartRequired when changing the value requires restarting the server.
+	RestartRequired Bool
+	Description     string
+}
+
+func (s Setting) Values() []interface{} {
+	return []interface{}{
+		s.Name,
+		s.Value,
+		s.Unit,
+		s.Source,
+		string(s.RestartRequired),
+		s.Description,
+	}
+}
+
+type SettingSet struct {
+	resultSet
+}
+
+func NewSettingSet(v []Setting) *SettingSet {
+	r := make([]Result, len(v))
+	for i := range v {
+		r[i] = &v[i]
+	}
+	return &SettingSet{
+		resultSet: resultSet{
+			results: r,
+			columns: []string{
+				"Parameter",
+				"Value",
+				"Unit",
+				"Source",
+				"Restart required",
+				"Description",
+			},
+		},
+	}
+}
+
+func (s SettingSet) Get() *Setting {
+	return s.results[s.current-1].(*Setting)
+}
+
+// Difference is a difference between the schemas of two databases.
+type Difference struct {
+	// Change is either added (only in the source), removed (only in the
+	// destination), or changed.
+	Change string
+	// Type is the object type, ie table, column, index, or constraint.
+	Type string
+	Name string
+	// Source and Destination are the descriptions of the object in each
+	// database.
+	Source      string
+	Destination string
+}
+
+func (d Difference) Values() []interface{} {
+	return []interface{}{
+		d.Change,
+		d.Type,
+		d.Name,
+		d.Source,
+		d.Destination,
+	}
+}
+
+type DifferenceSet struct {
+	resultSet
+}
+
+func NewDifferenceSet(v []Difference) *DifferenceSet {
+	r := make([]Result, len(v))
+	for i := range v {
+		r[i] = &v[i]
+	}
+	return &DifferenceSet{
+		resultSet: resultSet{
+			results: r,
+			columns: []string{
+				"Change",
+				"Type",
+				"Name",
+				"Source",
+				"Destination",
+			},
+		},
+	}
+}
+
+func (d DifferenceSet) Get() *Difference {
+	return d.results[d.current-1].(*Difference)
+}
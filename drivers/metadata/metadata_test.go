@@ -173,3 +173,53 @@ func TestColumnPrivileges_String(t *testing.T) {
 		})
 	}
 }
+
+func TestNumberLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		def    string
+		header bool
+		want   string
+	}{
+		{
+			name: "view",
+			def:  "CREATE OR REPLACE VIEW public.v AS\n SELECT a\n   FROM t;",
+			want: "1       CREATE OR REPLACE VIEW public.v AS\n" +
+				"2        SELECT a\n" +
+				"3          FROM t;",
+		},
+		{
+			name:   "function",
+			def:    "CREATE OR REPLACE FUNCTION public.f(a integer)\n RETURNS integer\n LANGUAGE sql\nAS $function$\n  select a\n$function$",
+			header: true,
+			want: "        CREATE OR REPLACE FUNCTION public.f(a integer)\n" +
+				"         RETURNS integer\n" +
+				"         LANGUAGE sql\n" +
+				"1       AS $function$\n" +
+				"2         select a\n" +
+				"3       $function$",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, numberLines(tt.def, tt.header)); diff != "" {
+				t.Errorf("numberLines() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNormalizeArgTypes(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"integer, text", "INTEGER,text"},
+		{"character  varying, int", " character varying ,int"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if x, y := normalizeArgTypes(tt.a), normalizeArgTypes(tt.b); x != y {
+			t.Errorf("expected %q and %q to be equal, got: %q != %q", tt.a, tt.b, x, y)
+		}
+	}
+}
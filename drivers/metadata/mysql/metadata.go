@@ -1,18 +1,32 @@
 package mysql
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gohxs/readline"
 	"github.com/xo/usql/drivers"
 	"github.com/xo/usql/drivers/completer"
+	"github.com/xo/usql/drivers/explain"
 	"github.com/xo/usql/drivers/metadata"
 	infos "github.com/xo/usql/drivers/metadata/informationschema"
+	"github.com/xo/usql/text"
 )
 
 var (
 	// NewReader for MySQL databases
-	NewReader = infos.New(
+	NewReader = func(db drivers.DB, opts ...metadata.ReaderOption) metadata.Reader {
+		mr := &metaReader{
+			LoggingReader: metadata.NewLoggingReader(db, opts...),
+		}
+		return metadata.NewPluginReader(newInfosReader(db, opts...), mr)
+	}
+	// newInfosReader is the information schema reader for MySQL databases
+	newInfosReader = infos.New(
 		infos.WithPlaceholder(func(int) string { return "?" }),
 		infos.WithSequences(false),
 		infos.WithCheckConstraints(false),
@@ -47,6 +61,311 @@ var (
 	}
 )
 
+// ByteOrder returns the expression sorting a text expression in byte order.
+func ByteOrder(expr string) string {
+	return "CAST(" + expr + " AS BINARY)"
+}
+
+// Explain returns the query plan for a query, using EXPLAIN FORMAT=JSON, or
+// EXPLAIN ANALYZE when analyzing.
+func Explain(ctx context.Context, db drivers.DB, sqlstr string, analyze bool, bind ...interface{}) (*explain.Plan, error) {
+	if analyze {
+		lines, err := explain.QueryLines(ctx, db, "EXPLAIN ANALYZE "+sqlstr, bind...)
+		if err != nil {
+			return nil, err
+		}
+		return explain.ParseMySQLTree(lines), nil
+	}
+	lines, err := explain.QueryLines(ctx, db, "EXPLAIN FORMAT=JSON "+sqlstr, bind...)
+	if err != nil {
+		return nil, err
+	}
+	return explain.ParseMySQL([]byte(strings.Join(lines, "\n")))
+}
+
+type metaReader struct {
+	metadata.LoggingReader
+}
+
+var _ metadata.RoleReader = &metaReader{}
+var _ metadata.ReplacementDefinitionReader = &metaReader{}
+var _ metadata.SettingsReader = &metaReader{}
+
+// ReplacementDefinition returns the SHOW CREATE statement of the view, or of
+// the function or procedure on MariaDB, as a CREATE OR REPLACE statement.
+// MySQL does not support replacing functions and procedures.
+func (r metaReader) ReplacementDefinition(d *metadata.Definition) (string, error) {
+	name := quoteIdent(d.Name)
+	if d.Schema != "" {
+		name = quoteIdent(d.Schema) + "." + name
+	}
+	typ := strings.ToUpper(d.Type)
+	switch typ {
+	case "VIEW":
+	case "FUNCTION", "PROCEDURE":
+		switch mariadb, err := r.isMariaDB(); {
+		case err != nil:
+			return "", err
+		case !mariadb:
+			return "", text.ErrNotSupported
+		}
+	default:
+		return "", text.ErrNotSupported
+	}
+	rows, closeRows, err := r.Query("SHOW CREATE " + typ + " " + name)
+	if err != nil {
+		return "", err
+	}
+	defer closeRows()
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	vals := make([]sql.NullString, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range vals {
+		dest[i] = &vals[i]
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+		return "", sql.ErrNoRows
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return "", err
+	}
+	var def string
+	for i, c := range cols {
+		if strings.EqualFold(c, "Create "+typ) {
+			def = vals[i].String
+		}
+	}
+	if def == "" {
+		// the definition is not visible without privileges on the routine
+		return "", text.ErrNotSupported
+	}
+	return createRE.ReplaceAllString(def, "CREATE OR REPLACE "), nil
+}
+
+// isMariaDB returns true when the server is MariaDB.
+func (r metaReader) isMariaDB() (bool, error) {
+	rows, closeRows, err := r.Query("SELECT VERSION()")
+	if err != nil {
+		return false, err
+	}
+	defer closeRows()
+	var version string
+	if rows.Next() {
+		if err := rows.Scan(&version); err != nil {
+			return false, err
+		}
+	}
+	return strings.Contains(strings.ToLower(version), "mariadb"), rows.Err()
+}
+
+// columns returns the lower cased columns of the tables in schema, as
+// "table.column", for the tables that exist.
+func (r metaReader) columns(schema string, tables ...string) (map[string]bool, error) {
+	qstr := `SELECT
+  LOWER(TABLE_NAME),
+  LOWER(COLUMN_NAME)
+FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = ? AND TABLE_NAME IN (?` + strings.Repeat(", ?", len(tables)-1) + `)`
+	vals := []interface{}{schema}
+	for _, t := range tables {
+		vals = append(vals, t)
+	}
+	rows, closeRows, err := r.Query(qstr, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows()
+	cols := make(map[string]bool)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, err
+		}
+		cols[table+"."+column] = true
+	}
+	return cols, rows.Err()
+}
+
+// createRE matches the CREATE keyword of a statement.
+var createRE = regexp.MustCompile(`(?i)^\s*CREATE\s+`)
+
+// quoteIdent quotes an identifier.
+func quoteIdent(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+}
+
+func (r metaReader) Roles(f metadata.Filter) (*metadata.RoleSet, error) {
+	cols, err := r.columns("mysql", "user", "role_edges", "roles_mapping")
+	if err != nil {
+		return nil, err
+	}
+	// not all servers (ie, MySQL 5.7, MariaDB, TiDB) have the same grant
+	// tables or columns, so only select what is available
+	attrs := []string{}
+	for _, c := range []string{"Super_priv", "Create_user_priv", "Grant_priv", "account_locked", "password_expired"} {
+		if cols["user."+strings.ToLower(c)] {
+			attrs = append(attrs, "u."+c)
+		} else {
+			attrs = append(attrs, "'N'")
+		}
+	}
+	memberOf := "''"
+	switch {
+	case cols["role_edges.from_user"]:
+		memberOf = `COALESCE((
+    SELECT GROUP_CONCAT(e.FROM_USER ORDER BY e.FROM_USER SEPARATOR ',')
+    FROM mysql.role_edges e
+    WHERE e.TO_USER = u.User AND e.TO_HOST = u.Host
+  ), '')`
+	case cols["roles_mapping.role"]:
+		memberOf = `COALESCE((
+    SELECT GROUP_CONCAT(m.Role ORDER BY m.Role SEPARATOR ',')
+    FROM mysql.roles_mapping m
+    WHERE m.User = u.User AND m.Host = u.Host
+  ), '')`
+	}
+	qstr := `SELECT
+  u.User,
+  u.Host,
+  ` + strings.Join(attrs, ",\n  ") + `,
+  ` + memberOf + `
+FROM mysql.user u`
+	conds := []string{}
+	vals := []interface{}{}
+	if !f.WithSystem {
+		conds = append(conds, "u.User NOT IN ('mysql.sys', 'mysql.session', 'mysql.infoschema', 'mariadb.sys')")
+	}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		conds = append(conds, "u.User LIKE ?")
+	}
+	if len(conds) != 0 {
+		qstr += "\nWHERE " + strings.Join(conds, " AND ")
+	}
+	qstr += "\nORDER BY u.User, u.Host"
+	rows, closeRows, err := r.Query(qstr, vals...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return metadata.NewRoleSet([]metadata.Role{}), nil
+		}
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Role{}
+	for rows.Next() {
+		var user, host, super, createUser, grant, locked, expired, memberOf string
+		err = rows.Scan(&user, &host, &super, &createUser, &grant, &locked, &expired, &memberOf)
+		if err != nil {
+			return nil, err
+		}
+		rec := metadata.Role{
+			Name: fmt.Sprintf("%s@%s", user, host),
+		}
+		for _, a := range []struct {
+			v string
+			s string
+		}{
+			{super, "Superuser"},
+			{createUser, "Create role"},
+			{grant, "Grant option"},
+			{locked, "Cannot login"},
+			{expired, "Password expired"},
+		} {
+			if a.v == "Y" {
+				rec.Attributes = append(rec.Attributes, a.s)
+			}
+		}
+		if memberOf != "" {
+			rec.MemberOf = strings.Split(memberOf, ",")
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewRoleSet(results), nil
+}
+
+func (r metaReader) Settings(f metadata.Filter) (*metadata.SettingSet, error) {
+	qstr := "SHOW VARIABLES"
+	vals := []interface{}{}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		qstr += " LIKE ?"
+	}
+	rows, closeRows, err := r.Query(qstr, vals...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return metadata.NewSettingSet([]metadata.Setting{}), nil
+		}
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Setting{}
+	for rows.Next() {
+		rec := metadata.Setting{}
+		err = rows.Scan(&rec.Name, &rec.Value)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	sources, err := r.settingSources()
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Source = sources[results[i].Name]
+	}
+	return metadata.NewSettingSet(results), nil
+}
+
+// settingSources returns the source of the system variables, and when they
+// were last set, from performance_schema.variables_info. Returns no sources
+// when the table is not available (ie, before MySQL 8.0 or on MariaDB).
+func (r metaReader) settingSources() (map[string]string, error) {
+	cols, err := r.columns("performance_schema", "variables_info")
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string]string)
+	if !cols["variables_info.variable_source"] {
+		return sources, nil
+	}
+	qstr := `SELECT
+  VARIABLE_NAME,
+  VARIABLE_SOURCE,
+  COALESCE(CAST(SET_TIME AS CHAR), '')
+FROM performance_schema.variables_info`
+	rows, closeRows, err := r.Query(qstr)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows()
+	for rows.Next() {
+		var name, source, setTime string
+		if err := rows.Scan(&name, &source, &setTime); err != nil {
+			return nil, err
+		}
+		if setTime != "" {
+			source += " (set " + setTime + ")"
+		}
+		sources[name] = source
+	}
+	return sources, rows.Err()
+}
+
 func complete(reader metadata.Reader) completer.CompleteFunc {
 	return func(previousWords []string, text []rune) [][]rune {
 		if completer.TailMatches(completer.IGNORE_CASE, previousWords, `USE`) {
@@ -18,6 +18,8 @@ type metaReader struct {
 var _ metadata.BasicReader = &metaReader{}
 var _ metadata.IndexReader = &metaReader{}
 var _ metadata.IndexColumnReader = &metaReader{}
+var _ metadata.DefinitionReader = &metaReader{}
+var _ metadata.RoleReader = &metaReader{}
 
 func NewReader() func(drivers.DB, ...metadata.ReaderOption) metadata.Reader {
 	return func(db drivers.DB, opts ...metadata.ReaderOption) metadata.Reader {
@@ -434,6 +436,176 @@ ORDER BY o.owner, o.table_name, o.index_name, b.column_position`
 	return metadata.NewIndexColumnSet(results), nil
 }
 
+func (r metaReader) Definitions(f metadata.Filter) (*metadata.DefinitionSet, error) {
+	var funcTypes []string
+	var views bool
+	for _, t := range f.Types {
+		switch t {
+		case "VIEW":
+			views = true
+		case "FUNCTION", "PROCEDURE":
+			funcTypes = append(funcTypes, t)
+		}
+	}
+	if len(f.Types) == 0 {
+		funcTypes, views = []string{"FUNCTION", "PROCEDURE"}, true
+	}
+	results := []metadata.Definition{}
+	if len(funcTypes) != 0 {
+		// the source of functions and procedures is stored line by line
+		filter := f
+		filter.Types = funcTypes
+		conds, vals := r.conditions(filter, formats{
+			schema:     "s.owner LIKE %s",
+			notSchemas: "s.owner NOT IN (%s)",
+			name:       "s.name LIKE :%d",
+			types:      "s.type IN (%s)",
+		})
+		qstr := `SELECT
+  s.owner,
+  s.name,
+  s.type,
+  s.text
+FROM all_source s
+`
+		if len(conds) != 0 {
+			qstr += " WHERE " + strings.Join(conds, " AND ")
+		}
+		qstr += `
+ORDER BY s.owner, s.name, s.type, s.line`
+		rows, closeRows, err := r.Query(qstr, vals...)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if err == nil {
+			defer closeRows()
+			var cur *metadata.Definition
+			for rows.Next() {
+				var schema, name, typ, line string
+				if err := rows.Scan(&schema, &name, &typ, &line); err != nil {
+					return nil, err
+				}
+				if cur == nil || cur.Schema != schema || cur.Name != name || cur.Type != typ {
+					results = append(results, metadata.Definition{
+						Schema:     schema,
+						Name:       name,
+						Type:       typ,
+						Definition: "CREATE OR REPLACE ",
+					})
+					cur = &results[len(results)-1]
+				}
+				cur.Definition += line
+			}
+			if rows.Err() != nil {
+				return nil, rows.Err()
+			}
+		}
+	}
+	if views {
+		filter := f
+		filter.Types = nil
+		conds, vals := r.conditions(filter, formats{
+			schema:     "v.owner LIKE %s",
+			notSchemas: "v.owner NOT IN (%s)",
+			name:       "v.view_name LIKE :%d",
+		})
+		qstr := `SELECT
+  v.owner,
+  v.view_name,
+  v.text
+FROM all_views v
+`
+		if len(conds) != 0 {
+			qstr += " WHERE " + strings.Join(conds, " AND ")
+		}
+		qstr += `
+ORDER BY v.owner, v.view_name`
+		rows, closeRows, err := r.Query(qstr, vals...)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if err == nil {
+			defer closeRows()
+			for rows.Next() {
+				rec := metadata.Definition{Type: "VIEW"}
+				if err := rows.Scan(&rec.Schema, &rec.Name, &rec.Definition); err != nil {
+					return nil, err
+				}
+				results = append(results, rec)
+			}
+			if rows.Err() != nil {
+				return nil, rows.Err()
+			}
+		}
+	}
+	return metadata.NewDefinitionSet(results), nil
+}
+
+// Roles lists users from dba_users, falling back to all_users when the
+// current user cannot access the DBA views.
+func (r metaReader) Roles(f metadata.Filter) (*metadata.RoleSet, error) {
+	conds, vals := r.conditions(f, formats{
+		notSchemas: "u.username NOT IN (%s)",
+		name:       "u.username LIKE :%d",
+	})
+	where := ""
+	if len(conds) != 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+	qstr := `SELECT
+  u.username,
+  u.account_status,
+  (SELECT LISTAGG(p.granted_role, ',') WITHIN GROUP (ORDER BY p.granted_role)
+   FROM dba_role_privs p
+   WHERE p.grantee = u.username)
+FROM dba_users u
+` + where + `
+ORDER BY u.username`
+	rows, closeRows, err := r.Query(qstr, vals...)
+	if err != nil && err != sql.ErrNoRows {
+		qstr = `SELECT
+  u.username,
+  'OPEN',
+  NULL
+FROM all_users u
+` + where + `
+ORDER BY u.username`
+		rows, closeRows, err = r.Query(qstr, vals...)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return metadata.NewRoleSet([]metadata.Role{}), nil
+		}
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Role{}
+	for rows.Next() {
+		var status string
+		var memberOf sql.NullString
+		rec := metadata.Role{}
+		err = rows.Scan(&rec.Name, &status, &memberOf)
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(status, "LOCKED") {
+			rec.Attributes = append(rec.Attributes, "Cannot login")
+		}
+		if strings.Contains(status, "EXPIRED") {
+			rec.Attributes = append(rec.Attributes, "Password expired")
+		}
+		if memberOf.String != "" {
+			rec.MemberOf = strings.Split(memberOf.String, ",")
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewRoleSet(results), nil
+}
+
 func (r metaReader) conditions(filter metadata.Filter, formats formats) ([]string, []interface{}) {
 	baseParam := 1
 	conds := []string{}
@@ -2,12 +2,14 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
 
 	"github.com/lib/pq"
 	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/drivers/explain"
 	"github.com/xo/usql/drivers/metadata"
 	infos "github.com/xo/usql/drivers/metadata/informationschema"
 )
@@ -23,6 +25,36 @@ var _ metadata.ColumnStatReader = &metaReader{}
 var _ metadata.IndexReader = &metaReader{}
 var _ metadata.IndexColumnReader = &metaReader{}
 var _ metadata.TriggerReader = &metaReader{}
+var _ metadata.DefinitionReader = &metaReader{}
+var _ metadata.RoleReader = &metaReader{}
+var _ metadata.TypeReader = &metaReader{}
+var _ metadata.ExtensionReader = &metaReader{}
+var _ metadata.ExtensionObjectReader = &metaReader{}
+var _ metadata.SettingsReader = &metaReader{}
+
+// FunctionTemplate is the template used when creating a new function.
+const FunctionTemplate = "CREATE FUNCTION ( )\n RETURNS \n LANGUAGE \n -- common options:  IMMUTABLE  STABLE  STRICT  SECURITY DEFINER\nAS $function$\n\n$function$\n"
+
+// ViewTemplate is the template used when creating a new view.
+const ViewTemplate = "CREATE VIEW  AS\n SELECT \n  -- something...\n"
+
+// ByteOrder returns the expression sorting a text expression in byte order.
+func ByteOrder(expr string) string {
+	return expr + ` COLLATE "C"`
+}
+
+// Explain returns the query plan for a query, using EXPLAIN (FORMAT JSON).
+func Explain(ctx context.Context, db drivers.DB, sqlstr string, analyze bool, bind ...interface{}) (*explain.Plan, error) {
+	opts := "FORMAT JSON"
+	if analyze {
+		opts = "ANALYZE, " + opts
+	}
+	lines, err := explain.QueryLines(ctx, db, "EXPLAIN ("+opts+") "+sqlstr, bind...)
+	if err != nil {
+		return nil, err
+	}
+	return explain.ParsePostgres([]byte(strings.Join(lines, "\n")))
+}
 
 func NewReader() func(drivers.DB, ...metadata.ReaderOption) metadata.Reader {
 	return func(db drivers.DB, opts ...metadata.ReaderOption) metadata.Reader {
@@ -452,6 +484,390 @@ FROM
 	return metadata.NewTriggerSet(results), nil
 }
 
+func (r metaReader) Definitions(f metadata.Filter) (*metadata.DefinitionSet, error) {
+	var funcs, views bool
+	for _, t := range f.Types {
+		switch t {
+		case "FUNCTION", "PROCEDURE":
+			funcs = true
+		case "VIEW", "MATERIALIZED VIEW":
+			views = true
+		}
+	}
+	if len(f.Types) == 0 {
+		funcs, views = true, true
+	}
+	var qstrs []string
+	vals := []interface{}{}
+	if funcs {
+		conds := []string{"p.prokind IN ('f', 'p', 'w')"}
+		if f.OnlyVisible {
+			conds = append(conds, "pg_catalog.pg_function_is_visible(p.oid)")
+		}
+		if !f.WithSystem {
+			conds = append(conds, "n.nspname NOT IN ('pg_catalog', 'information_schema')")
+		}
+		if f.Schema != "" {
+			vals = append(vals, f.Schema)
+			conds = append(conds, fmt.Sprintf("n.nspname LIKE $%d", len(vals)))
+		}
+		if f.Name != "" {
+			vals = append(vals, f.Name)
+			conds = append(conds, fmt.Sprintf("p.proname LIKE $%d", len(vals)))
+		}
+		qstrs = append(qstrs, `SELECT
+  current_database(),
+  n.nspname,
+  p.proname,
+  CASE p.prokind WHEN 'p' THEN 'PROCEDURE' ELSE 'FUNCTION' END,
+  pg_catalog.pg_get_function_identity_arguments(p.oid),
+  pg_catalog.pg_get_functiondef(p.oid)
+FROM pg_catalog.pg_proc p
+     LEFT JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace
+WHERE `+strings.Join(conds, " AND "))
+	}
+	if views {
+		conds := []string{"c.relkind IN ('v', 'm')"}
+		if f.OnlyVisible {
+			conds = append(conds, "pg_catalog.pg_table_is_visible(c.oid)")
+		}
+		if !f.WithSystem {
+			conds = append(conds, "n.nspname NOT IN ('pg_catalog', 'information_schema')")
+		}
+		if f.Schema != "" {
+			vals = append(vals, f.Schema)
+			conds = append(conds, fmt.Sprintf("n.nspname LIKE $%d", len(vals)))
+		}
+		if f.Name != "" {
+			vals = append(vals, f.Name)
+			conds = append(conds, fmt.Sprintf("c.relname LIKE $%d", len(vals)))
+		}
+		qstrs = append(qstrs, `SELECT
+  current_database(),
+  n.nspname,
+  c.relname,
+  CASE c.relkind WHEN 'm' THEN 'MATERIALIZED VIEW' ELSE 'VIEW' END,
+  '',
+  CASE c.relkind WHEN 'm' THEN 'CREATE MATERIALIZED VIEW ' ELSE 'CREATE OR REPLACE VIEW ' END
+    || pg_catalog.quote_ident(n.nspname) || '.' || pg_catalog.quote_ident(c.relname)
+    || CASE WHEN c.reloptions IS NOT NULL THEN ' WITH (' || pg_catalog.array_to_string(c.reloptions, ', ') || ')' ELSE '' END
+    || E' AS\n' || pg_catalog.pg_get_viewdef(c.oid, true)
+FROM pg_catalog.pg_class c
+     LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE `+strings.Join(conds, " AND "))
+	}
+	rows, closeRows, err := r.query(strings.Join(qstrs, "\nUNION ALL\n"), nil, "2, 3, 5", vals...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return metadata.NewDefinitionSet([]metadata.Definition{}), nil
+		}
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Definition{}
+	for rows.Next() {
+		rec := metadata.Definition{}
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.Name, &rec.Type, &rec.ArgTypes, &rec.Definition)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewDefinitionSet(results), nil
+}
+
+func (r metaReader) Roles(f metadata.Filter) (*metadata.RoleSet, error) {
+	qstr := `SELECT
+  r.rolname,
+  r.rolsuper,
+  r.rolinherit,
+  r.rolcreaterole,
+  r.rolcreatedb,
+  r.rolcanlogin,
+  r.rolreplication,
+  r.rolbypassrls,
+  r.rolconnlimit,
+  COALESCE(r.rolvaliduntil::text, ''),
+  COALESCE(pg_catalog.array_to_string(ARRAY(
+    SELECT b.rolname
+    FROM pg_catalog.pg_auth_members m
+         JOIN pg_catalog.pg_roles b ON m.roleid = b.oid
+    WHERE m.member = r.oid
+    ORDER BY 1
+  ), ','), ''),
+  COALESCE(pg_catalog.shobj_description(r.oid, 'pg_authid'), '')
+FROM pg_catalog.pg_roles r`
+	conds := []string{}
+	vals := []interface{}{}
+	if !f.WithSystem {
+		conds = append(conds, "r.rolname !~ '^pg_'")
+	}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		conds = append(conds, fmt.Sprintf("r.rolname LIKE $%d", len(vals)))
+	}
+	rows, closeRows, err := r.query(qstr, conds, "1", vals...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return metadata.NewRoleSet([]metadata.Role{}), nil
+		}
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Role{}
+	for rows.Next() {
+		var super, inherit, createRole, createDB, canLogin, replication, bypassRLS bool
+		var connLimit int
+		var validUntil, memberOf string
+		rec := metadata.Role{}
+		err = rows.Scan(&rec.Name, &super, &inherit, &createRole, &createDB, &canLogin, &replication, &bypassRLS, &connLimit, &validUntil, &memberOf, &rec.Description)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range []struct {
+			b bool
+			s string
+		}{
+			{super, "Superuser"},
+			{!inherit, "No inheritance"},
+			{createRole, "Create role"},
+			{createDB, "Create DB"},
+			{!canLogin, "Cannot login"},
+			{replication, "Replication"},
+			{bypassRLS, "Bypass RLS"},
+		} {
+			if a.b {
+				rec.Attributes = append(rec.Attributes, a.s)
+			}
+		}
+		switch {
+		case connLimit == 0:
+			rec.Attributes = append(rec.Attributes, "No connections")
+		case connLimit == 1:
+			rec.Attributes = append(rec.Attributes, "1 connection")
+		case connLimit > 1:
+			rec.Attributes = append(rec.Attributes, fmt.Sprintf("%d connections", connLimit))
+		}
+		if validUntil != "" {
+			rec.Attributes = append(rec.Attributes, "Password valid until "+validUntil)
+		}
+		if memberOf != "" {
+			rec.MemberOf = strings.Split(memberOf, ",")
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewRoleSet(results), nil
+}
+
+func (r metaReader) Types(f metadata.Filter) (*metadata.TypeSet, error) {
+	qstr := `SELECT
+  current_database(),
+  n.nspname,
+  pg_catalog.format_type(t.oid, NULL),
+  CASE t.typtype
+    WHEN 'b' THEN 'base'
+    WHEN 'c' THEN 'composite'
+    WHEN 'd' THEN 'domain'
+    WHEN 'e' THEN 'enum'
+    WHEN 'm' THEN 'multirange'
+    WHEN 'p' THEN 'pseudo'
+    WHEN 'r' THEN 'range'
+    ELSE ''
+  END,
+  CASE
+    WHEN t.typrelid != 0 THEN 'tuple'
+    WHEN t.typlen < 0 THEN 'var'
+    ELSE t.typlen::text
+  END,
+  CASE t.typtype
+    WHEN 'e' THEN pg_catalog.array_to_string(ARRAY(
+      SELECT e.enumlabel
+      FROM pg_catalog.pg_enum e
+      WHERE e.enumtypid = t.oid
+      ORDER BY e.enumsortorder
+    ), E'\n')
+    WHEN 'c' THEN pg_catalog.array_to_string(ARRAY(
+      SELECT a.attname || ' ' || pg_catalog.format_type(a.atttypid, a.atttypmod)
+      FROM pg_catalog.pg_attribute a
+      WHERE a.attrelid = t.typrelid AND a.attnum > 0 AND NOT a.attisdropped
+      ORDER BY a.attnum
+    ), E'\n')
+    WHEN 'd' THEN pg_catalog.format_type(t.typbasetype, t.typtypmod)
+    ELSE ''
+  END,
+  COALESCE(pg_catalog.obj_description(t.oid, 'pg_type'), '')
+FROM pg_catalog.pg_type t
+     LEFT JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace`
+	conds := []string{
+		"(t.typrelid = 0 OR (SELECT c.relkind = 'c' FROM pg_catalog.pg_class c WHERE c.oid = t.typrelid))",
+		"NOT EXISTS(SELECT 1 FROM pg_catalog.pg_type el WHERE el.oid = t.typelem AND el.typarray = t.oid)",
+	}
+	vals := []interface{}{}
+	if !f.WithSystem {
+		conds = append(conds, "n.nspname NOT IN ('pg_catalog', 'information_schema')")
+	}
+	if f.Schema != "" {
+		vals = append(vals, f.Schema)
+		conds = append(conds, fmt.Sprintf("n.nspname LIKE $%d", len(vals)))
+	}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		conds = append(conds, fmt.Sprintf("(t.typname LIKE $%d OR pg_catalog.format_type(t.oid, NULL) LIKE $%d)", len(vals), len(vals)))
+	}
+	rows, closeRows, err := r.query(qstr, conds, "2, 3", vals...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return metadata.NewTypeSet([]metadata.Type{}), nil
+		}
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Type{}
+	for rows.Next() {
+		var elements string
+		rec := metadata.Type{}
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.Name, &rec.Type, &rec.Size, &elements, &rec.Description)
+		if err != nil {
+			return nil, err
+		}
+		if elements != "" {
+			rec.Elements = strings.Split(elements, "\n")
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewTypeSet(results), nil
+}
+
+func (r metaReader) Extensions(f metadata.Filter) (*metadata.ExtensionSet, error) {
+	qstr := `SELECT
+  e.extname,
+  e.extversion,
+  n.nspname,
+  COALESCE(c.description, '')
+FROM pg_catalog.pg_extension e
+     LEFT JOIN pg_catalog.pg_namespace n ON n.oid = e.extnamespace
+     LEFT JOIN pg_catalog.pg_description c ON c.objoid = e.oid
+       AND c.classoid = 'pg_catalog.pg_extension'::pg_catalog.regclass`
+	conds := []string{}
+	vals := []interface{}{}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		conds = append(conds, fmt.Sprintf("e.extname LIKE $%d", len(vals)))
+	}
+	rows, closeRows, err := r.query(qstr, conds, "1", vals...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return metadata.NewExtensionSet([]metadata.Extension{}), nil
+		}
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Extension{}
+	for rows.Next() {
+		rec := metadata.Extension{}
+		err = rows.Scan(&rec.Name, &rec.Version, &rec.Schema, &rec.Description)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewExtensionSet(results), nil
+}
+
+func (r metaReader) ExtensionObjects(f metadata.Filter) (*metadata.ExtensionObjectSet, error) {
+	qstr := `SELECT
+  e.extname,
+  pg_catalog.pg_describe_object(d.classid, d.objid, 0)
+FROM pg_catalog.pg_depend d
+     JOIN pg_catalog.pg_extension e ON e.oid = d.refobjid`
+	conds := []string{
+		"d.refclassid = 'pg_catalog.pg_extension'::pg_catalog.regclass",
+		"d.deptype = 'e'",
+	}
+	vals := []interface{}{}
+	if f.Parent != "" {
+		vals = append(vals, f.Parent)
+		conds = append(conds, fmt.Sprintf("e.extname LIKE $%d", len(vals)))
+	}
+	rows, closeRows, err := r.query(qstr, conds, "1, 2", vals...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return metadata.NewExtensionObjectSet([]metadata.ExtensionObject{}), nil
+		}
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.ExtensionObject{}
+	for rows.Next() {
+		rec := metadata.ExtensionObject{}
+		err = rows.Scan(&rec.Extension, &rec.Description)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewExtensionObjectSet(results), nil
+}
+
+func (r metaReader) Settings(f metadata.Filter) (*metadata.SettingSet, error) {
+	qstr := `SELECT
+  s.name,
+  pg_catalog.current_setting(s.name),
+  COALESCE(s.unit, ''),
+  s.source,
+  CASE WHEN s.context = 'postmaster' THEN 'YES' ELSE 'NO' END,
+  COALESCE(s.short_desc, '')
+FROM pg_catalog.pg_settings s`
+	conds := []string{}
+	vals := []interface{}{}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		conds = append(conds, fmt.Sprintf("s.name ILIKE $%d", len(vals)))
+	}
+	rows, closeRows, err := r.query(qstr, conds, "1", vals...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return metadata.NewSettingSet([]metadata.Setting{}), nil
+		}
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Setting{}
+	for rows.Next() {
+		rec := metadata.Setting{}
+		err = rows.Scan(&rec.Name, &rec.Value, &rec.Unit, &rec.Source, &rec.RestartRequired, &rec.Description)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewSettingSet(results), nil
+}
+
 func (r metaReader) query(qstr string, conds []string, order string, vals ...interface{}) (*sql.Rows, func(), error) {
 	if len(conds) != 0 {
 		qstr += "\nWHERE " + strings.Join(conds, " AND ")
@@ -24,6 +24,13 @@ type PluginReader struct {
 	functionColumns    func(Filter) (*FunctionColumnSet, error)
 	sequences          func(Filter) (*SequenceSet, error)
 	privilegeSummaries func(Filter) (*PrivilegeSummarySet, error)
+	definitions        func(Filter) (*DefinitionSet, error)
+	replacements       func(*Definition) (string, error)
+	roles              func(Filter) (*RoleSet, error)
+	types              func(Filter) (*TypeSet, error)
+	extensions         func(Filter) (*ExtensionSet, error)
+	extensionObjects   func(Filter) (*ExtensionObjectSet, error)
+	settings           func(Filter) (*SettingSet, error)
 }
 
 var _ ExtendedReader = &PluginReader{}
@@ -74,6 +81,27 @@ func NewPluginReader(readers ...Reader) Reader {
 		if r, ok := i.(PrivilegeSummaryReader); ok {
 			p.privilegeSummaries = r.PrivilegeSummaries
 		}
+		if r, ok := i.(DefinitionReader); ok {
+			p.definitions = r.Definitions
+		}
+		if r, ok := i.(ReplacementDefinitionReader); ok {
+			p.replacements = r.ReplacementDefinition
+		}
+		if r, ok := i.(RoleReader); ok {
+			p.roles = r.Roles
+		}
+		if r, ok := i.(TypeReader); ok {
+			p.types = r.Types
+		}
+		if r, ok := i.(ExtensionReader); ok {
+			p.extensions = r.Extensions
+		}
+		if r, ok := i.(ExtensionObjectReader); ok {
+			p.extensionObjects = r.ExtensionObjects
+		}
+		if r, ok := i.(SettingsReader); ok {
+			p.settings = r.Settings
+		}
 	}
 	return &p
 }
@@ -176,6 +204,55 @@ func (p PluginReader) PrivilegeSummaries(f Filter) (*PrivilegeSummarySet, error)
 	return p.privilegeSummaries(f)
 }
 
+func (p PluginReader) Definitions(f Filter) (*DefinitionSet, error) {
+	if p.definitions == nil {
+		return nil, text.ErrNotSupported
+	}
+	return p.definitions(f)
+}
+
+func (p PluginReader) ReplacementDefinition(d *Definition) (string, error) {
+	if p.replacements == nil {
+		return "", text.ErrNotSupported
+	}
+	return p.replacements(d)
+}
+
+func (p PluginReader) Roles(f Filter) (*RoleSet, error) {
+	if p.roles == nil {
+		return nil, text.ErrNotSupported
+	}
+	return p.roles(f)
+}
+
+func (p PluginReader) Types(f Filter) (*TypeSet, error) {
+	if p.types == nil {
+		return nil, text.ErrNotSupported
+	}
+	return p.types(f)
+}
+
+func (p PluginReader) Extensions(f Filter) (*ExtensionSet, error) {
+	if p.extensions == nil {
+		return nil, text.ErrNotSupported
+	}
+	return p.extensions(f)
+}
+
+func (p PluginReader) ExtensionObjects(f Filter) (*ExtensionObjectSet, error) {
+	if p.extensionObjects == nil {
+		return nil, text.ErrNotSupported
+	}
+	return p.extensionObjects(f)
+}
+
+func (p PluginReader) Settings(f Filter) (*SettingSet, error) {
+	if p.settings == nil {
+		return nil, text.ErrNotSupported
+	}
+	return p.settings(f)
+}
+
 type LoggingReader struct {
 	db      DB
 	logger  logger
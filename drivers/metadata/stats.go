@@ -0,0 +1,343 @@
+package metadata
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/text"
+)
+
+// statsQueryRE matches a query passed to \ss in place of a table.
+var statsQueryRE = regexp.MustCompile(`(?is)^\s*(select|with|values)\s`)
+
+// statsSample is a sample of the rows profiled by \ss, either the first rows
+// or a percentage of randomly chosen rows.
+type statsSample struct {
+	rows    int64
+	percent float64
+}
+
+// parseStatsSample parses a sample of N rows or P% of the rows.
+func parseStatsSample(s string) (statsSample, error) {
+	if s == "" {
+		return statsSample{}, nil
+	}
+	if p, ok := strings.CutSuffix(s, "%"); ok {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || v <= 0 || v > 100 {
+			return statsSample{}, fmt.Errorf(text.InvalidOption, "sample="+s)
+		}
+		return statsSample{percent: v}, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || v <= 0 {
+		return statsSample{}, fmt.Errorf(text.InvalidOption, "sample="+s)
+	}
+	return statsSample{rows: v}, nil
+}
+
+// statsSource is a table or query profiled by \ss.
+type statsSource struct {
+	schema, table string
+	// from is the table or query expression used in the FROM clause.
+	from string
+}
+
+// statsSources returns the tables matching the pattern, or the query.
+func (w DefaultWriter) statsSources(d *dialect, pattern string) ([]statsSource, error) {
+	if statsQueryRE.MatchString(pattern) {
+		return []statsSource{{from: "(" + strings.TrimRight(strings.TrimSpace(pattern), ";") + ") q"}}, nil
+	}
+	r, ok := w.r.(TableReader)
+	if !ok {
+		if pattern == "" {
+			return nil, nil
+		}
+		return []statsSource{{table: pattern, from: pattern}}, nil
+	}
+	sp, tp, err := parsePattern(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse search pattern: %w", err)
+	}
+	var types []string
+	for _, c := range "tvm" {
+		types = append(types, w.tableTypes[c]...)
+	}
+	res, err := r.Tables(Filter{Schema: sp, Name: tp, Types: types, OnlyVisible: sp == ""})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer res.Close()
+	var sources []statsSource
+	for res.Next() {
+		t := res.Get()
+		if _, ok := w.systemSchemas[t.Schema]; ok && sp == "" {
+			continue
+		}
+		from := d.ident(t.Name)
+		if t.Schema != "" {
+			from = d.ident(t.Schema) + "." + from
+		}
+		sources = append(sources, statsSource{schema: t.Schema, table: t.Name, from: from})
+	}
+	return sources, nil
+}
+
+// statsLimit returns the query limited to the first n rows.
+func statsLimit(d *dialect, query string, n int64) string {
+	switch d.name {
+	case "sqlserver":
+		return "SELECT TOP " + strconv.FormatInt(n, 10) + strings.TrimPrefix(query, "SELECT")
+	case "oracle":
+		return query + " FETCH FIRST " + strconv.FormatInt(n, 10) + " ROWS ONLY"
+	}
+	return query + " LIMIT " + strconv.FormatInt(n, 10)
+}
+
+// statsRandom returns the expression of a random value between 0 and 1.
+func statsRandom(d *dialect) (string, bool) {
+	switch d.name {
+	case "postgres", "duckdb":
+		return "random()", true
+	case "mysql":
+		return "RAND()", true
+	case "sqlite":
+		return "(abs(random()) % 1000000) / 1000000.0", true
+	case "sqlserver":
+		return "RAND(CHECKSUM(NEWID()))", true
+	case "oracle":
+		return "DBMS_RANDOM.VALUE", true
+	}
+	return "", false
+}
+
+// statsSampleTable is the name of the temporary table holding the sampled rows.
+const statsSampleTable = "usql_stats_sample"
+
+// statsTempTable returns the name of the temporary table holding the rows of
+// the query, and the statement creating it.
+func statsTempTable(d *dialect, query string) (string, string, bool) {
+	switch d.name {
+	case "postgres", "mysql", "sqlite", "duckdb":
+		return statsSampleTable, "CREATE TEMPORARY TABLE " + statsSampleTable + " AS " + query, true
+	case "sqlserver":
+		name := "#" + statsSampleTable
+		return name, "SELECT * INTO " + name + " FROM (" + query + ") s", true
+	case "oracle":
+		// private temporary tables require Oracle 18c or later
+		name := "ORA$PTT_" + statsSampleTable
+		return name, "CREATE PRIVATE TEMPORARY TABLE " + name + " ON COMMIT PRESERVE DEFINITION AS " + query, true
+	}
+	return "", "", false
+}
+
+// statsDB is a database connection used to profile a table or query.
+type statsDB interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// statsWidth returns the expression of the width in bytes of a column value.
+func statsWidth(d *dialect, col string) string {
+	switch d.name {
+	case "postgres":
+		return "pg_column_size(" + col + ")"
+	case "mysql":
+		return "LENGTH(" + col + ")"
+	case "sqlite":
+		return "LENGTH(CAST(" + col + " AS BLOB))"
+	case "sqlserver":
+		return "DATALENGTH(" + col + ")"
+	case "oracle":
+		return "VSIZE(" + col + ")"
+	case "duckdb":
+		return "strlen(CAST(" + col + " AS VARCHAR))"
+	}
+	return "OCTET_LENGTH(CAST(" + col + " AS VARCHAR(4000)))"
+}
+
+// statsColumn is a column of a profiled table or query.
+type statsColumn struct {
+	name string
+	// comparable indicates the values can be counted distinct and grouped,
+	// ordered indicates the min and max can be computed, and numeric indicates
+	// the mean can be computed.
+	comparable, ordered, numeric bool
+}
+
+// newStatsColumn returns the column with the database type name.
+func newStatsColumn(d *dialect, name, typ string) statsColumn {
+	c := statsColumn{name: name}
+	switch canon, _, _ := canonicalType(typ); canon {
+	case "integer", "smallint", "bigint", "real", "double", "numeric":
+		c.comparable, c.ordered, c.numeric = true, true, true
+	case "varchar", "char", "date", "time", "timestamp", "timestamptz":
+		c.comparable, c.ordered = true, true
+	case "text":
+		// large object types can not be compared
+		c.comparable = d.name != "oracle" && d.name != "sqlserver"
+		c.ordered = c.comparable
+	case "boolean", "uuid":
+		c.comparable = true
+	}
+	return c
+}
+
+// profile computes the column stats of the table or query, returning the stats
+// and the number of rows profiled. The top k values of each column are
+// retrieved when k is greater than 0.
+//
+// A sample of the rows is copied to a temporary table before profiling, so
+// that the rows are only sampled once, and every stat is computed from the
+// same rows.
+func (w DefaultWriter) profile(u *dburl.URL, d *dialect, src statsSource, sample statsSample, verbose bool, k int) ([]ColumnStat, int64, error) {
+	ctx := context.Background()
+	// columns, retrieved from the table or query, as the types of the
+	// temporary table's columns may differ
+	rows, err := w.db.QueryContext(ctx, "SELECT * FROM "+src.from+" WHERE 1=0")
+	if err != nil {
+		return nil, 0, err
+	}
+	types, err := rows.ColumnTypes()
+	rows.Close()
+	if err != nil {
+		return nil, 0, err
+	}
+	cols := make([]statsColumn, len(types))
+	for i, typ := range types {
+		cols[i] = newStatsColumn(d, typ.Name(), typ.DatabaseTypeName())
+	}
+	if sample == (statsSample{}) {
+		return aggregate(ctx, w.db, d, src, src.from, cols, verbose, k)
+	}
+	// sample
+	query := "SELECT * FROM " + src.from
+	switch {
+	case sample.rows != 0:
+		query = statsLimit(d, query, sample.rows)
+	case sample.percent != 0:
+		random, ok := statsRandom(d)
+		if !ok {
+			return nil, 0, fmt.Errorf(text.NotSupportedByDriver, `\ss sample=P%`, u.Driver)
+		}
+		query += " WHERE " + random + " < " + strconv.FormatFloat(sample.percent/100, 'f', -1, 64)
+	}
+	name, create, ok := statsTempTable(d, query)
+	if !ok {
+		return nil, 0, fmt.Errorf(text.NotSupportedByDriver, `\ss sample`, u.Driver)
+	}
+	// temporary tables are only visible to the connection creating them
+	var db statsDB = w.db
+	if c, ok := w.db.(interface {
+		Conn(context.Context) (*sql.Conn, error)
+	}); ok {
+		conn, err := c.Conn(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer conn.Close()
+		db = conn
+	}
+	if _, err := db.ExecContext(ctx, create); err != nil {
+		return nil, 0, err
+	}
+	stats, total, err := aggregate(ctx, db, d, src, name, cols, verbose, k)
+	if _, dropErr := db.ExecContext(ctx, "DROP TABLE "+name); err == nil && dropErr != nil {
+		return nil, 0, dropErr
+	}
+	return stats, total, err
+}
+
+// aggregate computes the column stats of the rows of from with aggregate
+// queries, returning the stats and the number of rows.
+func aggregate(ctx context.Context, db statsDB, d *dialect, src statsSource, from string, cols []statsColumn, verbose bool, k int) ([]ColumnStat, int64, error) {
+	// aggregates
+	exprs := []string{"COUNT(*)"}
+	for _, c := range cols {
+		col := d.ident(c.name)
+		exprs = append(exprs, "COUNT("+col+")", "AVG("+statsWidth(d, col)+")")
+		if c.comparable {
+			exprs = append(exprs, "COUNT(DISTINCT "+col+")")
+		}
+		if c.ordered && verbose {
+			exprs = append(exprs, "MIN("+col+")", "MAX("+col+")")
+		}
+		if c.numeric && verbose {
+			exprs = append(exprs, "AVG("+col+" * 1.0)")
+		}
+	}
+	vals := make([]sql.NullString, len(exprs))
+	dest := make([]interface{}, len(exprs))
+	for i := range vals {
+		dest[i] = &vals[i]
+	}
+	if err := db.QueryRowContext(ctx, "SELECT "+strings.Join(exprs, ", ")+" FROM "+from).Scan(dest...); err != nil {
+		return nil, 0, err
+	}
+	total, _ := strconv.ParseInt(vals[0].String, 10, 64)
+	stats := make([]ColumnStat, len(cols))
+	i := 1
+	next := func() sql.NullString {
+		i++
+		return vals[i-1]
+	}
+	for j, c := range cols {
+		s := ColumnStat{Schema: src.schema, Table: src.table, Name: c.name}
+		count, _ := strconv.ParseInt(next().String, 10, 64)
+		if total != 0 {
+			s.NullFrac = float64(total-count) / float64(total)
+		}
+		if width, err := strconv.ParseFloat(next().String, 64); err == nil {
+			s.AvgWidth = int(math.Round(width))
+		}
+		if c.comparable {
+			s.NumDistinct, _ = strconv.ParseInt(next().String, 10, 64)
+		}
+		if c.ordered && verbose {
+			s.Min, s.Max = next().String, next().String
+		}
+		if c.numeric && verbose {
+			if mean, err := strconv.ParseFloat(next().String, 64); err == nil {
+				s.Mean = strconv.FormatFloat(math.Round(mean*1e4)/1e4, 'f', -1, 64)
+			}
+		}
+		if c.comparable && k > 0 && total != 0 {
+			var err error
+			if s.TopN, s.TopNFreqs, err = topN(ctx, db, d, from, c.name, k, total); err != nil {
+				return nil, 0, err
+			}
+		}
+		stats[j] = s
+	}
+	return stats, total, nil
+}
+
+// topN returns the k most common non-NULL values of the column, and their
+// frequencies.
+func topN(ctx context.Context, db statsDB, d *dialect, from, name string, k int, total int64) ([]string, []float64, error) {
+	col := d.ident(name)
+	query := statsLimit(d, "SELECT "+col+", COUNT(*) FROM "+from+" WHERE "+col+" IS NOT NULL GROUP BY "+col+" ORDER BY COUNT(*) DESC, "+col, int64(k))
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var values []string
+	var freqs []float64
+	for rows.Next() {
+		var v sql.NullString
+		var n int64
+		if err := rows.Scan(&v, &n); err != nil {
+			return nil, nil, err
+		}
+		values, freqs = append(values, v.String), append(freqs, float64(n)/float64(total))
+	}
+	return values, freqs, rows.Err()
+}
@@ -0,0 +1,88 @@
+package metadata
+
+import (
+	"testing"
+)
+
+func TestParseStatsSample(t *testing.T) {
+	tests := []struct {
+		s   string
+		exp statsSample
+		err bool
+	}{
+		{"", statsSample{}, false},
+		{"1000", statsSample{rows: 1000}, false},
+		{"10%", statsSample{percent: 10}, false},
+		{"0.5%", statsSample{percent: 0.5}, false},
+		{"0", statsSample{}, true},
+		{"-5", statsSample{}, true},
+		{"150%", statsSample{}, true},
+		{"abc", statsSample{}, true},
+	}
+	for _, test := range tests {
+		sample, err := parseStatsSample(test.s)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("%q expected error, got: %v", test.s, sample)
+		case !test.err && err != nil:
+			t.Errorf("%q expected no error, got: %v", test.s, err)
+		case sample != test.exp:
+			t.Errorf("%q expected %v, got: %v", test.s, test.exp, sample)
+		}
+	}
+}
+
+func TestStatsQueries(t *testing.T) {
+	tests := []struct {
+		dialect, limit, width, temp string
+		random                      bool
+	}{
+		{"generic", "SELECT * FROM t LIMIT 10", `OCTET_LENGTH(CAST("a" AS VARCHAR(4000)))`, "", false},
+		{"postgres", "SELECT * FROM t LIMIT 10", `pg_column_size("a")`, "CREATE TEMPORARY TABLE usql_stats_sample AS SELECT * FROM t", true},
+		{"mysql", "SELECT * FROM t LIMIT 10", "LENGTH(`a`)", "CREATE TEMPORARY TABLE usql_stats_sample AS SELECT * FROM t", true},
+		{"sqlite", "SELECT * FROM t LIMIT 10", `LENGTH(CAST("a" AS BLOB))`, "CREATE TEMPORARY TABLE usql_stats_sample AS SELECT * FROM t", true},
+		{"sqlserver", "SELECT TOP 10 * FROM t", "DATALENGTH([a])", "SELECT * INTO #usql_stats_sample FROM (SELECT * FROM t) s", true},
+		{"oracle", "SELECT * FROM t FETCH FIRST 10 ROWS ONLY", `VSIZE("a")`, "CREATE PRIVATE TEMPORARY TABLE ORA$PTT_usql_stats_sample ON COMMIT PRESERVE DEFINITION AS SELECT * FROM t", true},
+	}
+	for _, test := range tests {
+		d, ok := lookupDialect(test.dialect)
+		if !ok {
+			t.Fatalf("expected dialect %q to be valid", test.dialect)
+		}
+		if s := statsLimit(d, "SELECT * FROM t", 10); s != test.limit {
+			t.Errorf("%s expected limit %q, got: %q", test.dialect, test.limit, s)
+		}
+		if s := statsWidth(d, d.ident("a")); s != test.width {
+			t.Errorf("%s expected width %q, got: %q", test.dialect, test.width, s)
+		}
+		if _, ok := statsRandom(d); ok != test.random {
+			t.Errorf("%s expected random %t, got: %t", test.dialect, test.random, ok)
+		}
+		if _, s, _ := statsTempTable(d, "SELECT * FROM t"); s != test.temp {
+			t.Errorf("%s expected temporary table %q, got: %q", test.dialect, test.temp, s)
+		}
+	}
+}
+
+func TestNewStatsColumn(t *testing.T) {
+	tests := []struct {
+		dialect, typ                 string
+		comparable, ordered, numeric bool
+	}{
+		{"postgres", "INT4", true, true, true},
+		{"postgres", "NUMERIC", true, true, true},
+		{"postgres", "VARCHAR", true, true, false},
+		{"postgres", "TEXT", true, true, false},
+		{"postgres", "BOOL", true, false, false},
+		{"postgres", "JSONB", false, false, false},
+		{"sqlserver", "NTEXT", false, false, false},
+		{"sqlite", "TIMESTAMP", true, true, false},
+	}
+	for _, test := range tests {
+		d, _ := lookupDialect(test.dialect)
+		c := newStatsColumn(d, "a", test.typ)
+		if c.comparable != test.comparable || c.ordered != test.ordered || c.numeric != test.numeric {
+			t.Errorf("%s %s expected %t/%t/%t, got: %t/%t/%t", test.dialect, test.typ, test.comparable, test.ordered, test.numeric, c.comparable, c.ordered, c.numeric)
+		}
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"fmt"
 	"io"
+	"regexp"
 	"strings"
+	"unicode"
 
 	"github.com/xo/dburl"
 	"github.com/xo/tblfmt"
@@ -55,9 +57,7 @@ func NewDefaultWriter(r Reader, opts ...WriterOption) func(db DB, w io.Writer) W
 			't': {"TRIGGER"},
 			'w': {"WINDOW"},
 		},
-		systemSchemas: map[string]struct{}{
-			"information_schema": {},
-		},
+		systemSchemas: defaultSystemSchemas(),
 	}
 	for _, o := range opts {
 		o(defaultWriter)
@@ -69,6 +69,14 @@ func NewDefaultWriter(r Reader, opts ...WriterOption) func(db DB, w io.Writer) W
 	}
 }
 
+// defaultSystemSchemas returns the schemas ignored by default, in addition to
+// the system schemas excluded by readers.
+func defaultSystemSchemas() map[string]struct{} {
+	return map[string]struct{}{
+		"information_schema": {},
+	}
+}
+
 // WriterOption to configure the DefaultWriter
 type WriterOption func(*DefaultWriter)
 
@@ -688,45 +696,89 @@ func (w DefaultWriter) ListIndexes(u *dburl.URL, pattern string, verbose, showSy
 	return tblfmt.EncodeAll(w.w, res, params)
 }
 
-// ShowStats of columns for tables matching pattern
-func (w DefaultWriter) ShowStats(u *dburl.URL, statTypes, pattern string, verbose bool, k int) error {
-	r, ok := w.r.(ColumnStatReader)
-	if !ok {
-		return fmt.Errorf(text.NotSupportedByDriver, `\ss`, u.Driver)
-	}
-	sp, tp, err := parsePattern(pattern)
-	if err != nil {
-		return fmt.Errorf("failed to parse search pattern: %w", err)
-	}
-
-	rows := int64(0)
-	tr, ok := w.r.(TableReader)
-	if ok {
-		tables, err := tr.Tables(Filter{Schema: sp, Name: tp})
+// ShowStats of columns for tables matching pattern, or for a query. Tables are
+// profiled with aggregate queries when the reader does not provide column
+// stats, when a sample of the rows (N rows or P% of rows) is requested, or
+// when profile is true. Profiling can be slow on large tables, so tables
+// without column stats (ie, not yet analyzed) are only profiled when profile
+// is true.
+func (w DefaultWriter) ShowStats(u *dburl.URL, statTypes, pattern string, verbose bool, k int, sample string, profile bool) error {
+	if r, ok := w.r.(ColumnStatReader); ok && sample == "" && !profile && !statsQueryRE.MatchString(pattern) {
+		sp, tp, err := parsePattern(pattern)
 		if err != nil {
-			return fmt.Errorf("failed to get table entry: %w", err)
+			return fmt.Errorf("failed to parse search pattern: %w", err)
 		}
-		defer tables.Close()
-		if tables.Next() {
-			rows = tables.Get().Rows
+
+		rows := int64(0)
+		tr, ok := w.r.(TableReader)
+		if ok {
+			tables, err := tr.Tables(Filter{Schema: sp, Name: tp})
+			if err != nil {
+				return fmt.Errorf("failed to get table entry: %w", err)
+			}
+			defer tables.Close()
+			if tables.Next() {
+				rows = tables.Get().Rows
+			}
 		}
-	}
 
-	types := []string{"basic"}
-	if verbose {
-		types = append(types, "extended")
+		types := []string{"basic"}
+		if verbose {
+			types = append(types, "extended")
+		}
+		res, err := r.ColumnStats(Filter{Schema: sp, Parent: tp, Types: types})
+		switch {
+		case err == text.ErrNotSupported:
+		case err != nil:
+			return fmt.Errorf("failed to get column stats: %w", err)
+		case res.Len() != 0:
+			defer res.Close()
+			return w.writeStats(res, verbose, k, func(*ColumnStat) int64 {
+				return rows
+			})
+		default:
+			res.Close()
+			fmt.Fprintf(w.w, text.ColumnStatsNotFound, pattern)
+			fmt.Fprintln(w.w)
+			return nil
+		}
 	}
-	res, err := r.ColumnStats(Filter{Schema: sp, Parent: tp, Types: types})
+	// profile the tables or query
+	s, err := parseStatsSample(sample)
 	if err != nil {
-		return fmt.Errorf("failed to get column stats: %w", err)
+		return err
 	}
-	defer res.Close()
-
-	if res.Len() == 0 {
+	d, ok := lookupDialect(u.Driver)
+	if !ok {
+		d = dialects["generic"]
+	}
+	sources, err := w.statsSources(d, pattern)
+	if err != nil {
+		return err
+	}
+	var stats []ColumnStat
+	totals := make(map[[2]string]int64)
+	for _, src := range sources {
+		v, total, err := w.profile(u, d, src, s, verbose, k)
+		if err != nil {
+			return fmt.Errorf("failed to profile %s: %w", src.from, err)
+		}
+		stats = append(stats, v...)
+		totals[[2]string{src.schema, src.table}] = total
+	}
+	if len(stats) == 0 {
 		fmt.Fprintf(w.w, text.RelationNotFound, pattern)
 		fmt.Fprintln(w.w)
 		return nil
 	}
+	return w.writeStats(NewColumnStatSet(stats), verbose, k, func(f *ColumnStat) int64 {
+		return totals[[2]string{f.Schema, f.Table}]
+	})
+}
+
+// writeStats writes the column stats, using rows to determine the number of
+// rows of the column's table.
+func (w DefaultWriter) writeStats(res *ColumnStatSet, verbose bool, k int, rows func(*ColumnStat) int64) error {
 	columns := []string{"Schema", "Table", "Name", "Average width", "Nulls fraction", "Distinct values", "Dist. fraction"}
 	if verbose {
 		columns = append(columns, "Minimum value", "Maximum value", "Mean value", "Top N common values", "Top N values freqs")
@@ -743,7 +795,7 @@ func (w DefaultWriter) ShowStats(u *dburl.URL, statTypes, pattern string, verbos
 			n = len(freqs)
 		}
 		distFrac := 1.0
-		if rows != 0 && f.NumDistinct != rows {
+		if rows := rows(f); rows != 0 && f.NumDistinct != rows {
 			distFrac = float64(f.NumDistinct) / float64(rows)
 		}
 		v := []interface{}{
@@ -821,6 +873,301 @@ func (w DefaultWriter) ListPrivilegeSummaries(u *dburl.URL, pattern string, show
 	return tblfmt.EncodeAll(w.w, res, params)
 }
 
+// ListRoles matching pattern
+func (w DefaultWriter) ListRoles(u *dburl.URL, pattern string, verbose, showSystem bool) error {
+	r, ok := w.r.(RoleReader)
+	if !ok {
+		return fmt.Errorf(text.NotSupportedByDriver, `\du`, u.Driver)
+	}
+	res, err := r.Roles(Filter{Name: strings.ReplaceAll(pattern, "*", "%"), WithSystem: showSystem})
+	if err != nil {
+		return fmt.Errorf("failed to list roles: %w", err)
+	}
+	defer res.Close()
+
+	columns := []string{"Role name", "Attributes", "Member of"}
+	if verbose {
+		columns = append(columns, "Description")
+	}
+	res.SetColumns(columns)
+	res.SetScanValues(func(r Result) []interface{} {
+		return r.(*Role).Values()[:len(columns)]
+	})
+
+	params := env.Vars().Print()
+	params["title"] = "List of roles"
+	return tblfmt.EncodeAll(w.w, res, params)
+}
+
+// ListTypes matching pattern
+func (w DefaultWriter) ListTypes(u *dburl.URL, pattern string, verbose, showSystem bool) error {
+	r, ok := w.r.(TypeReader)
+	if !ok {
+		return fmt.Errorf(text.NotSupportedByDriver, `\dT`, u.Driver)
+	}
+	sp, tp, err := parsePattern(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to parse search pattern: %w", err)
+	}
+	res, err := r.Types(Filter{Schema: sp, Name: tp, WithSystem: showSystem})
+	if err != nil {
+		return fmt.Errorf("failed to list types: %w", err)
+	}
+	defer res.Close()
+
+	if !showSystem {
+		// in case the reader doesn't implement WithSystem
+		res.SetFilter(func(r Result) bool {
+			_, ok := w.systemSchemas[r.(*Type).Schema]
+			return !ok
+		})
+	}
+	columns := []string{"Schema", "Name", "Type"}
+	if verbose {
+		columns = append(columns, "Size", "Elements")
+	}
+	columns = append(columns, "Description")
+	res.SetColumns(columns)
+	res.SetScanValues(func(r Result) []interface{} {
+		f := r.(*Type)
+		v := []interface{}{f.Schema, f.Name, f.Type}
+		if verbose {
+			v = append(v, f.Size, strings.Join(f.Elements, "\n"))
+		}
+		return append(v, f.Description)
+	})
+
+	params := env.Vars().Print()
+	params["title"] = "List of data types"
+	return tblfmt.EncodeAll(w.w, res, params)
+}
+
+// ListExtensions matching pattern, or the objects belonging to each of them
+// when verbose
+func (w DefaultWriter) ListExtensions(u *dburl.URL, pattern string, verbose bool) error {
+	r, ok := w.r.(ExtensionReader)
+	if !ok {
+		return fmt.Errorf(text.NotSupportedByDriver, `\dx`, u.Driver)
+	}
+	res, err := r.Extensions(Filter{Name: strings.ReplaceAll(pattern, "*", "%")})
+	if err != nil {
+		return fmt.Errorf("failed to list extensions: %w", err)
+	}
+	defer res.Close()
+
+	params := env.Vars().Print()
+	if !verbose {
+		params["title"] = "List of installed extensions"
+		return tblfmt.EncodeAll(w.w, res, params)
+	}
+	if res.Len() == 0 {
+		fmt.Fprintf(w.w, text.ExtensionNotFound, pattern)
+		fmt.Fprintln(w.w)
+		return nil
+	}
+	or, ok := w.r.(ExtensionObjectReader)
+	if !ok {
+		return fmt.Errorf(text.NotSupportedByDriver, `\dx+`, u.Driver)
+	}
+	for res.Next() {
+		name := res.Get().Name
+		objs, err := or.ExtensionObjects(Filter{Parent: name})
+		switch {
+		case err == text.ErrNotSupported:
+			return fmt.Errorf(text.NotSupportedByDriver, `\dx+`, u.Driver)
+		case err != nil:
+			return fmt.Errorf("failed to list objects of extension %s: %w", name, err)
+		}
+		objs.SetColumns([]string{"Object description"})
+		objs.SetScanValues(func(r Result) []interface{} {
+			return []interface{}{r.(*ExtensionObject).Description}
+		})
+		params["title"] = fmt.Sprintf("Objects in extension %q", name)
+		err = tblfmt.EncodeAll(w.w, objs, params)
+		objs.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListSettings matching pattern
+func (w DefaultWriter) ListSettings(u *dburl.URL, pattern string, verbose bool) error {
+	r, ok := w.r.(SettingsReader)
+	if !ok {
+		return fmt.Errorf(text.NotSupportedByDriver, `\dconfig`, u.Driver)
+	}
+	res, err := r.Settings(Filter{Name: strings.ReplaceAll(pattern, "*", "%")})
+	if err != nil {
+		return fmt.Errorf("failed to list settings: %w", err)
+	}
+	defer res.Close()
+
+	columns := []string{"Parameter", "Value"}
+	if verbose {
+		columns = append(columns, "Unit", "Source", "Restart required", "Description")
+	}
+	res.SetColumns(columns)
+	res.SetScanValues(func(r Result) []interface{} {
+		return r.(*Setting).Values()[:len(columns)]
+	})
+
+	params := env.Vars().Print()
+	params["title"] = "List of configuration parameters"
+	return tblfmt.EncodeAll(w.w, res, params)
+}
+
+// ShowDefinition of the function or view matching name
+func (w DefaultWriter) ShowDefinition(u *dburl.URL, defType, name string, verbose bool) error {
+	if _, ok := w.r.(DefinitionReader); !ok {
+		return fmt.Errorf(text.NotSupportedByDriver, `\`+defType, u.Driver)
+	}
+	if name == "" {
+		return fmt.Errorf(`\%s: %w`, defType, text.ErrMissingRequiredArgument)
+	}
+	isFunc := defType == "sf"
+	typ := "view"
+	if isFunc {
+		typ = "function"
+	}
+	def, err := GetDefinition(w.r, typ, name)
+	if err != nil {
+		return err
+	}
+	if verbose {
+		def = numberLines(def, isFunc && hasCreatePrefix(def))
+	}
+	_, err = fmt.Fprintln(w.w, def)
+	return err
+}
+
+// GetDefinition returns the CREATE statement of the function or view (typ is
+// either "function" or "view") matching name. Functions may be specified
+// with their argument types, ie: func(integer, text).
+func GetDefinition(r Reader, typ, name string) (string, error) {
+	d, err := getDefinition(r, typ, name)
+	if err != nil {
+		return "", err
+	}
+	return d.Definition, nil
+}
+
+// GetReplacementDefinition returns the statements replacing the function or
+// view (typ is either "function" or "view") matching name with its current
+// definition, for editing and re-executing the definition. Returns
+// text.ErrNotSupported when the definition can not replace the existing
+// function or view.
+func GetReplacementDefinition(r Reader, typ, name string) (string, error) {
+	d, err := getDefinition(r, typ, name)
+	if err != nil {
+		return "", err
+	}
+	if rr, ok := r.(ReplacementDefinitionReader); ok {
+		switch s, err := rr.ReplacementDefinition(d); {
+		case err == nil:
+			return s, nil
+		case err != text.ErrNotSupported:
+			return "", err
+		}
+	}
+	if !createOrReplaceRE.MatchString(d.Definition) {
+		return "", text.ErrNotSupported
+	}
+	return d.Definition, nil
+}
+
+// createOrReplaceRE matches a CREATE OR REPLACE or CREATE OR ALTER statement.
+var createOrReplaceRE = regexp.MustCompile(`(?is)^\s*CREATE\s+OR\s+(REPLACE|ALTER)\s`)
+
+// getDefinition returns the definition of the function or view matching
+// name, adding the CREATE statement to view definitions without one.
+func getDefinition(r Reader, typ, name string) (*Definition, error) {
+	dr, ok := r.(DefinitionReader)
+	if !ok {
+		return nil, text.ErrNotSupported
+	}
+	types := []string{"FUNCTION", "PROCEDURE"}
+	if typ == "view" {
+		types = []string{"VIEW", "MATERIALIZED VIEW"}
+	}
+	// split argument types from name
+	var args string
+	if i := strings.IndexRune(name, '('); i != -1 && typ == "function" {
+		name, args = strings.TrimSpace(name[:i]), normalizeArgTypes(strings.TrimSuffix(name[i+1:], ")"))
+	}
+	sp, tp, err := parsePattern(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse search pattern: %w", err)
+	}
+	res, err := dr.Definitions(Filter{Schema: sp, Name: tp, Types: types, OnlyVisible: sp == "", WithSystem: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s definition: %w", typ, err)
+	}
+	defer res.Close()
+	if args != "" {
+		res.SetFilter(func(r Result) bool {
+			return normalizeArgTypes(r.(*Definition).ArgTypes) == args
+		})
+	}
+	switch res.Len() {
+	case 0:
+		return nil, fmt.Errorf(text.DefinitionNotFound, typ, name)
+	case 1:
+	default:
+		return nil, fmt.Errorf(text.DefinitionAmbiguous, typ, name)
+	}
+	res.Next()
+	d := res.Get()
+	d.Definition = strings.TrimRightFunc(d.Definition, unicode.IsSpace)
+	if typ == "view" && !hasCreatePrefix(d.Definition) {
+		n := d.Name
+		if d.Schema != "" {
+			n = d.Schema + "." + n
+		}
+		d.Definition = "CREATE OR REPLACE VIEW " + n + " AS\n" + d.Definition
+	}
+	return d, nil
+}
+
+// hasCreatePrefix returns true when s starts with CREATE.
+func hasCreatePrefix(s string) bool {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	return len(s) >= 6 && strings.EqualFold(s[:6], "CREATE")
+}
+
+// normalizeArgTypes normalizes a function's argument types for comparison.
+func normalizeArgTypes(s string) string {
+	var v []string
+	for _, arg := range strings.Split(s, ",") {
+		v = append(v, strings.Join(strings.Fields(strings.ToLower(arg)), " "))
+	}
+	return strings.Join(v, ",")
+}
+
+// numberLines prefixes the lines of a definition with line numbers. When
+// header is true, lines of a function definition preceding the function body
+// are not numbered, similar to psql.
+func numberLines(def string, header bool) string {
+	var b strings.Builder
+	n := 0
+	for i, line := range strings.Split(def, "\n") {
+		if i != 0 {
+			b.WriteByte('\n')
+		}
+		if header && (strings.HasPrefix(line, "AS ") || strings.HasPrefix(line, "BEGIN ") || strings.HasPrefix(line, "RETURN ")) {
+			header = false
+		}
+		if header {
+			b.WriteString("        " + line)
+			continue
+		}
+		n++
+		fmt.Fprintf(&b, "%-7d %s", n, line)
+	}
+	return b.String()
+}
+
 func parsePattern(pattern string) (string, string, error) {
 	// TODO do proper escaping, quoting etc
 	if strings.ContainsRune(pattern, '.') {
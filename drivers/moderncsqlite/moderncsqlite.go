@@ -40,6 +40,8 @@ func init() {
 		},
 		ConvertBytes:      sqshared.ConvertBytes,
 		NewMetadataReader: sqshared.NewMetadataReader,
+		Explain:           sqshared.Explain,
+		ByteOrder:         sqshared.ByteOrder,
 		Copy:              drivers.CopyWithInsert(func(int) string { return "?" }),
 	})
 }
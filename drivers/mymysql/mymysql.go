@@ -38,5 +38,6 @@ func init() {
 		},
 		Copy:         drivers.CopyWithInsert(func(int) string { return "?" }),
 		NewCompleter: mymeta.NewCompleter,
+		ByteOrder:    mymeta.ByteOrder,
 	})
 }
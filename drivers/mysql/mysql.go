@@ -35,6 +35,22 @@ func init() {
 			}
 			return "", err.Error()
 		},
+		ErrDetail: func(err error) *drivers.ErrorDetail {
+			e, ok := err.(*mysql.MySQLError)
+			if !ok {
+				return nil
+			}
+			// prefer the SQLSTATE, keeping the error number as the message's
+			// prefix, as with Err
+			code, msg := strconv.Itoa(int(e.Number)), e.Message
+			if e.SQLState != [5]byte{} {
+				code, msg = string(e.SQLState[:]), code+": "+msg
+			}
+			return &drivers.ErrorDetail{
+				Code:    code,
+				Message: msg,
+			}
+		},
 		IsPasswordErr: func(err error) bool {
 			if e, ok := err.(*mysql.MySQLError); ok {
 				return e.Number == 1045
@@ -45,7 +61,10 @@ func init() {
 		NewMetadataWriter: func(db drivers.DB, w io.Writer, opts ...metadata.ReaderOption) metadata.Writer {
 			return metadata.NewDefaultWriter(mymeta.NewReader(db, opts...))(db, w)
 		},
-		Copy:         drivers.CopyWithInsert(func(int) string { return "?" }),
-		NewCompleter: mymeta.NewCompleter,
+		Copy:             drivers.CopyWithInsert(func(int) string { return "?" }),
+		NewCompleter:     mymeta.NewCompleter,
+		FunctionTemplate: "CREATE FUNCTION  ()\nRETURNS \nDETERMINISTIC\nBEGIN\n\nEND",
+		Explain:          mymeta.Explain,
+		ByteOrder:        mymeta.ByteOrder,
 	}, "memsql", "vitess", "tidb")
 }
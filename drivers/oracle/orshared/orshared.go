@@ -67,8 +67,25 @@ func Register(name string, err func(error) (string, string), isPasswordErr func(
 		NewMetadataWriter: func(db drivers.DB, w io.Writer, opts ...metadata.ReaderOption) metadata.Writer {
 			return metadata.NewDefaultWriter(orameta.NewReader()(db, opts...))(db, w)
 		},
-		Copy: drivers.CopyWithInsert(func(n int) string {
-			return fmt.Sprintf(":%d", n)
-		}),
+		Copy:             drivers.CopyWithInsert(placeholder),
+		Placeholder:      placeholder,
+		FunctionTemplate: "CREATE OR REPLACE FUNCTION  ()\nRETURN \nIS\nBEGIN\n\nEND",
+		ViewTemplate:     "CREATE OR REPLACE VIEW  AS\nSELECT\n  -- something...\n",
+		// the row limiting clause is not allowed in a subquery on older
+		// versions, and does not change the result's columns
+		DescribeQuery: func(sqlstr string) string {
+			return "SELECT * FROM (" + rowLimitRE.ReplaceAllString(sqlstr, "") + "\n) gdesc WHERE 1=0"
+		},
+		ByteOrder: func(expr string) string {
+			return "NLSSORT(" + expr + ", 'NLS_SORT=BINARY')"
+		},
 	})
 }
+
+// rowLimitRE matches a trailing row limiting clause.
+var rowLimitRE = regexp.MustCompile(`(?is)(?:\s+OFFSET\s+[^\s()]+\s+ROWS?)?(?:\s+FETCH\s+(?:FIRST|NEXT)\s+[^()]*?\s*ROWS?\s+(?:ONLY|WITH\s+TIES))?\s*$`)
+
+// placeholder returns the n-th query parameter placeholder.
+func placeholder(n int) string {
+	return fmt.Sprintf(":%d", n)
+}
@@ -72,6 +72,31 @@ func init() {
 			}
 			return "", err.Error()
 		},
+		ErrDetail: func(err error) *drivers.ErrorDetail {
+			var e *pgconn.PgError
+			if !errors.As(err, &e) {
+				return nil
+			}
+			var loc string
+			if e.File != "" {
+				loc = fmt.Sprintf("%s, %s:%d", e.Routine, e.File, e.Line)
+			}
+			return &drivers.ErrorDetail{
+				Severity:   e.Severity,
+				Code:       e.Code,
+				Message:    e.Message,
+				Detail:     e.Detail,
+				Hint:       e.Hint,
+				Position:   int(e.Position),
+				Where:      e.Where,
+				Schema:     e.SchemaName,
+				Table:      e.TableName,
+				Column:     e.ColumnName,
+				DataType:   e.DataTypeName,
+				Constraint: e.ConstraintName,
+				Location:   loc,
+			}
+		},
 		IsPasswordErr: func(err error) bool {
 			var e *pgconn.PgError
 			if errors.As(err, &e) {
@@ -83,6 +108,13 @@ func init() {
 		NewMetadataWriter: func(db drivers.DB, w io.Writer, opts ...metadata.ReaderOption) metadata.Writer {
 			return metadata.NewDefaultWriter(pgmeta.NewReader()(db, opts...))(db, w)
 		},
+		FunctionTemplate: pgmeta.FunctionTemplate,
+		ViewTemplate:     pgmeta.ViewTemplate,
+		Explain:          pgmeta.Explain,
+		ByteOrder:        pgmeta.ByteOrder,
+		Placeholder: func(n int) string {
+			return fmt.Sprintf("$%d", n)
+		},
 		Copy: func(ctx context.Context, db *sql.DB, rows *sql.Rows, table string) (int64, error) {
 			conn, err := db.Conn(context.Background())
 			if err != nil {
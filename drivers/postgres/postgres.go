@@ -13,6 +13,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"github.com/lib/pq" // DRIVER
@@ -99,6 +100,32 @@ func init() {
 			}
 			return "", err.Error()
 		},
+		ErrDetail: func(err error) *drivers.ErrorDetail {
+			e, ok := err.(*pq.Error)
+			if !ok {
+				return nil
+			}
+			pos, _ := strconv.Atoi(e.Position)
+			var loc string
+			if e.File != "" {
+				loc = e.Routine + ", " + e.File + ":" + e.Line
+			}
+			return &drivers.ErrorDetail{
+				Severity:   e.Severity,
+				Code:       string(e.Code),
+				Message:    e.Message,
+				Detail:     e.Detail,
+				Hint:       e.Hint,
+				Position:   pos,
+				Where:      e.Where,
+				Schema:     e.Schema,
+				Table:      e.Table,
+				Column:     e.Column,
+				DataType:   e.DataTypeName,
+				Constraint: e.Constraint,
+				Location:   loc,
+			}
+		},
 		IsPasswordErr: func(err error) bool {
 			if e, ok := err.(*pq.Error); ok {
 				return e.Code.Name() == "invalid_password"
@@ -109,6 +136,13 @@ func init() {
 		NewMetadataWriter: func(db drivers.DB, w io.Writer, opts ...metadata.ReaderOption) metadata.Writer {
 			return metadata.NewDefaultWriter(pgmeta.NewReader()(db, opts...))(db, w)
 		},
+		FunctionTemplate: pgmeta.FunctionTemplate,
+		ViewTemplate:     pgmeta.ViewTemplate,
+		Explain:          pgmeta.Explain,
+		ByteOrder:        pgmeta.ByteOrder,
+		Placeholder: func(n int) string {
+			return fmt.Sprintf("$%d", n)
+		},
 		Copy: func(ctx context.Context, db *sql.DB, rows *sql.Rows, table string) (int64, error) {
 			columns, err := rows.Columns()
 			if err != nil {
@@ -147,6 +181,8 @@ func init() {
 			if err != nil {
 				return 0, fmt.Errorf("failed to begin transaction: %w", err)
 			}
+			// no-op after commit
+			defer tx.Rollback()
 			stmt, err := tx.PrepareContext(ctx, query)
 			if err != nil {
 				return 0, fmt.Errorf("failed to prepare insert query: %w", err)
@@ -3,10 +3,13 @@ package sqshared
 import (
 	"database/sql"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/xo/usql/drivers"
 	"github.com/xo/usql/drivers/metadata"
+	"github.com/xo/usql/text"
 )
 
 type MetadataReader struct {
@@ -22,11 +25,16 @@ func NewMetadataReader(db drivers.DB, opts ...metadata.ReaderOption) metadata.Re
 }
 
 var (
-	_ metadata.BasicReader          = &MetadataReader{}
-	_ metadata.FunctionReader       = &MetadataReader{}
-	_ metadata.FunctionColumnReader = &MetadataReader{}
-	_ metadata.IndexReader          = &MetadataReader{}
-	_ metadata.IndexColumnReader    = &MetadataReader{}
+	_ metadata.BasicReader                 = &MetadataReader{}
+	_ metadata.FunctionReader              = &MetadataReader{}
+	_ metadata.FunctionColumnReader        = &MetadataReader{}
+	_ metadata.IndexReader                 = &MetadataReader{}
+	_ metadata.IndexColumnReader           = &MetadataReader{}
+	_ metadata.ConstraintReader            = &MetadataReader{}
+	_ metadata.ConstraintColumnReader      = &MetadataReader{}
+	_ metadata.TriggerReader               = &MetadataReader{}
+	_ metadata.DefinitionReader            = &MetadataReader{}
+	_ metadata.ReplacementDefinitionReader = &MetadataReader{}
 )
 
 func (r *MetadataReader) SetLimit(l int) {
@@ -317,6 +325,242 @@ JOIN pragma_index_xinfo(i.name) ic`
 	return metadata.NewIndexColumnSet(results), nil
 }
 
+// Constraints of tables; sqlite3 does not name most constraints, so names are
+// derived from the table and column names, similar to PostgreSQL
+func (r MetadataReader) Constraints(f metadata.Filter) (*metadata.ConstraintSet, error) {
+	constraints, _, err := r.constraints(f)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.NewConstraintSet(constraints), nil
+}
+
+// ConstraintColumns of primary, unique and foreign keys
+func (r MetadataReader) ConstraintColumns(f metadata.Filter) (*metadata.ConstraintColumnSet, error) {
+	_, columns, err := r.constraints(f)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.NewConstraintColumnSet(columns), nil
+}
+
+// constraints reads the primary, unique and foreign keys of tables matching
+// the filter's parent, referencing tables matching the filter's reference.
+func (r MetadataReader) constraints(f metadata.Filter) ([]metadata.Constraint, []metadata.ConstraintColumn, error) {
+	qstr := `SELECT
+  m.name,
+  'PRIMARY KEY',
+  '',
+  p.name,
+  p.pk,
+  '',
+  '',
+  '',
+  '',
+  ''
+FROM sqlite_master m
+JOIN pragma_table_info(m.name) p
+WHERE m.type = 'table' AND p.pk > 0
+UNION ALL
+SELECT
+  m.name,
+  'UNIQUE',
+  i.name,
+  c.name,
+  c.seqno + 1,
+  '',
+  '',
+  '',
+  '',
+  ''
+FROM sqlite_master m
+JOIN pragma_index_list(m.name) i
+JOIN pragma_index_info(i.name) c
+WHERE m.type = 'table' AND i.origin = 'u'
+UNION ALL
+SELECT
+  m.name,
+  'FOREIGN KEY',
+  fk.id,
+  fk."from",
+  fk.seq + 1,
+  fk."table",
+  COALESCE(fk."to", ''),
+  fk.on_update,
+  fk.on_delete,
+  fk.match
+FROM sqlite_master m
+JOIN pragma_foreign_key_list(m.name) fk
+WHERE m.type = 'table'`
+	rows, closeRows, err := r.Query("SELECT * FROM (" + qstr + ") ORDER BY 1, 2, 3, 5")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeRows()
+
+	type key struct{ table, typ, id string }
+	var keys []key
+	columns := map[key][]metadata.ConstraintColumn{}
+	constraints := map[key]metadata.Constraint{}
+	for rows.Next() {
+		var k key
+		var c metadata.ConstraintColumn
+		rec := metadata.Constraint{}
+		err = rows.Scan(
+			&k.table,
+			&k.typ,
+			&k.id,
+			&c.Name,
+			&c.OrdinalPosition,
+			&rec.ForeignTable,
+			&c.ForeignName,
+			&rec.UpdateRule,
+			&rec.DeleteRule,
+			&rec.MatchType,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := constraints[k]; !ok {
+			keys = append(keys, k)
+			rec.Table, rec.Type = k.table, k.typ
+			constraints[k] = rec
+		}
+		c.Table, c.ForeignTable = k.table, constraints[k].ForeignTable
+		columns[k] = append(columns[k], c)
+	}
+	if rows.Err() != nil {
+		return nil, nil, rows.Err()
+	}
+
+	var parent, reference, name *regexp.Regexp
+	if parent, err = likeRegexp(f.Parent); err != nil {
+		return nil, nil, err
+	}
+	if reference, err = likeRegexp(f.Reference); err != nil {
+		return nil, nil, err
+	}
+	if name, err = likeRegexp(f.Name); err != nil {
+		return nil, nil, err
+	}
+	var results []metadata.Constraint
+	var resultColumns []metadata.ConstraintColumn
+	for _, k := range keys {
+		rec, cols := constraints[k], columns[k]
+		var names []string
+		for _, c := range cols {
+			names = append(names, c.Name)
+		}
+		suffix := "key"
+		switch rec.Type {
+		case "PRIMARY KEY":
+			names, suffix = nil, "pkey"
+		case "FOREIGN KEY":
+			suffix = "fkey"
+		}
+		rec.Name = strings.Join(append(append([]string{rec.Table}, names...), suffix), "_")
+		switch {
+		case parent != nil && !parent.MatchString(rec.Table),
+			reference != nil && !reference.MatchString(rec.ForeignTable),
+			name != nil && !name.MatchString(rec.Name):
+			continue
+		}
+		results = append(results, rec)
+		for _, c := range cols {
+			c.Constraint = rec.Name
+			resultColumns = append(resultColumns, c)
+		}
+	}
+	return results, resultColumns, nil
+}
+
+// Triggers of tables
+func (r MetadataReader) Triggers(f metadata.Filter) (*metadata.TriggerSet, error) {
+	qstr := `SELECT
+  tbl_name,
+  name,
+  sql
+FROM sqlite_master`
+	conds := []string{"type = 'trigger'"}
+	vals := []interface{}{}
+	if f.Parent != "" {
+		vals = append(vals, f.Parent)
+		conds = append(conds, "tbl_name LIKE ?")
+	}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		conds = append(conds, "name LIKE ?")
+	}
+	rows, closeRows, err := r.query(qstr, conds, "tbl_name, name", vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Trigger{}
+	for rows.Next() {
+		rec := metadata.Trigger{}
+		err = rows.Scan(&rec.Table, &rec.Name, &rec.Definition)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewTriggerSet(results), nil
+}
+
+// Definitions of views; sqlite3 does not store the source of functions
+func (r MetadataReader) Definitions(f metadata.Filter) (*metadata.DefinitionSet, error) {
+	results := []metadata.Definition{}
+	if len(f.Types) != 0 && !slices.Contains(f.Types, "VIEW") {
+		return metadata.NewDefinitionSet(results), nil
+	}
+	qstr := `SELECT
+  name,
+  sql
+FROM (
+    SELECT name, sql FROM sqlite_master WHERE type = 'view'
+    UNION ALL
+    SELECT name, sql FROM sqlite_temp_master WHERE type = 'view'
+)`
+	conds := []string{}
+	vals := []interface{}{}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		conds = append(conds, "name LIKE ?")
+	}
+	rows, closeRows, err := r.query(qstr, conds, "name", vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows()
+
+	for rows.Next() {
+		rec := metadata.Definition{Type: "VIEW"}
+		err = rows.Scan(&rec.Name, &rec.Definition)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewDefinitionSet(results), nil
+}
+
+// ReplacementDefinition returns the definition preceded by a DROP VIEW
+// statement, as SQLite does not support replacing views.
+func (r MetadataReader) ReplacementDefinition(d *metadata.Definition) (string, error) {
+	if d.Type != "VIEW" {
+		return "", text.ErrNotSupported
+	}
+	return `DROP VIEW IF EXISTS "` + strings.ReplaceAll(d.Name, `"`, `""`) + "\";\n" + d.Definition, nil
+}
+
 func (r MetadataReader) query(qstr string, conds []string, order string, vals ...interface{}) (*sql.Rows, func(), error) {
 	if len(conds) != 0 {
 		qstr += "\nWHERE " + strings.Join(conds, " AND ")
@@ -329,3 +573,25 @@ func (r MetadataReader) query(qstr string, conds []string, order string, vals ..
 	}
 	return r.Query(qstr, vals...)
 }
+
+// likeRegexp converts a LIKE pattern to a case insensitive regexp, returning
+// nil for an empty pattern.
+func likeRegexp(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	var sb strings.Builder
+	sb.WriteString("(?is)^")
+	for _, c := range pattern {
+		switch c {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
@@ -3,11 +3,16 @@
 package sqshared
 
 import (
+	"context"
 	"database/sql/driver"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/drivers/explain"
+	"github.com/xo/usql/text"
 )
 
 // ConvertBytes is the byte formatter func for sqlite3 databases.
@@ -24,6 +29,20 @@ func ConvertBytes(buf []byte, tfmt string) (string, error) {
 	return s, nil
 }
 
+// ByteOrder returns the expression sorting a text expression in byte order.
+func ByteOrder(expr string) string {
+	return expr + " COLLATE BINARY"
+}
+
+// Explain returns the query plan for a query, using EXPLAIN QUERY PLAN. SQLite
+// does not support analyzing queries.
+func Explain(ctx context.Context, db drivers.DB, sqlstr string, analyze bool, bind ...interface{}) (*explain.Plan, error) {
+	if analyze {
+		return nil, text.ErrNotSupported
+	}
+	return explain.ParseSQLite(ctx, db, sqlstr, bind...)
+}
+
 // Time provides a type that will correctly scan the various timestamps
 // values stored by the github.com/mattn/go-sqlite3 driver for time.Time
 // values, as well as correctly satisfying the sql/driver/Valuer interface.
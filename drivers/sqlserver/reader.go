@@ -3,11 +3,13 @@ package sqlserver
 import (
 	"database/sql"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/xo/usql/drivers"
 	"github.com/xo/usql/drivers/metadata"
 	infos "github.com/xo/usql/drivers/metadata/informationschema"
+	"github.com/xo/usql/text"
 )
 
 type metaReader struct {
@@ -18,6 +20,11 @@ type metaReader struct {
 var _ metadata.CatalogReader = &metaReader{}
 var _ metadata.IndexReader = &metaReader{}
 var _ metadata.IndexColumnReader = &metaReader{}
+var _ metadata.DefinitionReader = &metaReader{}
+var _ metadata.ReplacementDefinitionReader = &metaReader{}
+var _ metadata.RoleReader = &metaReader{}
+var _ metadata.TypeReader = &metaReader{}
+var _ metadata.SettingsReader = &metaReader{}
 
 func NewReader(db drivers.DB, opts ...metadata.ReaderOption) metadata.Reader {
 	ir := infos.New(
@@ -225,6 +232,250 @@ JOIN sys.types ty ON ty.user_type_id = c.user_type_id
 	return metadata.NewIndexColumnSet(results), nil
 }
 
+func (r metaReader) Definitions(f metadata.Filter) (*metadata.DefinitionSet, error) {
+	qstr := `
+SELECT
+  db_name(),
+  s.name,
+  o.name,
+  CASE WHEN o.type = 'V' THEN 'VIEW' WHEN o.type IN ('P', 'PC') THEN 'PROCEDURE' ELSE 'FUNCTION' END AS type,
+  '',
+  COALESCE(m.definition, '')
+FROM sys.sql_modules m
+JOIN sys.objects o ON o.object_id = m.object_id
+JOIN sys.schemas s ON s.schema_id = o.schema_id
+`
+	conds := []string{"o.type IN ('V', 'P', 'PC', 'FN', 'IF', 'TF', 'FS', 'FT')"}
+	vals := []interface{}{}
+	if f.OnlyVisible {
+		conds = append(conds, "s.name = schema_name()")
+	}
+	if !f.WithSystem {
+		conds = append(conds, "s.name NOT IN ('db_accessadmin', 'db_backupoperator', 'db_datareader', 'db_datawriter', 'db_ddladmin', 'db_denydatareader', 'db_denydatawriter', 'db_owner', 'db_securityadmin', 'INFORMATION_SCHEMA', 'sys')")
+	}
+	if f.Schema != "" {
+		vals = append(vals, f.Schema)
+		conds = append(conds, fmt.Sprintf("s.name LIKE @p%d", len(vals)))
+	}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		conds = append(conds, fmt.Sprintf("o.name LIKE @p%d", len(vals)))
+	}
+	if len(f.Types) != 0 {
+		pholders := []string{}
+		for _, t := range f.Types {
+			vals = append(vals, t)
+			pholders = append(pholders, fmt.Sprintf("@p%d", len(vals)))
+		}
+		conds = append(conds, fmt.Sprintf("CASE WHEN o.type = 'V' THEN 'VIEW' WHEN o.type IN ('P', 'PC') THEN 'PROCEDURE' ELSE 'FUNCTION' END IN (%s)", strings.Join(pholders, ", ")))
+	}
+	rows, closeRows, err := r.query(qstr, conds, "s.name, o.name", vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Definition{}
+	for rows.Next() {
+		rec := metadata.Definition{}
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.Name, &rec.Type, &rec.ArgTypes, &rec.Definition)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewDefinitionSet(results), nil
+}
+
+// ReplacementDefinition returns the definition as a CREATE OR ALTER statement.
+func (r metaReader) ReplacementDefinition(d *metadata.Definition) (string, error) {
+	if !createRE.MatchString(d.Definition) {
+		return "", text.ErrNotSupported
+	}
+	return createRE.ReplaceAllString(d.Definition, "${1}CREATE OR ALTER "), nil
+}
+
+// createRE matches the CREATE keyword of a module definition, preceded by
+// any whitespace and comments.
+var createRE = regexp.MustCompile(`(?is)^((?:\s|--[^\n]*\n|/\*.*?\*/)*)CREATE\s+`)
+
+func (r metaReader) Roles(f metadata.Filter) (*metadata.RoleSet, error) {
+	qstr := `
+SELECT
+  p.name,
+  p.type,
+  COALESCE(p.is_disabled, 0),
+  COALESCE(IS_SRVROLEMEMBER('sysadmin', p.name), 0),
+  COALESCE(STUFF((
+    SELECT ',' + r.name
+    FROM sys.server_role_members m
+    JOIN sys.server_principals r ON r.principal_id = m.role_principal_id
+    WHERE m.member_principal_id = p.principal_id
+    ORDER BY r.name
+    FOR XML PATH('')
+  ), 1, 1, ''), '')
+FROM sys.server_principals p
+`
+	conds := []string{"p.type IN ('S', 'U', 'G', 'R', 'E', 'X')"}
+	vals := []interface{}{}
+	if !f.WithSystem {
+		conds = append(conds, "p.is_fixed_role = 0", "p.name NOT LIKE '##%'", "p.name NOT LIKE 'NT %'")
+	}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		conds = append(conds, fmt.Sprintf("p.name LIKE @p%d", len(vals)))
+	}
+	rows, closeRows, err := r.query(qstr, conds, "p.name", vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Role{}
+	for rows.Next() {
+		var typ, memberOf string
+		var disabled, sysadmin bool
+		rec := metadata.Role{}
+		err = rows.Scan(&rec.Name, &typ, &disabled, &sysadmin, &memberOf)
+		if err != nil {
+			return nil, err
+		}
+		if sysadmin {
+			rec.Attributes = append(rec.Attributes, "Superuser")
+		}
+		switch strings.TrimSpace(typ) {
+		case "S":
+			rec.Attributes = append(rec.Attributes, "SQL login")
+		case "U":
+			rec.Attributes = append(rec.Attributes, "Windows login")
+		case "G":
+			rec.Attributes = append(rec.Attributes, "Windows group")
+		case "E", "X":
+			rec.Attributes = append(rec.Attributes, "External login")
+		case "R":
+			rec.Attributes = append(rec.Attributes, "Server role", "Cannot login")
+		}
+		if disabled {
+			rec.Attributes = append(rec.Attributes, "Cannot login")
+		}
+		if memberOf != "" {
+			rec.MemberOf = strings.Split(memberOf, ",")
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewRoleSet(results), nil
+}
+
+func (r metaReader) Types(f metadata.Filter) (*metadata.TypeSet, error) {
+	qstr := `
+SELECT
+  db_name(),
+  s.name,
+  t.name,
+  CASE
+    WHEN t.is_table_type = 1 THEN 'composite'
+    WHEN t.is_user_defined = 1 THEN 'domain'
+    ELSE 'base'
+  END,
+  CASE WHEN t.max_length = -1 THEN 'var' ELSE CAST(t.max_length AS varchar(10)) END,
+  CASE
+    WHEN t.is_table_type = 1 THEN COALESCE(STUFF((
+      SELECT CHAR(10) + c.name + ' ' + TYPE_NAME(c.user_type_id)
+      FROM sys.table_types tt
+      JOIN sys.columns c ON c.object_id = tt.type_table_object_id
+      WHERE tt.user_type_id = t.user_type_id
+      ORDER BY c.column_id
+      FOR XML PATH(''), TYPE
+    ).value('.', 'nvarchar(max)'), 1, 1, ''), '')
+    WHEN t.is_user_defined = 1 THEN TYPE_NAME(t.system_type_id)
+    ELSE ''
+  END,
+  COALESCE(CAST(ep.value AS nvarchar(max)), '')
+FROM sys.types t
+JOIN sys.schemas s ON s.schema_id = t.schema_id
+LEFT JOIN sys.extended_properties ep ON ep.class = 6 AND ep.major_id = t.user_type_id AND ep.minor_id = 0 AND ep.name = 'MS_Description'
+`
+	conds := []string{}
+	vals := []interface{}{}
+	if !f.WithSystem {
+		conds = append(conds, "t.is_user_defined = 1")
+	}
+	if f.Schema != "" {
+		vals = append(vals, f.Schema)
+		conds = append(conds, fmt.Sprintf("s.name LIKE @p%d", len(vals)))
+	}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		conds = append(conds, fmt.Sprintf("t.name LIKE @p%d", len(vals)))
+	}
+	rows, closeRows, err := r.query(qstr, conds, "s.name, t.name", vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Type{}
+	for rows.Next() {
+		var elements string
+		rec := metadata.Type{}
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.Name, &rec.Type, &rec.Size, &elements, &rec.Description)
+		if err != nil {
+			return nil, err
+		}
+		if elements != "" {
+			rec.Elements = strings.Split(elements, "\n")
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewTypeSet(results), nil
+}
+
+func (r metaReader) Settings(f metadata.Filter) (*metadata.SettingSet, error) {
+	qstr := `
+SELECT
+  name,
+  CAST(value_in_use AS nvarchar(max)),
+  CASE WHEN value <> value_in_use THEN 'pending reconfigure' ELSE 'configured' END,
+  CASE WHEN is_dynamic = 1 THEN 'NO' ELSE 'YES' END,
+  CAST(description AS nvarchar(max))
+FROM sys.configurations
+`
+	conds := []string{}
+	vals := []interface{}{}
+	if f.Name != "" {
+		vals = append(vals, f.Name)
+		conds = append(conds, fmt.Sprintf("name LIKE @p%d", len(vals)))
+	}
+	rows, closeRows, err := r.query(qstr, conds, "name", vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows()
+
+	results := []metadata.Setting{}
+	for rows.Next() {
+		rec := metadata.Setting{}
+		err = rows.Scan(&rec.Name, &rec.Value, &rec.Source, &rec.RestartRequired, &rec.Description)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewSettingSet(results), nil
+}
+
 func (r metaReader) query(qstr string, conds []string, order string, vals ...interface{}) (*sql.Rows, func(), error) {
 	if len(conds) != 0 {
 		qstr += "\nWHERE " + strings.Join(conds, " AND ")
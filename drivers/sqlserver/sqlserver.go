@@ -88,7 +88,17 @@ func init() {
 		NewMetadataWriter: func(db drivers.DB, w io.Writer, opts ...metadata.ReaderOption) metadata.Writer {
 			return metadata.NewDefaultWriter(NewReader(db, opts...))(db, w)
 		},
-		Copy: drivers.CopyWithInsert(placeholder),
+		Copy:        drivers.CopyWithInsert(placeholder),
+		Placeholder: placeholder,
+		// queries with ORDER BY or WITH can not be used as a derived table,
+		// so only retrieve the result's metadata. SET FMTONLY is reverted
+		// once sp_executesql returns.
+		DescribeQuery: func(sqlstr string) string {
+			return "EXEC sp_executesql N'SET FMTONLY ON;\n" + strings.ReplaceAll(sqlstr, "'", "''") + "'"
+		},
+		ByteOrder: func(expr string) string {
+			return expr + " COLLATE Latin1_General_BIN2"
+		},
 	})
 }
 
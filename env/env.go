@@ -56,6 +56,11 @@ func Getenv(keys ...string) (string, bool) {
 	return "", false
 }
 
+// Expand expands the leading ~ in path to the user's home directory.
+func Expand(u *user.User, path string) string {
+	return passfile.Expand(u.HomeDir, path)
+}
+
 // Chdir changes the current working directory to the specified path, or to the
 // user's home directory if path is not specified.
 func Chdir(u *user.User, path string) error {
@@ -154,6 +159,12 @@ func HistoryFile(u *user.User) string {
 	return passfile.Expand(u.HomeDir, path)
 }
 
+// ConnHistoryFile returns the path to the history file for the connection
+// key (see [HistoryFile]), ie ~/.usql_history-<key>.
+func ConnHistoryFile(u *user.User, key string) string {
+	return HistoryFile(u) + "-" + key
+}
+
 // RCFile returns the path to the RC file.
 //
 // Defaults to ~/.<command name>rc, overridden by environment variable
@@ -93,6 +93,46 @@ var varNames = []varName{
 		`ECHO_HIDDEN`,
 		`if set, display internal queries executed by backslash commands; if set to "noexec", shows queries without execution`,
 	},
+	{
+		`ERROR`,
+		`true if last query failed, false if succeeded`,
+	},
+	{
+		`HISTORY_PER_CONNECTION`,
+		`if set, save executed statements to a separate history file for each database connection`,
+	},
+	{
+		`LAST_ERROR_MESSAGE`,
+		`message of the last error`,
+	},
+	{
+		`LAST_ERROR_SQLSTATE`,
+		`SQLSTATE (or driver error code) of the last error, or "00000" if no error`,
+	},
+	{
+		`LAST_QUERY_BYTES`,
+		`number of bytes of output written for the last query's results`,
+	},
+	{
+		`LAST_QUERY_FETCH_TIME`,
+		`time (in milliseconds) spent fetching the last query's result rows`,
+	},
+	{
+		`LAST_QUERY_FIRST_ROW_TIME`,
+		`time (in milliseconds) until the first row of the last query was returned`,
+	},
+	{
+		`LAST_QUERY_FORMAT_TIME`,
+		`time (in milliseconds) spent formatting the last query's results`,
+	},
+	{
+		`LAST_QUERY_ROWS`,
+		`number of rows returned by the last query`,
+	},
+	{
+		`LAST_QUERY_TIME`,
+		`total time (in milliseconds) of the last query`,
+	},
 	{
 		`ON_ERROR_STOP`,
 		`stop batch execution after error`,
@@ -225,6 +265,10 @@ var envVarNames = []varName{
 		text.CommandUpper() + `_HISTORY`,
 		`alternative location for the command history file`,
 	},
+	{
+		text.CommandUpper() + `_HISTORY_PER_CONNECTION`,
+		`default for the HISTORY_PER_CONNECTION variable`,
+	},
 	{
 		text.CommandUpper() + `_PAGER, PAGER`,
 		`name of external pager program`,
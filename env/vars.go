@@ -43,6 +43,13 @@ func NewDefaultVars() *Variables {
 	if v, _ := Getenv(cmdNameUpper + "_SHOW_HOST_INFORMATION"); v != "" {
 		showHostInformation = v
 	}
+	// per-connection history
+	historyPerConnection := "off"
+	if v, _ := Getenv(cmdNameUpper + "_HISTORY_PER_CONNECTION"); v != "" {
+		if b, err := ParseBool(v, "HISTORY_PER_CONNECTION"); err == nil {
+			historyPerConnection = b
+		}
+	}
 	// get NO_COLOR
 	noColor := false
 	if s, ok := Getenv("NO_COLOR"); ok {
@@ -88,6 +95,11 @@ func NewDefaultVars() *Variables {
 			"EDITOR":                editorCmd,
 			"QUIET":                 "off",
 			"ON_ERROR_STOP":         "off",
+			// last error
+			"LAST_ERROR_MESSAGE":  "",
+			"LAST_ERROR_SQLSTATE": "00000",
+			// history
+			"HISTORY_PER_CONNECTION": historyPerConnection,
 			// prompts
 			"PROMPT1": "%S%N%m%/%R%# ",
 			// syntax highlighting variables
@@ -155,7 +167,7 @@ func (v *Variables) Set(name, value string) error {
 		return err
 	}
 	switch name {
-	case "ON_ERROR_STOP", "QUIET":
+	case "ON_ERROR_STOP", "QUIET", "HISTORY_PER_CONNECTION":
 		if value == "" {
 			value = "on"
 		} else {
@@ -6,6 +6,9 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -18,6 +21,7 @@ import (
 	"os/user"
 	"path"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"slices"
 	"strconv"
@@ -25,11 +29,13 @@ import (
 	"syscall"
 	"time"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/formatters"
 	"github.com/alecthomas/chroma/v2/styles"
 	"github.com/go-git/go-billy/v5"
+	"github.com/mattn/go-runewidth"
 	"github.com/xo/dburl"
 	"github.com/xo/dburl/passfile"
 	"github.com/xo/echartsgoja"
@@ -37,8 +43,10 @@ import (
 	"github.com/xo/tblfmt"
 	"github.com/xo/usql/drivers"
 	"github.com/xo/usql/drivers/completer"
+	"github.com/xo/usql/drivers/explain"
 	"github.com/xo/usql/drivers/metadata"
 	"github.com/xo/usql/env"
+	"github.com/xo/usql/history"
 	"github.com/xo/usql/metacmd"
 	"github.com/xo/usql/metacmd/charts"
 	"github.com/xo/usql/rline"
@@ -68,6 +76,10 @@ type Handler struct {
 	nopw bool
 	// timing of every command executed.
 	timing bool
+	// timingVerbose is the per-phase timing of every query executed.
+	timingVerbose bool
+	// stats are the statistics of the last executed query's results.
+	stats *queryStats
 	// singleLineMode is single line mode.
 	singleLineMode bool
 	// buf is the query statement buffer.
@@ -80,12 +92,19 @@ type Handler struct {
 	lastPrint string
 	// lastRaw is the last executed raw query statement.
 	lastRaw string
+	// lastError is the last error returned when executing a statement.
+	lastError error
 	// batch indicates a batch has been started.
 	batch bool
 	// batchEnd is the batch end string.
 	batchEnd string
 	// bind are bound values for that will be used for statement execution.
 	bind []interface{}
+	// prepared are the named prepared statements.
+	prepared map[string]*prepared
+	// named is the name of the prepared statement to execute in place of the
+	// query buffer.
+	named string
 	// u is the active connection information.
 	u *dburl.URL
 	// db is the active database connection.
@@ -94,6 +113,8 @@ type Handler struct {
 	tx *sql.Tx
 	// out file or pipe
 	out io.WriteCloser
+	// conds is the conditional block (\if) stack.
+	conds []cond
 }
 
 // New creates a new input handler.
@@ -136,6 +157,16 @@ func (h *Handler) SetTiming(timing bool) {
 	h.timing = timing
 }
 
+// GetTimingVerbose gets the verbose (per-phase) timing toggle.
+func (h *Handler) GetTimingVerbose() bool {
+	return h.timingVerbose
+}
+
+// SetTimingVerbose sets the verbose (per-phase) timing toggle.
+func (h *Handler) SetTimingVerbose(timingVerbose bool) {
+	h.timingVerbose = timingVerbose
+}
+
 // SetSingleLineMode sets the single line mode toggle.
 func (h *Handler) SetSingleLineMode(singleLineMode bool) {
 	h.singleLineMode = singleLineMode
@@ -164,19 +195,44 @@ func (h *Handler) Run() error {
 	var lastErr error
 	var execute bool
 	for {
-		execute = false
+		execute, cont, opt = false, false, metacmd.Option{}
 		// set prompt
 		if iactive {
 			h.l.Prompt(h.Prompt(env.Get("PROMPT1")))
 		}
 		// read next statement/command
-		switch cmd, paramstr, err = h.buf.Next(env.Untick(h.user, env.Vars(), false)); {
+		cmd, paramstr, err = h.buf.Next(env.Untick(h.user, env.Vars(), false))
+		// discard statements and commands in inactive conditional branches
+		if err == nil && !h.condActive() {
+			// skip inline data block following COPY ... FROM STDIN
+			if h.buf.Ready() && strings.HasPrefix(h.buf.Prefix, "COPY ") && copyFromStdinRE.MatchString(h.buf.String()) {
+				if _, err := io.Copy(io.Discard, h.buf.CopyData()); err != nil {
+					return err
+				}
+			}
+			h.buf.Truncate(h.conds[len(h.conds)-1].n)
+			if name := strings.TrimPrefix(cmd, `\`); !isCondCmd(name) {
+				if iactive && cmd != "" {
+					fmt.Fprintln(stderr, fmt.Sprintf(text.CommandIgnoredUseEndIf, cmd, "Ctrl-C"))
+				}
+				continue
+			}
+		}
+		switch {
 		case h.singleLineMode && err == nil:
 			execute = h.buf.Len != 0
 		case err == rline.ErrInterrupt:
 			h.buf.Reset(nil)
+			// exit the current conditional block
+			if iactive && len(h.conds) != 0 {
+				h.conds = h.conds[:len(h.conds)-1]
+				fmt.Fprintln(stderr, text.ErrIfEscaped)
+			}
 			continue
 		case err == io.EOF:
+			if len(h.conds) != 0 {
+				return text.ErrUnterminatedIf
+			}
 			return lastErr
 		case err != nil:
 			return err
@@ -254,29 +310,47 @@ func (h *Handler) Run() error {
 					h.batch, h.batchEnd = false, ""
 				}
 			}
+			var data io.Reader
 			if h.buf.Len != 0 {
 				h.lastExec, h.lastExecPrefix, h.lastPrint, h.lastRaw = h.buf.String(), h.buf.Prefix, h.buf.PrintString(), h.buf.RawString()
+				// capture inline data block following COPY ... FROM STDIN
+				if h.buf.Ready() && strings.HasPrefix(h.buf.Prefix, "COPY ") && copyFromStdinRE.MatchString(h.lastExec) {
+					data = h.buf.CopyData()
+				}
 				h.buf.Reset(nil)
 			}
 			// log.Printf(">> PROCESS EXECUTE: (%s) `%s`", h.lastPrefix, h.last)
-			if !h.batch && h.lastExec != "" && h.lastExec != ";" {
+			if !h.batch && (h.lastExec != "" && h.lastExec != ";" || h.named != "") {
 				// force a transaction for batched queries for certain drivers
 				var forceBatch bool
 				if h.u != nil {
 					_, _, forceBatch = drivers.IsBatchQueryPrefix(h.u, stmt.FindPrefix(h.lastExec, true, true, true))
 					forceBatch = forceBatch && drivers.BatchAsTransaction(h.u)
 				}
+				// save to connection history
+				if iactive {
+					h.saveHistory(h.lastRaw)
+				}
 				// execute
 				out := stdout
 				if h.out != nil {
 					out = h.out
 				}
 				ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
-				if err = h.Execute(ctx, out, opt, h.lastExecPrefix, h.lastExec, forceBatch, h.unbind()...); err != nil {
+				if name := h.unbindNamed(); data == nil && name != "" {
+					err = h.ExecuteNamed(ctx, out, opt, name, h.unbind()...)
+				} else if data != nil {
+					err = h.doCopyIn(ctx, out, h.lastExec, data)
+				} else {
+					err = h.Execute(ctx, out, opt, h.lastExecPrefix, h.lastExec, forceBatch, h.unbind()...)
+				}
+				h.setError(err)
+				if err != nil {
 					lastErr = WrapErr(h.lastExec, err)
 					if env.Get("ON_ERROR_STOP") == "on" {
 						if iactive {
 							fmt.Fprintln(stderr, "error:", err)
+							h.printErrorPosition(stderr, err, h.lastExec)
 							h.buf.Reset([]rune{}) // empty the buffer so no other statements are run
 							continue
 						} else {
@@ -285,6 +359,7 @@ func (h *Handler) Run() error {
 						}
 					} else {
 						fmt.Fprintln(stderr, "error:", err)
+						h.printErrorPosition(stderr, err, h.lastExec)
 					}
 				}
 				stop()
@@ -456,6 +531,10 @@ func (h *Handler) Execute(ctx context.Context, w io.Writer, opt metacmd.Option,
 		f = h.doExecWatch
 	case metacmd.ExecChart:
 		f = h.doExecChart
+	case metacmd.ExecDesc:
+		f = h.doExecDesc
+	case metacmd.ExecExplain:
+		f = h.doExecExplain
 	}
 	if err = drivers.WrapErr(h.u.Driver, f(ctx, w, opt, prefix, sqlstr, qtyp, bind)); err != nil {
 		if forceTrans {
@@ -470,6 +549,77 @@ func (h *Handler) Execute(ctx context.Context, w io.Writer, opt metacmd.Option,
 	return nil
 }
 
+// setError sets the last error and the ERROR, LAST_ERROR_SQLSTATE,
+// LAST_ERROR_MESSAGE, and ROW_COUNT variables after executing a statement.
+func (h *Handler) setError(err error) {
+	if err == nil {
+		_ = env.Vars().Set("ERROR", "false")
+		return
+	}
+	h.lastError = err
+	detail := drivers.ErrDetail(err)
+	_ = env.Vars().Set("ERROR", "true")
+	_ = env.Vars().Set("LAST_ERROR_SQLSTATE", detail.Code)
+	_ = env.Vars().Set("LAST_ERROR_MESSAGE", detail.Message)
+	_ = env.Vars().Set("ROW_COUNT", "0")
+}
+
+// printErrorPosition writes the line of the query containing the position of
+// the error (as reported by the driver) to w, with a caret under the
+// character at the position, similar to psql.
+func (h *Handler) printErrorPosition(w io.Writer, err error, sqlstr string) {
+	detail := drivers.ErrDetail(err)
+	if detail == nil || detail.Position <= 0 || sqlstr == "" {
+		return
+	}
+	lines, n, pos := strings.Split(sqlstr, "\n"), 0, detail.Position-1
+	switch {
+	case detail.Line > 0:
+		n = detail.Line - 1
+	default:
+		for ; n < len(lines)-1 && pos > utf8.RuneCountInString(lines[n]); n++ {
+			pos -= utf8.RuneCountInString(lines[n]) + 1
+		}
+	}
+	if n >= len(lines) {
+		return
+	}
+	line := []rune(strings.NewReplacer("\t", " ", "\r", "").Replace(lines[n]))
+	pos = min(pos, len(line))
+	// truncate long lines around the position
+	start, end := 0, len(line)
+	if end > errorLineWidth {
+		start = max(0, pos-errorLineWidth/2)
+		end = min(len(line), start+errorLineWidth)
+		start = max(0, end-errorLineWidth)
+	}
+	prefix, suffix := fmt.Sprintf("LINE %d: ", n+1), ""
+	if start > 0 {
+		prefix += "..."
+	}
+	if end < len(line) {
+		suffix = "..."
+	}
+	s := string(line[start:end])
+	if h.l.Interactive() && env.Get("SYNTAX_HL") == "true" {
+		b := new(bytes.Buffer)
+		if h.Highlight(b, s) == nil {
+			s = strings.TrimSuffix(b.String(), "\n")
+		}
+	}
+	fmt.Fprintln(w, prefix+s+suffix)
+	fmt.Fprintln(w, strings.Repeat(" ", runewidth.StringWidth(prefix+string(line[start:pos])))+"^")
+}
+
+// errorLineWidth is the maximum number of characters of a query line displayed
+// by printErrorPosition.
+const errorLineWidth = 60
+
+// LastError returns the last error returned when executing a statement.
+func (h *Handler) LastError() error {
+	return h.lastError
+}
+
 // Reset resets the handler's query statement buffer.
 func (h *Handler) Reset(r []rune) {
 	h.buf.Reset(r)
@@ -488,6 +638,138 @@ func (h *Handler) unbind() []interface{} {
 	return v
 }
 
+// prepared is a named prepared statement.
+type prepared struct {
+	stmt   *sql.Stmt
+	prefix string
+	sqlstr string
+	qtyp   bool
+}
+
+// Parse prepares the query buffer (or the last executed query, when the query
+// buffer is empty) as the named prepared statement.
+func (h *Handler) Parse(ctx context.Context, name string) error {
+	if h.db == nil {
+		return text.ErrNotConnected
+	}
+	if _, ok := h.prepared[name]; ok {
+		return fmt.Errorf(text.PreparedStatementExists, name)
+	}
+	prefix, sqlstr := h.lastExecPrefix, h.lastExec
+	if h.buf.Len != 0 {
+		prefix, sqlstr = h.buf.Prefix, h.buf.String()
+	}
+	if sqlstr == "" {
+		return text.ErrQueryBufferEmpty
+	}
+	prefix, sqlstr, qtyp, err := drivers.Process(h.u, prefix, strings.TrimSpace(sqlstr))
+	if err != nil {
+		return drivers.WrapErr(h.u.Driver, err)
+	}
+	s, err := h.db.PrepareContext(ctx, sqlstr)
+	if err != nil {
+		return drivers.WrapErr(h.u.Driver, err)
+	}
+	if h.prepared == nil {
+		h.prepared = make(map[string]*prepared)
+	}
+	h.prepared[name] = &prepared{
+		stmt:   s,
+		prefix: prefix,
+		sqlstr: sqlstr,
+		qtyp:   qtyp,
+	}
+	// the query buffer has been sent
+	if h.buf.Len != 0 {
+		h.lastExec, h.lastExecPrefix, h.lastPrint, h.lastRaw = h.buf.String(), h.buf.Prefix, h.buf.PrintString(), h.buf.RawString()
+		h.buf.Reset(nil)
+	}
+	return nil
+}
+
+// BindNamed binds query parameters for the named prepared statement, which will
+// be executed in place of the query buffer.
+func (h *Handler) BindNamed(name string, bind []interface{}) error {
+	if _, ok := h.prepared[name]; !ok {
+		return fmt.Errorf(text.PreparedStatementNotFound, name)
+	}
+	h.named, h.bind = name, bind
+	return nil
+}
+
+// unbindNamed returns the name of the prepared statement to execute.
+func (h *Handler) unbindNamed() string {
+	name := h.named
+	h.named = ""
+	return name
+}
+
+// Deallocate closes the named prepared statement.
+func (h *Handler) Deallocate(name string) error {
+	p, ok := h.prepared[name]
+	if !ok {
+		return fmt.Errorf(text.PreparedStatementNotFound, name)
+	}
+	delete(h.prepared, name)
+	if h.named == name {
+		h.named, h.bind = "", nil
+	}
+	return drivers.WrapErr(h.u.Driver, p.stmt.Close())
+}
+
+// Prepared returns the queries of the named prepared statements.
+func (h *Handler) Prepared() map[string]string {
+	m := make(map[string]string, len(h.prepared))
+	for name, p := range h.prepared {
+		m[name] = p.sqlstr
+	}
+	return m
+}
+
+// closePrepared closes all named prepared statements.
+func (h *Handler) closePrepared() {
+	for _, p := range h.prepared {
+		_ = p.stmt.Close()
+	}
+	h.prepared, h.named = nil, ""
+}
+
+// ExecuteNamed executes the named prepared statement.
+func (h *Handler) ExecuteNamed(ctx context.Context, w io.Writer, opt metacmd.Option, name string, bind ...interface{}) error {
+	if h.db == nil {
+		return text.ErrNotConnected
+	}
+	p, ok := h.prepared[name]
+	if !ok {
+		return fmt.Errorf(text.PreparedStatementNotFound, name)
+	}
+	s := p.stmt
+	if h.tx != nil {
+		s = h.tx.StmtContext(ctx, s)
+	}
+	start := time.Now()
+	if p.qtyp {
+		rows, err := s.QueryContext(ctx, bind...)
+		if err != nil {
+			return drivers.WrapErr(h.u.Driver, err)
+		}
+		if err := h.doRows(w, opt, p.prefix, rows, start); err != nil {
+			return drivers.WrapErr(h.u.Driver, err)
+		}
+	} else {
+		res, err := s.ExecContext(ctx, bind...)
+		if err != nil {
+			_ = env.Vars().Set("ROW_COUNT", "0")
+			return drivers.WrapErr(h.u.Driver, err)
+		}
+		if err := h.doResult(w, p.prefix, res); err != nil {
+			return drivers.WrapErr(h.u.Driver, err)
+		}
+	}
+	h.printTiming(start)
+	return nil
+}
+
 // Prompt parses a prompt.
 //
 // NOTE: the documentation below is INCORRECT, as it is just copied from
@@ -679,7 +961,11 @@ func (h *Handler) Prompt(prompt string) string {
 			}
 		// case 'p': // the process id of the connected backend -- never going to be supported
 		case 'R': // statement state
-			buf = append(buf, h.buf.State()...)
+			if h.condActive() {
+				buf = append(buf, h.buf.State()...)
+			} else {
+				buf = append(buf, '@')
+			}
 		case 'x': // empty when not in a transaction block, * in transaction block, ! in failed transaction block, or ? when indeterminate
 		case 'l': // line number
 		case ':': // variable value
@@ -720,6 +1006,43 @@ func (h *Handler) LastExec() string {
 	return h.lastExec
 }
 
+// History returns the statement history. When HISTORY_PER_CONNECTION is
+// enabled and there is an open database connection, returns the entries from
+// the connection's history file, otherwise returns the lines of the history
+// file.
+func (h *Handler) History() ([]history.Entry, error) {
+	if path := h.historyFile(); path != "" {
+		return history.Load(path)
+	}
+	return history.LoadLines(env.HistoryFile(h.user))
+}
+
+// historyFile returns the path to the connection's history file, or empty when
+// HISTORY_PER_CONNECTION is not enabled or there is no open database
+// connection.
+func (h *Handler) historyFile() string {
+	if h.u == nil || env.Get("HISTORY_PER_CONNECTION") != "on" {
+		return ""
+	}
+	return env.ConnHistoryFile(h.user, history.Key(h.u))
+}
+
+// saveHistory saves the statement to the connection's history file.
+func (h *Handler) saveHistory(s string) {
+	path := h.historyFile()
+	if path == "" || strings.TrimSpace(s) == "" {
+		return
+	}
+	err := history.Append(path, history.Entry{
+		Time: time.Now(),
+		Conn: history.Conn(h.u),
+		Stmt: s,
+	})
+	if err != nil {
+		fmt.Fprintln(h.l.Stderr(), "error:", err)
+	}
+}
+
 // LastPrint returns the last printable statement.
 func (h *Handler) LastPrint() string {
 	return h.lastPrint
@@ -770,29 +1093,17 @@ func (h *Handler) Open(ctx context.Context, params ...string) error {
 	if h.tx != nil {
 		return text.ErrPreviousTransactionExists
 	}
+	h.closePrepared()
 	if len(params) == 1 {
 		if v, ok := env.Vars().GetConn(params[0]); ok {
 			params = v
 		}
 	}
-	if len(params) < 2 {
-		dsn := params[0]
-		// parse dsn
-		u, err := dburl.Parse(dsn)
-		if err != nil {
-			return err
-		}
-		h.u = u
-		// force parameters
-		h.forceParams(h.u)
-	} else {
-		h.u = &dburl.URL{
-			Driver: params[0],
-			DSN:    strings.Join(params[1:], " "),
-		}
+	var err error
+	if h.u, err = h.ResolveURL(params...); err != nil {
+		return err
 	}
 	// open connection
-	var err error
 	h.db, err = drivers.Open(ctx, h.u, h.GetOutput, h.l.Stderr)
 	if err != nil && !drivers.IsPasswordErr(h.u, err) {
 		defer h.Close()
@@ -827,6 +1138,31 @@ func (h *Handler) Open(ctx context.Context, params ...string) error {
 	return h.Open(ctx, dsn)
 }
 
+// ResolveURL resolves the URL for the named connection (see \cset), URL, or
+// driver and DSN, forcing the driver's parameters and using the passfile as
+// when opening a connection.
+func (h *Handler) ResolveURL(params ...string) (*dburl.URL, error) {
+	if len(params) == 1 {
+		if v, ok := env.Vars().GetConn(params[0]); ok {
+			params = v
+		}
+	}
+	if len(params) > 1 {
+		return &dburl.URL{
+			Driver: params[0],
+			DSN:    strings.Join(params[1:], " "),
+		}, nil
+	}
+	// parse dsn
+	u, err := dburl.Parse(params[0])
+	if err != nil {
+		return nil, err
+	}
+	// force parameters
+	h.forceParams(u)
+	return u, nil
+}
+
 func (h *Handler) connStrings() []string {
 	entries, err := passfile.Entries(h.user.HomeDir, text.PassfileName)
 	if err != nil {
@@ -916,6 +1252,7 @@ func (h *Handler) Close() error {
 		return text.ErrPreviousTransactionExists
 	}
 	if h.db != nil {
+		h.closePrepared()
 		err := h.db.Close()
 		drv := h.u.Driver
 		h.db, h.u = nil, nil
@@ -1041,14 +1378,66 @@ func (h *Handler) Print(s string, v ...interface{}) {
 
 // doExecWatch repeatedly executes a query against the database.
 func (h *Handler) doExecWatch(ctx context.Context, w io.Writer, opt metacmd.Option, prefix, sqlstr string, qtyp bool, bind []interface{}) error {
-	for {
+	// append results to a file or chart
+	var series *watchSeries
+	if opt.WatchFile != "" || opt.WatchChart != "" {
+		switch {
+		case !qtyp:
+			return text.ErrWatchSeriesNotQuery
+		case opt.WatchChart != "" && !env.TermGraphics().Available():
+			return text.ErrGraphicsNotSupported
+		}
+		var err error
+		file := opt.WatchFile
+		if file != "" {
+			file = env.Expand(h.user, file)
+		}
+		if series, err = newWatchSeries(file, opt.WatchFormat, opt.WatchChart != ""); err != nil {
+			return err
+		}
+		defer series.Close()
+	}
+	var prev []byte
+	for i := 1; ; i++ {
 		// the actual output that psql has: "Mon Jan 2006 3:04:05 PM MST" -- which is _slightly_ different than RFC1123
 		// fmt.Fprintf(w, "%s (every %fs)\n\n", time.Now().Format("Mon Jan 2006 3:04:05 PM MST"), float64(opt.Watch)/float64(time.Second))
-		fmt.Fprintf(w, "%s (every %v)\n", time.Now().Format(time.RFC1123), opt.Watch)
-		fmt.Fprintln(w)
-		if err := h.doExecSingle(ctx, w, opt, prefix, sqlstr, qtyp, bind); err != nil {
+		if series == nil || opt.WatchChart != "" {
+			fmt.Fprintf(w, "%s (every %v)\n", time.Now().Format(time.RFC1123), opt.Watch)
+			fmt.Fprintln(w)
+		}
+		// buffer output to highlight changes
+		out, buf := w, new(bytes.Buffer)
+		if opt.WatchDiff {
+			out = buf
+		}
+		var err error
+		if series != nil {
+			err = h.doWatchSeries(ctx, w, opt, series, sqlstr, bind)
+		} else {
+			err = h.doExecSingle(ctx, out, opt, prefix, sqlstr, qtyp, bind)
+		}
+		if opt.WatchDiff {
+			_, _ = w.Write(highlightChanges(prev, buf.Bytes()))
+			prev = buf.Bytes()
+		}
+		if err != nil {
 			return err
 		}
+		// check stop conditions
+		if opt.WatchCount != 0 && i >= opt.WatchCount {
+			return nil
+		}
+		if n, err := strconv.Atoi(env.Get("ROW_COUNT")); opt.WatchMinRows != 0 && err == nil && n < opt.WatchMinRows {
+			return nil
+		}
+		if opt.WatchWhile != "" {
+			switch v, err := env.ParseBool(env.Get(opt.WatchWhile), opt.WatchWhile); {
+			case env.Get(opt.WatchWhile) == "", v == "off":
+				return nil
+			case err != nil:
+				return err
+			}
+		}
 		select {
 		case <-ctx.Done():
 			if err := ctx.Err(); err != nil && !errors.Is(err, context.Canceled) {
@@ -1060,6 +1449,216 @@ func (h *Handler) doExecWatch(ctx context.Context, w io.Writer, opt metacmd.Opti
 	}
 }
 
+// doWatchSeries executes a watched query, appending its results to the series,
+// and displaying the series as a chart when a chart type was specified. Only
+// queries are supported, and the diff option can not be used with a series
+// (see [metacmd.Watch]).
+func (h *Handler) doWatchSeries(ctx context.Context, w io.Writer, opt metacmd.Option, series *watchSeries, sqlstr string, bind []interface{}) error {
+	t := time.Now()
+	rows, err := h.DB().QueryContext(ctx, sqlstr, bind...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	cols, err := drivers.Columns(h.u, rows)
+	if err != nil {
+		return err
+	}
+	watchWhile := -1
+	if opt.WatchWhile != "" {
+		if watchWhile, err = watchWhileIndex(cols, opt.WatchWhile); err != nil {
+			return err
+		}
+	}
+	var res [][]sql.NullString
+	clen, tfmt := len(cols), env.Vars().PrintTimeFormat()
+	for rows.Next() {
+		row, err := h.scanNull(rows, clen, tfmt)
+		if err != nil {
+			return err
+		}
+		res = append(res, row)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_ = env.Vars().Set("ROW_COUNT", strconv.Itoa(len(res)))
+	if watchWhile != -1 {
+		var value string
+		if len(res) != 0 {
+			value = res[0][watchWhile].String
+		}
+		if err := env.Vars().Set(opt.WatchWhile, value); err != nil {
+			return err
+		}
+	}
+	if err := series.append(t, cols, res); err != nil {
+		return err
+	}
+	if opt.WatchChart != "" {
+		cfg, err := charts.ParseArgs(map[string]string{"type": opt.WatchChart})
+		if err != nil {
+			return err
+		}
+		if err := h.drawChart(ctx, w, cfg, series.cols, series.transposed); err != nil {
+			return err
+		}
+	}
+	h.printTiming(t)
+	return nil
+}
+
+// watchChartPoints is the number of the most recent points retained for
+// display as a watch chart.
+const watchChartPoints = 1000
+
+// watchSeries is the time series of the results of each execution of a
+// watched query, appended to a file, and retained for display as a chart.
+type watchSeries struct {
+	w      io.WriteCloser
+	file   string
+	format string
+	header []string
+	// chart indicates the most recent points are retained in transposed for
+	// display as a chart.
+	chart      bool
+	cols       []string
+	transposed [][]string
+}
+
+// newWatchSeries creates a watch series, opening file for appending when not
+// empty. The header of an existing CSV file is read, so that only results
+// with the same columns are appended.
+func newWatchSeries(file, format string, chart bool) (*watchSeries, error) {
+	series := &watchSeries{file: file, format: format, chart: chart}
+	if file == "" {
+		return series, nil
+	}
+	if format != "jsonl" {
+		var err error
+		if series.header, err = readCSVHeader(file); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	series.w = f
+	return series, nil
+}
+
+// readCSVHeader reads the header of an existing CSV file, returning nil when
+// the file does not exist or is empty.
+func readCSVHeader(file string) ([]string, error) {
+	f, err := os.Open(file)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	defer f.Close()
+	header, err := csv.NewReader(f).Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	return header, err
+}
+
+// Close closes the series' file.
+func (series *watchSeries) Close() error {
+	if series.w != nil {
+		return series.w.Close()
+	}
+	return nil
+}
+
+// append appends the rows, returned at time t, to the series, writing them to
+// the series' file, and retaining the most recent points for the chart.
+func (series *watchSeries) append(t time.Time, cols []string, rows [][]sql.NullString) error {
+	cols = append([]string{"time"}, cols...)
+	if series.chart {
+		// reset chart data when the columns change
+		if !slices.Equal(cols, series.cols) {
+			series.cols, series.transposed = cols, make([][]string, len(cols))
+		}
+		for _, row := range rows {
+			series.transposed[0] = append(series.transposed[0], t.Format(time.TimeOnly))
+			for i, v := range row {
+				series.transposed[i+1] = append(series.transposed[i+1], v.String)
+			}
+		}
+		if n := len(series.transposed[0]) - watchChartPoints; n > 0 {
+			for i := range series.transposed {
+				series.transposed[i] = series.transposed[i][n:]
+			}
+		}
+	}
+	if series.w == nil {
+		return nil
+	}
+	ts := t.Format(time.RFC3339Nano)
+	if series.format == "jsonl" {
+		// encode each row as an object, retaining the column order
+		for _, row := range rows {
+			buf := new(bytes.Buffer)
+			for i, col := range cols {
+				var v interface{}
+				switch {
+				case i == 0:
+					v = ts
+				case row[i-1].Valid:
+					v = row[i-1].String
+				}
+				key, err := json.Marshal(col)
+				if err != nil {
+					return err
+				}
+				value, err := json.Marshal(v)
+				if err != nil {
+					return err
+				}
+				if i == 0 {
+					buf.WriteByte('{')
+				} else {
+					buf.WriteByte(',')
+				}
+				buf.Write(key)
+				buf.WriteByte(':')
+				buf.Write(value)
+			}
+			buf.WriteString("}\n")
+			if _, err := series.w.Write(buf.Bytes()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// the columns must not change, as a CSV file has a single header
+	switch {
+	case series.header == nil:
+		if err := csv.NewWriter(series.w).WriteAll([][]string{cols}); err != nil {
+			return err
+		}
+		series.header = cols
+	case !slices.Equal(cols, series.header):
+		return fmt.Errorf(text.WatchColumnsMismatch, strings.Join(cols, ", "), strings.Join(series.header, ", "), series.file)
+	}
+	cw := csv.NewWriter(series.w)
+	for _, row := range rows {
+		record := []string{ts}
+		for _, v := range row {
+			record = append(record, v.String)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
 // doExecChart executes a single query against the database, displaying its output as a chart.
 func (h *Handler) doExecChart(ctx context.Context, w io.Writer, opt metacmd.Option, prefix, sqlstr string, qtyp bool, bind []interface{}) error {
 	stdout, _, _ := h.l.Stdout(), h.l.Stderr(), h.l.Interactive()
@@ -1099,6 +1698,16 @@ func (h *Handler) doExecChart(ctx context.Context, w io.Writer, opt metacmd.Opti
 		}
 	}
 	// display
+	if err := h.drawChart(ctx, stdout, cfg, cols, transposed); err != nil {
+		return err
+	}
+	h.printTiming(start)
+	return nil
+}
+
+// drawChart draws a chart of the (transposed) columns to w, or writes it to
+// the chart's file.
+func (h *Handler) drawChart(ctx context.Context, w io.Writer, cfg charts.ChartConfig, cols []string, transposed [][]string) error {
 	c, err := charts.MakeChart(cfg, cols, transposed)
 	if err != nil {
 		return err
@@ -1120,20 +1729,7 @@ func (h *Handler) doExecChart(ctx context.Context, w io.Writer, opt metacmd.Opti
 	if err != nil {
 		return err
 	}
-	if err := typ.Encode(stdout, img); err != nil {
-		return err
-	}
-	if h.timing {
-		d := time.Since(start)
-		s := text.TimingDesc
-		v := []interface{}{float64(d.Microseconds()) / 1000}
-		if d > 1*time.Second {
-			s += " (%v)"
-			v = append(v, d.Round(1*time.Millisecond))
-		}
-		fmt.Fprintln(h.l.Stdout(), fmt.Sprintf(s, v...))
-	}
-	return nil
+	return env.TermGraphics().Encode(w, img)
 }
 
 // doExecSingle executes a single query against the database based on its query type.
@@ -1148,19 +1744,53 @@ func (h *Handler) doExecSingle(ctx context.Context, w io.Writer, opt metacmd.Opt
 	if err := f(ctx, w, opt, prefix, sqlstr, bind); err != nil {
 		return err
 	}
-	if h.timing {
-		d := time.Since(start)
-		s := text.TimingDesc
-		v := []interface{}{float64(d.Microseconds()) / 1000}
-		if d > 1*time.Second {
-			s += " (%v)"
-			v = append(v, d.Round(1*time.Millisecond))
-		}
-		fmt.Fprintln(h.l.Stdout(), fmt.Sprintf(s, v...))
-	}
+	h.printTiming(start)
 	return nil
 }
 
+// printTiming writes the time elapsed since start to the output, when timing
+// is enabled.
+func (h *Handler) printTiming(start time.Time) {
+	d := time.Since(start)
+	stats := h.stats
+	if stats == nil {
+		stats = new(queryStats)
+	}
+	h.stats = nil
+	// set LAST_QUERY_* variables
+	vars := env.Vars()
+	_ = vars.Set("LAST_QUERY_TIME", formatMillis(d))
+	_ = vars.Set("LAST_QUERY_FIRST_ROW_TIME", formatMillis(stats.first))
+	_ = vars.Set("LAST_QUERY_FETCH_TIME", formatMillis(stats.fetch))
+	_ = vars.Set("LAST_QUERY_FORMAT_TIME", formatMillis(stats.format))
+	_ = vars.Set("LAST_QUERY_ROWS", strconv.FormatInt(stats.rows, 10))
+	_ = vars.Set("LAST_QUERY_BYTES", strconv.FormatInt(stats.bytes, 10))
+	if !h.timing {
+		return
+	}
+	s := text.TimingDesc
+	v := []interface{}{float64(d.Microseconds()) / 1000}
+	if d > 1*time.Second {
+		s += " (%v)"
+		v = append(v, d.Round(1*time.Millisecond))
+	}
+	stdout := h.l.Stdout()
+	fmt.Fprintln(stdout, fmt.Sprintf(s, v...))
+	if h.timingVerbose {
+		fmt.Fprintf(
+			stdout, text.TimingVerboseDesc,
+			formatMillis(stats.first), formatMillis(stats.fetch), formatMillis(stats.format),
+			stats.rows, stats.bytes,
+		)
+		fmt.Fprintln(stdout)
+	}
+}
+
+// formatMillis formats a duration as milliseconds.
+func formatMillis(d time.Duration) string {
+	return strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', 3, 64)
+}
+
 // doExecSet executes a SQL query, setting all returned columns as variables.
 func (h *Handler) doExecSet(ctx context.Context, w io.Writer, opt metacmd.Option, prefix, sqlstr string, _ bool, bind []interface{}) error {
 	// query
@@ -1224,11 +1854,19 @@ func (h *Handler) doExecExec(ctx context.Context, w io.Writer, _ metacmd.Option,
 // doQuery executes a doQuery against the database.
 func (h *Handler) doQuery(ctx context.Context, w io.Writer, opt metacmd.Option, typ, sqlstr string, bind []interface{}) error {
 	// run query
+	start := time.Now()
 	rows, err := h.DB().QueryContext(ctx, sqlstr, bind...)
 	if err != nil {
 		return err
 	}
+	return h.doRows(w, opt, typ, rows, start)
+}
+
+// doRows displays the query's result rows, for the query started at start.
+func (h *Handler) doRows(w io.Writer, opt metacmd.Option, typ string, rows *sql.Rows, start time.Time) error {
 	defer rows.Close()
+	began := time.Now()
+	var err error
 	params := env.Vars().Print()
 	params["time"] = env.Vars().PrintTimeFormat()
 	for k, v := range opt.Params {
@@ -1263,11 +1901,12 @@ func (h *Handler) doQuery(ctx context.Context, w io.Writer, opt metacmd.Option,
 	case drivers.UseColumnTypes(h.u):
 		extra = append(extra, tblfmt.WithUseColumnTypes(true))
 	}
-	resultSet := tblfmt.ResultSet(rows)
+	counter := &rowCounter{Rows: rows, start: start}
+	resultSet := tblfmt.ResultSet(counter)
 	// wrap query with crosstab
 	if opt.Exec == metacmd.ExecCrosstab {
 		var err error
-		if resultSet, err = tblfmt.NewCrosstabView(rows, append(extra, tblfmt.WithParams(opt.Crosstab...))...); err != nil {
+		if resultSet, err = tblfmt.NewCrosstabView(counter, append(extra, tblfmt.WithParams(opt.Crosstab...))...); err != nil {
 			return err
 		}
 		extra = nil
@@ -1275,8 +1914,21 @@ func (h *Handler) doQuery(ctx context.Context, w io.Writer, opt metacmd.Option,
 	if drivers.LowerColumnNames(h.u) {
 		params["lower_column_names"] = "true"
 	}
+	// capture the watch condition column
+	watchWhile := -1
+	if opt.Exec == metacmd.ExecWatch && opt.WatchWhile != "" {
+		cols, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		if watchWhile, err = watchWhileIndex(cols, opt.WatchWhile); err != nil {
+			return err
+		}
+		counter.capture = true
+	}
 	// encode and handle error conditions
-	switch err := tblfmt.EncodeAll(w, resultSet, params, extra...); {
+	out := &byteCounter{w: w}
+	switch err := tblfmt.EncodeAll(out, resultSet, params, extra...); {
 	case err != nil && cmd != nil && errors.Is(err, syscall.EPIPE):
 		// broken pipe means pager quit before consuming all data, which might be expected
 		return nil
@@ -1295,9 +1947,226 @@ func (h *Handler) doQuery(ctx context.Context, w io.Writer, opt metacmd.Option,
 			cmd.Wait()
 		}
 	}
+	_ = env.Vars().Set("ROW_COUNT", strconv.FormatInt(counter.n, 10))
+	h.stats = &queryStats{
+		first:  counter.first,
+		fetch:  counter.fetch,
+		format: time.Since(began) - counter.fetch,
+		rows:   counter.n,
+		bytes:  out.n,
+	}
+	// store the watch condition
+	if watchWhile != -1 {
+		return h.setWatchWhile(counter, opt.WatchWhile, watchWhile)
+	}
 	return err
 }
 
+// watchWhileIndex returns the index of the watch while column name in cols.
+func watchWhileIndex(cols []string, name string) (int, error) {
+	i := slices.IndexFunc(cols, func(col string) bool {
+		return strings.EqualFold(col, name)
+	})
+	if i == -1 {
+		return -1, fmt.Errorf(text.ColumnNotFound, name)
+	}
+	return i, nil
+}
+
+// setWatchWhile sets the variable name to the value of column i in the first
+// row captured by the counter, or to empty when there were no rows.
+func (h *Handler) setWatchWhile(counter *rowCounter, name string, i int) error {
+	var value string
+	if counter.values != nil {
+		row, err := h.convertNull(counter.values, env.Vars().PrintTimeFormat())
+		if err != nil {
+			return err
+		}
+		value = row[i].String
+	}
+	return env.Vars().Set(name, value)
+}
+
+// rowCounter wraps rows, counting the number of rows read and the time spent
+// fetching them, and optionally capturing the values of the first row.
+type rowCounter struct {
+	*sql.Rows
+	n       int64
+	capture bool
+	values  []interface{}
+	// start is when the query was started.
+	start time.Time
+	// first is the time from the start of the query until the first row was
+	// returned.
+	first time.Duration
+	// fetch is the total time spent fetching rows.
+	fetch time.Duration
+}
+
+// Scan satisfies the [tblfmt.ResultSet] interface.
+func (r *rowCounter) Scan(dest ...interface{}) error {
+	if err := r.Rows.Scan(dest...); err != nil || !r.capture || r.n != 1 {
+		return err
+	}
+	r.values = make([]interface{}, len(dest))
+	for i, d := range dest {
+		v := new(interface{})
+		switch x := d.(type) {
+		case *interface{}:
+			*v = *x
+		case *sql.RawBytes:
+			*v = bytes.Clone(*x)
+		default:
+			// drivers using column types scan to the column's scan type,
+			// ie *sql.NullString
+			*v = reflect.ValueOf(d).Elem().Interface()
+			if valuer, ok := (*v).(driver.Valuer); ok {
+				var err error
+				if *v, err = valuer.Value(); err != nil {
+					return err
+				}
+			}
+		}
+		r.values[i] = v
+	}
+	return nil
+}
+
+// Next satisfies the [tblfmt.ResultSet] interface.
+func (r *rowCounter) Next() bool {
+	now := time.Now()
+	next := r.Rows.Next()
+	end := time.Now()
+	r.fetch += end.Sub(now)
+	if r.n == 0 && r.first == 0 {
+		r.first = end.Sub(r.start)
+	}
+	if next {
+		r.n++
+	}
+	return next
+}
+
+// byteCounter wraps a writer, counting the number of bytes written.
+type byteCounter struct {
+	w io.Writer
+	n int64
+}
+
+// Write satisfies the [io.Writer] interface.
+func (w *byteCounter) Write(buf []byte) (int, error) {
+	n, err := w.w.Write(buf)
+	w.n += int64(n)
+	return n, err
+}
+
+// queryStats are the per-phase timing and size statistics of a query's
+// results.
+type queryStats struct {
+	first, fetch, format time.Duration
+	rows, bytes          int64
+}
+
+// doExecDesc describes the result columns of a query, without executing it
+// (\gdesc). As database/sql can not retrieve the result columns of a prepared
+// statement without executing it, the query is executed in a form that
+// returns no rows (see [drivers.DescribeQuery]), in order to retrieve the
+// result's column types. Statements without side effects (ie, SHOW) are
+// executed as-is.
+func (h *Handler) doExecDesc(ctx context.Context, w io.Writer, opt metacmd.Option, prefix, sqlstr string, qtyp bool, bind []interface{}) error {
+	if !qtyp {
+		fmt.Fprintln(w, text.QueryHasNoColumns)
+		return nil
+	}
+	q, err := drivers.DescribeQuery(h.u, prefix, sqlstr)
+	if err != nil {
+		return err
+	}
+	rows, err := h.DB().QueryContext(ctx, q, bind...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	cols, err := rows.ColumnTypes()
+	switch {
+	case err != nil:
+		return err
+	case len(cols) == 0:
+		fmt.Fprintln(w, text.QueryHasNoColumns)
+		return nil
+	}
+	params := env.Vars().Print()
+	for k, v := range opt.Params {
+		params[k] = v
+	}
+	if err := tblfmt.EncodeAll(w, &columnDescs{cols: cols}, params); err != nil {
+		return err
+	}
+	if params["format"] == "aligned" {
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// doExecExplain displays the query plan of a query as a tree (\explain),
+// highlighting the most expensive nodes when writing to a terminal.
+func (h *Handler) doExecExplain(ctx context.Context, w io.Writer, opt metacmd.Option, _, sqlstr string, _ bool, bind []interface{}) error {
+	start := time.Now()
+	plan, err := drivers.Explain(ctx, h.u, h.DB(), sqlstr, opt.ExplainAnalyze, bind...)
+	if err != nil {
+		return err
+	}
+	if err := explain.Render(w, plan, h.l.Interactive() && h.out == nil); err != nil {
+		return err
+	}
+	h.printTiming(start)
+	return nil
+}
+
+// columnDescs is a result set of the names and database types of a query's
+// result columns.
+type columnDescs struct {
+	cols []*sql.ColumnType
+	i    int
+}
+
+// Next satisfies the [tblfmt.ResultSet] interface.
+func (r *columnDescs) Next() bool {
+	r.i++
+	return r.i <= len(r.cols)
+}
+
+// Scan satisfies the [tblfmt.ResultSet] interface.
+func (r *columnDescs) Scan(dest ...interface{}) error {
+	if len(dest) != 2 {
+		return text.ErrWrongNumberOfArguments
+	}
+	col := r.cols[r.i-1]
+	*dest[0].(*interface{}) = col.Name()
+	*dest[1].(*interface{}) = col.DatabaseTypeName()
+	return nil
+}
+
+// Columns satisfies the [tblfmt.ResultSet] interface.
+func (r *columnDescs) Columns() ([]string, error) {
+	return []string{"Column", "Type"}, nil
+}
+
+// Close satisfies the [tblfmt.ResultSet] interface.
+func (r *columnDescs) Close() error {
+	return nil
+}
+
+// Err satisfies the [tblfmt.ResultSet] interface.
+func (r *columnDescs) Err() error {
+	return nil
+}
+
+// NextResultSet satisfies the [tblfmt.ResultSet] interface.
+func (r *columnDescs) NextResultSet() bool {
+	return false
+}
+
 // doExecRows executes all the columns in the row.
 func (h *Handler) doExecRows(ctx context.Context, w io.Writer, rows *sql.Rows) error {
 	// get columns
@@ -1329,6 +2198,19 @@ func (h *Handler) doExecRows(ctx context.Context, w io.Writer, rows *sql.Rows) e
 
 // scan scans a row.
 func (h *Handler) scan(rows *sql.Rows, clen int, tfmt string) ([]string, error) {
+	r, err := h.scanNull(rows, clen, tfmt)
+	if err != nil {
+		return nil, err
+	}
+	row := make([]string, clen)
+	for i, v := range r {
+		row[i] = v.String
+	}
+	return row, nil
+}
+
+// scanNull scans a row, retaining NULL values.
+func (h *Handler) scanNull(rows *sql.Rows, clen int, tfmt string) ([]sql.NullString, error) {
 	// scan to []interface{}
 	r := make([]interface{}, clen)
 	for i := range r {
@@ -1337,49 +2219,42 @@ func (h *Handler) scan(rows *sql.Rows, clen int, tfmt string) ([]string, error)
 	if err := rows.Scan(r...); err != nil {
 		return nil, err
 	}
-	// get conversion funcs
-	cb, cm, cs, cd := drivers.ConvertBytes(h.u), drivers.ConvertMap(h.u), drivers.ConvertSlice(h.u), drivers.ConvertDefault(h.u)
-	row := make([]string, clen)
-	for n, z := range r {
-		j := z.(*interface{})
-		switch x := (*j).(type) {
-		case []byte:
-			if x != nil {
-				var err error
-				if row[n], err = cb(x, tfmt); err != nil {
-					return nil, err
-				}
-			}
-		case string:
-			row[n] = x
-		case time.Time:
-			row[n] = x.Format(tfmt)
-		case fmt.Stringer:
-			row[n] = x.String()
-		case map[string]interface{}:
-			if x != nil {
-				var err error
-				if row[n], err = cm(x); err != nil {
-					return nil, err
-				}
-			}
-		case []interface{}:
-			if x != nil {
-				var err error
-				if row[n], err = cs(x); err != nil {
-					return nil, err
-				}
-			}
-		default:
-			if x != nil {
-				var err error
-				if row[n], err = cd(x); err != nil {
-					return nil, err
-				}
-			}
-		}
+	return h.convertNull(r, tfmt)
+}
+
+// convertNull converts scanned values (pointers to interface{}) to strings,
+// retaining NULL values.
+func (h *Handler) convertNull(r []interface{}, tfmt string) ([]sql.NullString, error) {
+	return drivers.ConvertNull(h.u, r, tfmt)
+}
+
+// doCopyIn copies the inline data block following a COPY ... FROM STDIN
+// statement into the table on the current database connection. The data
+// block is always fully consumed, even when an error is encountered.
+func (h *Handler) doCopyIn(ctx context.Context, w io.Writer, sqlstr string, data io.Reader) error {
+	defer func() {
+		_, _ = io.Copy(io.Discard, data)
+	}()
+	if h.l.Interactive() {
+		fmt.Fprintln(h.l.Stdout(), text.EnterCopyData)
+		h.l.Prompt(text.CopyDataPrompt)
 	}
-	return row, nil
+	m := copyFromStdinRE.FindStringSubmatch(sqlstr)
+	opts, err := metacmd.ParseCopyOptions(m[2])
+	if err != nil {
+		return err
+	}
+	table := copyTableRE.ReplaceAllString(strings.TrimSpace(m[1]), "(")
+	n, err := h.CopyFrom(ctx, data, table, opts)
+	if err != nil {
+		_ = env.Vars().Set("ROW_COUNT", "0")
+		return err
+	}
+	_ = env.Vars().Set("ROW_COUNT", strconv.FormatInt(n, 10))
+	if env.Get("QUIET") == "off" {
+		fmt.Fprintln(w, "COPY", n)
+	}
+	return nil
 }
 
 // doExec does a database exec.
@@ -1389,6 +2264,11 @@ func (h *Handler) doExec(ctx context.Context, w io.Writer, _ metacmd.Option, typ
 		_ = env.Vars().Set("ROW_COUNT", "0")
 		return err
 	}
+	return h.doResult(w, typ, res)
+}
+
+// doResult displays the statement's result.
+func (h *Handler) doResult(w io.Writer, typ string, res sql.Result) error {
 	// get affected
 	count, err := drivers.RowsAffected(h.u, res)
 	if err != nil {
@@ -1460,26 +2340,142 @@ func (h *Handler) Rollback() error {
 	return nil
 }
 
-// If starts an if block.
-func (h *Handler) If(ok bool) error {
+// CopyFrom copies the copy data read from r into the table on the current
+// database connection.
+func (h *Handler) CopyFrom(ctx context.Context, r io.Reader, table string, opts drivers.CopyOptions) (int64, error) {
+	if h.db == nil {
+		return 0, text.ErrNotConnected
+	}
+	rows, closeRows, err := drivers.NewCopyRows(ctx, r, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer closeRows()
+	return drivers.CopyDB(ctx, h.u, h.DB(), rows, table)
+}
+
+// CopyTo executes the query on the current database connection, writing the
+// results to w as copy data.
+func (h *Handler) CopyTo(ctx context.Context, w io.Writer, query string, opts drivers.CopyOptions) (int64, error) {
+	if h.db == nil {
+		return 0, text.ErrNotConnected
+	}
+	rows, err := h.DB().QueryContext(ctx, query)
+	if err != nil {
+		return 0, drivers.WrapErr(h.u.Driver, err)
+	}
+	defer rows.Close()
+	cols, err := drivers.Columns(h.u, rows)
+	if err != nil {
+		return 0, err
+	}
+	cw := drivers.NewCopyWriter(w, opts)
+	if opts.Header {
+		if err := cw.WriteHeader(cols); err != nil {
+			return 0, err
+		}
+	}
+	var n int64
+	clen, tfmt := len(cols), env.Vars().PrintTimeFormat()
+	for rows.Next() {
+		row, err := h.scanNull(rows, clen, tfmt)
+		if err != nil {
+			return n, err
+		}
+		if err := cw.Write(row); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, drivers.WrapErr(h.u.Driver, err)
+	}
+	return n, cw.Flush()
+}
+
+// If starts a conditional block, entering it when the block is reached from
+// an active branch and ok returns true. ok is not evaluated when the block
+// is nested within an inactive branch.
+func (h *Handler) If(ok func() (bool, error)) error {
+	if !h.condActive() {
+		h.conds = append(h.conds, cond{state: condIgnored, n: h.buf.Len})
+		return nil
+	}
+	h.conds = append(h.conds, cond{state: condFalse, n: h.buf.Len})
+	v, err := ok()
+	if err != nil {
+		return err
+	}
+	if v {
+		h.conds[len(h.conds)-1].state = condTrue
+	}
 	return nil
 }
 
-// ElseIf starts an else if block.
-func (h *Handler) ElseIf(ok bool) error {
+// ElseIf starts an alternative branch in the current conditional block. ok is
+// evaluated only when no prior branch of the block has been taken.
+func (h *Handler) ElseIf(ok func() (bool, error)) error {
+	if len(h.conds) == 0 {
+		return text.ErrElifNoMatchingIf
+	}
+	c := &h.conds[len(h.conds)-1]
+	switch c.state {
+	case condTrue:
+		c.state, c.n = condIgnored, h.buf.Len
+	case condFalse:
+		v, err := ok()
+		if err != nil {
+			return err
+		}
+		if v {
+			c.state = condTrue
+		}
+	case condElseTrue, condElseFalse:
+		return text.ErrElifAfterElse
+	}
 	return nil
 }
 
-// Else starts an else block.
-func (h *Handler) Else(bool) error {
+// Else starts the final branch in the current conditional block.
+func (h *Handler) Else() error {
+	if len(h.conds) == 0 {
+		return text.ErrElseNoMatchingIf
+	}
+	c := &h.conds[len(h.conds)-1]
+	switch c.state {
+	case condTrue:
+		c.state, c.n = condElseFalse, h.buf.Len
+	case condIgnored:
+		c.state = condElseFalse
+	case condFalse:
+		c.state = condElseTrue
+	case condElseTrue, condElseFalse:
+		return text.ErrElseAfterElse
+	}
 	return nil
 }
 
-// EndIf closes an if block.
-func (h *Handler) EndIf(bool) error {
+// EndIf closes the current conditional block.
+func (h *Handler) EndIf() error {
+	if len(h.conds) == 0 {
+		return text.ErrEndIfNoMatchingIf
+	}
+	h.conds = h.conds[:len(h.conds)-1]
 	return nil
 }
 
+// condActive returns true when not within an inactive conditional branch.
+func (h *Handler) condActive() bool {
+	if len(h.conds) == 0 {
+		return true
+	}
+	switch h.conds[len(h.conds)-1].state {
+	case condTrue, condElseTrue:
+		return true
+	}
+	return false
+}
+
 // IncludeReader includes the content of rdr.
 func (h *Handler) IncludeReader(rdr io.Reader, path string) error {
 	r := bufio.NewReader(rdr)
@@ -1541,6 +2537,14 @@ func (h *Handler) Include(path string, relative bool) error {
 	return h.IncludeReader(f, path)
 }
 
+// MetadataReader returns the metadata reader for the handler.
+func (h *Handler) MetadataReader(ctx context.Context) (metadata.Reader, error) {
+	if h.db == nil {
+		return nil, text.ErrNotConnected
+	}
+	return drivers.NewMetadataReader(ctx, h.u, h.db, h.l.Stdout(), readerOpts()...)
+}
+
 // MetadataWriter loads the metadata writer for the
 func (h *Handler) MetadataWriter(ctx context.Context) (metadata.Writer, error) {
 	if h.db == nil {
@@ -1596,6 +2600,41 @@ func (e *Error) Unwrap() error {
 	return e.Err
 }
 
+// condState is the state of a conditional block.
+type condState int
+
+// condState values.
+const (
+	// condTrue is an active branch.
+	condTrue condState = iota
+	// condFalse is an inactive branch, where no prior branch has been taken.
+	condFalse
+	// condIgnored is an inactive branch, where a prior branch has been taken
+	// or the block is nested within an inactive branch.
+	condIgnored
+	// condElseTrue is an active \else branch.
+	condElseTrue
+	// condElseFalse is an inactive \else branch.
+	condElseFalse
+)
+
+// cond is a conditional block.
+type cond struct {
+	// state is the state of the block.
+	state condState
+	// n is the length of the statement buffer when the block became inactive.
+	n int
+}
+
+// isCondCmd returns true when name is a conditional meta command.
+func isCondCmd(name string) bool {
+	switch name {
+	case "if", "elif", "else", "endif":
+		return true
+	}
+	return false
+}
+
 func readerOpts() []metadata.ReaderOption {
 	var opts []metadata.ReaderOption
 	if env.Get("ECHO_HIDDEN") == "on" || env.Get("ECHO_HIDDEN") == "noexec" {
@@ -1676,6 +2715,45 @@ func isSpaceOrControl(r rune) bool {
 	return unicode.IsSpace(r) || unicode.IsControl(r)
 }
 
+// highlightChanges returns cur with the words of each line that differ from
+// the word in the same position of the same line in prev highlighted (in
+// reverse video). When prev is nil, cur is returned as-is.
+func highlightChanges(prev, cur []byte) []byte {
+	if prev == nil {
+		return cur
+	}
+	a, b := strings.Split(string(prev), "\n"), strings.Split(string(cur), "\n")
+	for i := range b {
+		var words []string
+		if i < len(a) {
+			words = strings.Fields(a[i])
+		}
+		var sb strings.Builder
+		line, n := b[i], 0
+		for line != "" {
+			j := strings.IndexFunc(line, func(r rune) bool { return !unicode.IsSpace(r) })
+			if j == -1 {
+				sb.WriteString(line)
+				break
+			}
+			sb.WriteString(line[:j])
+			line = line[j:]
+			k := strings.IndexFunc(line, unicode.IsSpace)
+			if k == -1 {
+				k = len(line)
+			}
+			if word := line[:k]; n < len(words) && words[n] == word {
+				sb.WriteString(word)
+			} else {
+				sb.WriteString("\x1b[7m" + word + "\x1b[0m")
+			}
+			line, n = line[k:], n+1
+		}
+		b[i] = sb.String()
+	}
+	return []byte(strings.Join(b, "\n"))
+}
+
 // lastIndex returns the last index in r of needle, or -1 if not found.
 func lastIndex(r []rune, needle rune) int {
 	for i := len(r) - 1; i >= 0; i-- {
@@ -1692,5 +2770,13 @@ var ansiRE = regexp.MustCompile(`\x1b[[0-9]+([:;][0-9]+)*m`)
 // lineendRE is the end of line terminal.
 var lineendRE = regexp.MustCompile(`(?:\r?\n)+$`)
 
+// copyFromStdinRE matches a COPY ... FROM STDIN statement, capturing the
+// table (with optional column list) and options.
+var copyFromStdinRE = regexp.MustCompile(`(?ism)^\s*COPY\s+(.+?)\s+FROM\s+STDIN\b(.*?)\s*;?\s*\z`)
+
+// copyTableRE matches the whitespace preceding the column list in a COPY
+// statement.
+var copyTableRE = regexp.MustCompile(`\s+\(`)
+
 // helpQuitExitRE is a regexp to use to match help, quit, or exit messages.
 var helpQuitExitRE = regexp.MustCompile(`(?im)^+(` + strings.Join([]string{text.HelpPrefix, text.QuitPrefix, text.ExitPrefix}, "|") + `)\s*$`)
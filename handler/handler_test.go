@@ -0,0 +1,349 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gohxs/readline"
+	"github.com/xo/usql/drivers"
+	_ "github.com/xo/usql/drivers/sqlite3"
+	"github.com/xo/usql/rline"
+	"github.com/xo/usql/text"
+)
+
+func TestConditionals(t *testing.T) {
+	tests := []struct {
+		name, script, exp string
+	}{
+		{
+			"if",
+			"\\if true\n\\echo a\n\\else\n\\echo b\n\\endif\n\\echo c\n",
+			"a\nc\n",
+		},
+		{
+			"elif",
+			"\\if false\n\\echo a\n\\elif true\n\\echo b\n\\elif true\n\\echo c\n\\else\n\\echo d\n\\endif\n",
+			"b\n",
+		},
+		{
+			"nested",
+			"\\if true\n" +
+				"\\if false\n\\echo a\n\\else\n\\echo b\n\\endif\n" +
+				"\\echo c\n" +
+				"\\else\n" +
+				"\\if true\n\\echo d\n\\else\n\\echo e\n\\endif\n" +
+				"\\endif\n" +
+				"\\echo f\n",
+			"b\nc\nf\n",
+		},
+		{
+			"statements",
+			"\\if false\nselect 1;\nselect 'it''s\n\\endif\n\\echo a\n",
+			"a\n",
+		},
+		{
+			"copy data block",
+			"\\if false\nCOPY t (a, b) FROM STDIN;\n1\tit's\n\\endif\n\\echo b\n\\.\n\\endif\n\\echo a\n",
+			"a\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stdout, stderr, err := runScript(t, test.script)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v (stderr: %q)", err, stderr)
+			}
+			if stdout != test.exp || stderr != "" {
+				t.Errorf("expected %q, got: %q (stderr: %q)", test.exp, stdout, stderr)
+			}
+		})
+	}
+}
+
+func TestConditionalsUnbalanced(t *testing.T) {
+	dir := t.TempDir()
+	inc := filepath.Join(dir, "inc.sql")
+	if err := os.WriteFile(inc, []byte("\\echo a\n\\endif\n"), 0o644); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	unterminated := filepath.Join(dir, "unterminated.sql")
+	if err := os.WriteFile(unterminated, []byte("\\if true\n\\echo b\n"), 0o644); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	tests := []struct {
+		script, exp string
+		err         error
+	}{
+		{"\\endif\n", "", text.ErrEndIfNoMatchingIf},
+		{"\\else\n", "", text.ErrElseNoMatchingIf},
+		{"\\if true\n\\else\n\\else\n\\endif\n", "", text.ErrElseAfterElse},
+		{"\\if true\n\\echo a\n", "a\n", text.ErrUnterminatedIf},
+		// blocks must be balanced within an included file
+		{"\\if true\n\\i " + inc + "\n\\echo c\n", "a\nc\n", text.ErrUnterminatedIf},
+		{"\\i " + unterminated + "\n\\echo c\n", "b\nc\n", nil},
+	}
+	for i, test := range tests {
+		stdout, stderr, err := runScript(t, test.script)
+		switch {
+		case test.err == nil && err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case test.err != nil && (err == nil || !strings.Contains(err.Error(), test.err.Error())) && !strings.Contains(stderr, test.err.Error()):
+			t.Errorf("test %d expected error %v, got: %v", i, test.err, err)
+		}
+		if stdout != test.exp {
+			t.Errorf("test %d expected %q, got: %q (stderr: %q)", i, test.exp, stdout, stderr)
+		}
+	}
+}
+
+func TestConditionalsInterrupt(t *testing.T) {
+	lines := []string{"\\if false", "\\if true", "", "\\echo a", "\\endif", "\\echo b"}
+	u, err := user.Current()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	l := &rline.Rline{Inst: &readline.Instance{Config: &readline.Config{}}, Out: stdout, Err: stderr, Int: true, N: func() ([]rune, error) {
+		if len(lines) == 0 {
+			return nil, io.EOF
+		}
+		line := lines[0]
+		lines = lines[1:]
+		// an empty line is an interrupt (Ctrl-C)
+		if line == "" {
+			return nil, rline.ErrInterrupt
+		}
+		return []rune(line), nil
+	}}
+	if err := New(l, u, t.TempDir(), nil, true).Run(); err != nil {
+		t.Fatalf("expected no error, got: %v (stderr: %q)", err, stderr)
+	}
+	// only the inner block is exited, so \echo a is still ignored
+	if s := stdout.String(); strings.Contains(s, "a\n") || !strings.HasSuffix(s, "b\n") {
+		t.Errorf("expected only b, got: %q", s)
+	}
+	if s, exp := stderr.String(), text.ErrIfEscaped.Error()+"\n"; !strings.HasPrefix(s, exp) {
+		t.Errorf("expected stderr to start with %q, got: %q", exp, s)
+	}
+}
+
+func TestCopyWithInsert(t *testing.T) {
+	withoutCopy(t, "sqlite3")
+	dir := t.TempDir()
+	file := filepath.Join(dir, "t.csv")
+	if err := os.WriteFile(file, []byte("id,name\n1,a\n2,b\n"), 0o644); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	stdout, stderr, err := runScript(t, "\\c sqlite3:"+filepath.Join(dir, "test.db")+"\n"+
+		"create table t (id integer, name text);\n"+
+		"\\copy t FROM '"+file+"' WITH (FORMAT csv, HEADER)\n"+
+		"\\begin\n"+
+		"\\copy t FROM '"+file+"' WITH (FORMAT csv, HEADER)\n"+
+		"\\rollback\n"+
+		"\\pset format unaligned\n"+
+		"select count(*) from t;\n",
+	)
+	if err != nil || stderr != "" {
+		t.Fatalf("expected no error, got: %v (stderr: %q)", err, stderr)
+	}
+	if exp := "CREATE TABLE\nCOPY 2\nCOPY 2\nOutput format is unaligned.\ncount(*)\n2\n(1 row)\n"; stdout != exp {
+		t.Errorf("expected %q, got: %q", exp, stdout)
+	}
+}
+
+func TestCopyFromStdin(t *testing.T) {
+	withoutCopy(t, "sqlite3")
+	stdout, stderr, err := runScript(t, "\\c sqlite3:"+filepath.Join(t.TempDir(), "test.db")+"\n"+
+		"create table t (id integer, name text);\n"+
+		"COPY t (id, name) FROM stdin;\n"+
+		"1\ta\n"+
+		"2\t\\N\n"+
+		"3\tc;\n"+
+		"\\.\n"+
+		"\\pset format unaligned\n"+
+		"select id, coalesce(name, 'null') from t;\n",
+	)
+	if err != nil || stderr != "" {
+		t.Fatalf("expected no error, got: %v (stderr: %q)", err, stderr)
+	}
+	if exp := "CREATE TABLE\nCOPY 3\nOutput format is unaligned.\nid|coalesce(name, 'null')\n1|a\n2|null\n3|c;\n(3 rows)\n"; stdout != exp {
+		t.Errorf("expected %q, got: %q", exp, stdout)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	stdout, stderr, err := runScript(t, "\\c sqlite3:"+filepath.Join(t.TempDir(), "test.db")+"\n"+
+		"create table t (a integer, b text);\n"+
+		"\\pset format unaligned\n"+
+		"select a, b from t order by a \\gdesc\n"+
+		"pragma table_info(t) \\gdesc\n"+
+		"insert into t values (1, 'a') \\gdesc\n"+
+		"select count(*) from t;\n",
+	)
+	if err != nil || stderr != "" {
+		t.Fatalf("expected no error, got: %v (stderr: %q)", err, stderr)
+	}
+	exp := "CREATE TABLE\nOutput format is unaligned.\n" +
+		"Column|Type\na|INTEGER\nb|TEXT\n(2 rows)\n" +
+		"Column|Type\ncid|\nname|\ntype|\nnotnull|\ndflt_value|\npk|\n(6 rows)\n" +
+		text.QueryHasNoColumns + "\n" +
+		"count(*)\n0\n(1 row)\n"
+	if stdout != exp {
+		t.Errorf("expected %q, got: %q", exp, stdout)
+	}
+}
+
+func TestRowCounterCapture(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer db.Close()
+	tests := []struct {
+		name string
+		dest func() []interface{}
+	}{
+		{"interface", func() []interface{} {
+			return []interface{}{new(interface{}), new(interface{}), new(interface{})}
+		}},
+		{"column types", func() []interface{} {
+			return []interface{}{new(int64), new(sql.RawBytes), new(sql.NullString)}
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows, err := db.Query(`SELECT * FROM (SELECT 1, 'a', NULL UNION ALL SELECT 2, 'b', 'c') ORDER BY 1`)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			defer rows.Close()
+			r := &rowCounter{Rows: rows, capture: true}
+			for r.Next() {
+				if err := r.Scan(test.dest()...); err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+			}
+			var v []interface{}
+			for _, z := range r.values {
+				v = append(v, *z.(*interface{}))
+			}
+			if len(v) != 3 || fmt.Sprint(v[0]) != "1" || fmt.Sprintf("%s", v[1]) != "a" || v[2] != nil {
+				t.Errorf("expected [1 a <nil>], got: %v", v)
+			}
+		})
+	}
+}
+
+func TestWatchFileColumns(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "watch.csv")
+	_, stderr, err := runScript(t, "\\c sqlite3:"+filepath.Join(t.TempDir(), "test.db")+"\n"+
+		"select 1 as a \\watch i=0.01 c=2 file="+file+"\n"+
+		"select 1 as b \\watch i=0.01 c=1 file="+file+"\n",
+	)
+	if exp := `result columns (time, b) do not match the columns (time, a)`; (err == nil || !strings.Contains(err.Error(), exp)) && !strings.Contains(stderr, exp) {
+		t.Errorf("expected error %q, got: %v (stderr: %q)", exp, err, stderr)
+	}
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(buf)), "\n")
+	if len(lines) != 3 || lines[0] != "time,a" || !strings.HasSuffix(lines[1], ",1") || !strings.HasSuffix(lines[2], ",1") {
+		t.Errorf("expected header and 2 rows, got: %q", buf)
+	}
+}
+
+func TestWatchSeries(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "watch.csv")
+	tests := []struct {
+		script string
+		err    error
+	}{
+		{"select 1 as a \\watch i=0.01 c=1 d file=" + file + "\n", text.ErrWatchDiffWithSeries},
+		{"create table t (a int) \\watch i=0.01 c=1 file=" + file + "\n", text.ErrWatchSeriesNotQuery},
+	}
+	for i, test := range tests {
+		_, stderr, err := runScript(t, "\\c sqlite3:"+filepath.Join(t.TempDir(), "test.db")+"\n"+test.script)
+		if exp := test.err.Error(); (err == nil || !strings.Contains(err.Error(), exp)) && !strings.Contains(stderr, exp) {
+			t.Errorf("test %d expected error %q, got: %v (stderr: %q)", i, exp, err, stderr)
+		}
+	}
+	// only the most recent points are retained, and only for a chart
+	for _, chart := range []bool{false, true} {
+		series, err := newWatchSeries("", "", chart)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		for i := range watchChartPoints + 5 {
+			if err := series.append(time.Now(), []string{"a"}, [][]sql.NullString{{{String: strconv.Itoa(i), Valid: true}}}); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+		}
+		switch {
+		case !chart && series.transposed != nil:
+			t.Errorf("expected no chart points, got: %d", len(series.transposed[0]))
+		case chart && (len(series.transposed[1]) != watchChartPoints || series.transposed[1][0] != "5"):
+			t.Errorf("expected %d chart points starting at 5, got: %d", watchChartPoints, len(series.transposed[1]))
+		}
+	}
+}
+
+func TestEditViewDefinition(t *testing.T) {
+	dir := t.TempDir()
+	editor := filepath.Join(dir, "editor.sh")
+	script := "#!/bin/sh\nfor f; do case \"$f\" in +*) ;; *) sed -i 's/select a from t/select a, b from t/' \"$f\";; esac; done\n"
+	if err := os.WriteFile(editor, []byte(script), 0o755); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	stdout, stderr, err := runScript(t, "\\c sqlite3:"+filepath.Join(dir, "test.db")+"\n"+
+		"create table t (a integer, b integer);\n"+
+		"create view v as select a from t;\n"+
+		"\\set EDITOR "+editor+"\n"+
+		"\\ev v\n"+
+		";\n"+
+		"\\pset format unaligned\n"+
+		"select * from v;\n",
+	)
+	if err != nil || stderr != "" {
+		t.Fatalf("expected no error, got: %v (stderr: %q)", err, stderr)
+	}
+	if exp := "CREATE TABLE\nCREATE VIEW\nDROP VIEW\nOutput format is unaligned.\na|b\n(0 rows)\n"; stdout != exp {
+		t.Errorf("expected %q, got: %q", exp, stdout)
+	}
+}
+
+// withoutCopy disables the driver's copy func for the duration of the test.
+func withoutCopy(t *testing.T, name string) {
+	t.Helper()
+	d := drivers.Available()[name]
+	f := d.Copy
+	d.Copy = nil
+	drivers.Available()[name] = d
+	t.Cleanup(func() {
+		d.Copy = f
+		drivers.Available()[name] = d
+	})
+}
+
+// runScript runs the script on a new handler, returning the handler's
+// output.
+func runScript(t *testing.T, script string) (string, string, error) {
+	t.Helper()
+	u, err := user.Current()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	h := New(&rline.Rline{Out: stdout, Err: stderr}, u, t.TempDir(), nil, true)
+	err = h.IncludeReader(strings.NewReader(script), "test.sql")
+	return stdout.String(), stderr.String(), err
+}
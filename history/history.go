@@ -0,0 +1,137 @@
+// Package history provides a persistent, per-connection statement history for
+// usql.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xo/dburl"
+)
+
+// Entry is a history entry.
+type Entry struct {
+	// Time is the time the statement was executed.
+	Time time.Time `json:"time"`
+	// Conn is the connection the statement was executed on.
+	Conn string `json:"conn"`
+	// Stmt is the (possibly multi-line) statement.
+	Stmt string `json:"stmt"`
+}
+
+// Conn returns the connection description for the URL, as stored in history
+// entries. The description does not contain the password.
+func Conn(u *dburl.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.Short()
+}
+
+// Key returns a file name safe key for the URL, used to name the URL's
+// history file.
+//
+// The key is made up of the URL's driver, host, port, database, and user name,
+// followed by a hash of the connection description to prevent collisions.
+func Key(u *dburl.URL) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(Conn(u)))
+	s := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '-', r == '.':
+			return r
+		}
+		return '_'
+	}, u.Normalize("-", "", 0))
+	return strings.Trim(s, "-_.") + "-" + strconv.FormatUint(uint64(h.Sum32()), 16)
+}
+
+// Append appends the entry to the history file at path.
+func Append(path string, e Entry) error {
+	buf, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(buf, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load loads the history entries from the history file at path. A
+// non-existent file has no entries.
+func Load(path string) ([]Entry, error) {
+	return load(path, func(s string) (Entry, error) {
+		var e Entry
+		err := json.Unmarshal([]byte(s), &e)
+		return e, err
+	})
+}
+
+// LoadLines loads the history entries from the line-based (readline) history
+// file at path. Entries have neither a time nor a connection.
+func LoadLines(path string) ([]Entry, error) {
+	return load(path, func(s string) (Entry, error) {
+		return Entry{Stmt: s}, nil
+	})
+}
+
+// load loads the non-empty lines of the file at path, using f to decode each
+// line.
+func load(path string, f func(string) (Entry, error)) ([]Entry, error) {
+	fi, err := os.Open(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	defer fi.Close()
+	var entries []Entry
+	r := bufio.NewReader(fi)
+	for {
+		s, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if s = strings.TrimRight(s, "\r\n"); s != "" {
+			e, err := f(s)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+		if err == io.EOF {
+			return entries, nil
+		}
+	}
+}
+
+// Search returns the entries whose statement or connection matches the
+// (case-insensitive) regular expression pattern.
+func Search(entries []Entry, pattern string) ([]Entry, error) {
+	re, err := regexp.Compile(`(?i)` + pattern)
+	if err != nil {
+		return nil, err
+	}
+	var v []Entry
+	for _, e := range entries {
+		if re.MatchString(e.Stmt) || re.MatchString(e.Conn) {
+			v = append(v, e)
+		}
+	}
+	return v, nil
+}
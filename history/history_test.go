@@ -0,0 +1,84 @@
+package history
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/xo/dburl"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		s   string
+		exp string
+	}{
+		{"pg://user:pass@localhost:5432/mydb", `^postgres-localhost-5432-mydb-user-[0-9a-f]+$`},
+		{"sq:/path/to/my.db", `^sqlite3-_path_to_my.db-[0-9a-f]+$`},
+	}
+	for _, test := range tests {
+		u, err := dburl.Parse(test.s)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		key := Key(u)
+		if !regexp.MustCompile(test.exp).MatchString(key) {
+			t.Errorf("expected key for %q to match %s, got: %q", test.s, test.exp, key)
+		}
+		if s := Conn(u); regexp.MustCompile(`pass`).MatchString(s) {
+			t.Errorf("expected conn for %q to not contain password, got: %q", test.s, s)
+		}
+	}
+	a, _ := dburl.Parse("pg://a@localhost/db")
+	b, _ := dburl.Parse("pg://b@localhost/db")
+	if Key(a) == Key(b) {
+		t.Errorf("expected different keys for different users, got: %q", Key(a))
+	}
+}
+
+func TestAppendLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	entries, err := Load(path)
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case len(entries) != 0:
+		t.Fatalf("expected no entries, got: %v", entries)
+	}
+	now := time.Now().Round(time.Second)
+	exp := []Entry{
+		{Time: now, Conn: "pg:localhost/a", Stmt: "select 1;"},
+		{Time: now, Conn: "pg:localhost/b", Stmt: "select\n  *\nfrom t;"},
+		{Time: now, Conn: "sq:file.db", Stmt: "insert into t values (1);"},
+	}
+	for _, e := range exp {
+		if err := Append(path, e); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	if entries, err = Load(path); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(entries) != len(exp) {
+		t.Fatalf("expected %d entries, got: %d", len(exp), len(entries))
+	}
+	for i, e := range entries {
+		if !e.Time.Equal(exp[i].Time) || e.Conn != exp[i].Conn || e.Stmt != exp[i].Stmt {
+			t.Errorf("entry %d expected %+v, got: %+v", i, exp[i], e)
+		}
+	}
+	v, err := Search(entries, "FROM t")
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case len(v) != 1 || v[0].Stmt != exp[1].Stmt:
+		t.Errorf("expected search to match entry 1, got: %v", v)
+	}
+	if v, _ = Search(entries, `^sq:`); len(v) != 1 || v[0].Conn != "sq:file.db" {
+		t.Errorf("expected search to match entry 2, got: %v", v)
+	}
+	if _, err = Search(entries, "("); err == nil {
+		t.Errorf("expected error for invalid pattern")
+	}
+}
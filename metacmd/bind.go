@@ -0,0 +1,160 @@
+package metacmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/stmt"
+	"github.com/xo/usql/text"
+)
+
+// bindValues reads the remaining command parameters as bind values.
+func (p *Params) bindValues() ([]interface{}, error) {
+	unquote := env.Untick(p.Handler.User(), env.Vars(), true)
+	var v []interface{}
+	for {
+		// read the raw parameter, without decoding quoted strings or variables
+		s, ok, err := p.Params.Next(func(string, bool) (string, bool, error) {
+			return "", false, nil
+		})
+		switch {
+		case err != nil:
+			return nil, err
+		case !ok:
+			return v, nil
+		}
+		z, err := bindValue(s, unquote)
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, z)
+	}
+}
+
+// bindValue converts a (raw) bind parameter to a value, using unquote to
+// decode quoted strings and variables. Parameters may be annotated with a type
+// (ie, 42::int), and the unquoted NULL is a NULL value.
+//
+// Variable references (:name, :'name', or :"name") are replaced with the
+// variable's value, without quoting.
+func bindValue(s string, unquote func(string, bool) (string, bool, error)) (interface{}, error) {
+	s, typ := splitCast(s)
+	if strings.EqualFold(s, "null") {
+		return nil, nil
+	}
+	var z string
+	var buf []byte
+	switch m := bindVarRE.FindStringSubmatch(s); {
+	case m != nil && m[1] == m[3]:
+		v, ok, err := unquote(m[2], true)
+		switch {
+		case err != nil:
+			return nil, err
+		case !ok:
+			return nil, fmt.Errorf(text.UndefinedBindVariable, m[2])
+		}
+		z = v
+	case bindHexRE.MatchString(s):
+		var err error
+		if buf, err = hex.DecodeString(s[2 : len(s)-1]); err != nil {
+			return nil, fmt.Errorf(text.InvalidBindValue, s, "bytes")
+		}
+		if typ == "" {
+			return buf, nil
+		}
+		z = string(buf)
+	default:
+		var err error
+		if z, _, err = stmt.NewParams(s).Next(unquote); err != nil {
+			return nil, err
+		}
+	}
+	switch strings.ToLower(typ) {
+	case "", "text", "string", "varchar", "char":
+		return z, nil
+	case "int", "integer", "smallint", "bigint", "int2", "int4", "int8":
+		i, err := strconv.ParseInt(z, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf(text.InvalidBindValue, z, typ)
+		}
+		return i, nil
+	case "float", "real", "double", "float4", "float8":
+		f, err := strconv.ParseFloat(z, 64)
+		if err != nil {
+			return nil, fmt.Errorf(text.InvalidBindValue, z, typ)
+		}
+		return f, nil
+	case "bool", "boolean":
+		b, err := env.ParseBool(z, typ)
+		if err != nil {
+			return nil, fmt.Errorf(text.InvalidBindValue, z, typ)
+		}
+		return b == "on", nil
+	case "bytes", "bytea", "blob", "binary":
+		switch {
+		case buf != nil:
+			return buf, nil
+		case strings.HasPrefix(z, `\x`):
+			b, err := hex.DecodeString(z[2:])
+			if err != nil {
+				return nil, fmt.Errorf(text.InvalidBindValue, z, typ)
+			}
+			return b, nil
+		}
+		return []byte(z), nil
+	case "date", "time", "timestamp", "timestamptz", "datetime":
+		for _, layout := range bindTimeLayouts {
+			if t, err := time.Parse(layout, z); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf(text.InvalidBindValue, z, typ)
+	}
+	return nil, fmt.Errorf(text.UnknownBindType, typ)
+}
+
+// splitCast splits a type annotation (ie, ::int) from the end of s, when not
+// inside a quoted string.
+func splitCast(s string) (string, string) {
+	var quote rune
+	end := -1
+	for i, c := range s {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == ':' && strings.HasPrefix(s[i:], "::"):
+			end = i
+		}
+	}
+	if end == -1 || !bindTypeRE.MatchString(s[end+2:]) {
+		return s, ""
+	}
+	return s[:end], s[end+2:]
+}
+
+// bindVarRE matches a variable reference.
+var bindVarRE = regexp.MustCompile(`^:(['"]?)([A-Za-z_][A-Za-z0-9_]*)(['"]?)$`)
+
+// bindHexRE matches a hex string literal.
+var bindHexRE = regexp.MustCompile(`^[xX]'[0-9a-fA-F]*'$`)
+
+// bindTypeRE matches a bind type.
+var bindTypeRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// bindTimeLayouts are the accepted time layouts for bind values.
+var bindTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	time.DateOnly,
+	time.TimeOnly,
+}
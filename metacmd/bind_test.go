@@ -0,0 +1,57 @@
+package metacmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xo/usql/env"
+)
+
+func TestBindValue(t *testing.T) {
+	vars := env.NewVars()
+	if err := vars.Set("n", "42"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	unquote := env.Untick(nil, vars, false)
+	tests := []struct {
+		s   string
+		exp interface{}
+		err bool
+	}{
+		{`abc`, "abc", false},
+		{`'a b'`, "a b", false},
+		{`NULL`, nil, false},
+		{`null::int`, nil, false},
+		{`'NULL'`, "NULL", false},
+		{`42::int`, int64(42), false},
+		{`'42'::bigint`, int64(42), false},
+		{`4x::int`, nil, true},
+		{`1.5::float8`, 1.5, false},
+		{`t::bool`, true, false},
+		{`off::boolean`, false, false},
+		{`'x'::text`, "x", false},
+		{`'a::b'`, "a::b", false},
+		{`x'deadbeef'`, []byte{0xde, 0xad, 0xbe, 0xef}, false},
+		{`x'deadbeef'::bytes`, []byte{0xde, 0xad, 0xbe, 0xef}, false},
+		{`\xbeef::bytea`, []byte{0xbe, 0xef}, false},
+		{`abc::bytes`, []byte("abc"), false},
+		{`2024-01-02::date`, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), false},
+		{`'2024-01-02 03:04:05'::timestamp`, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), false},
+		{`:n`, "42", false},
+		{`:'n'::int`, int64(42), false},
+		{`:missing`, nil, true},
+		{`1::unknown`, nil, true},
+	}
+	for _, test := range tests {
+		v, err := bindValue(test.s, unquote)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("%q expected error, got: %v", test.s, v)
+		case !test.err && err != nil:
+			t.Errorf("%q expected no error, got: %v", test.s, err)
+		case !reflect.DeepEqual(v, test.exp):
+			t.Errorf("%q expected %#v, got: %#v", test.s, test.exp, v)
+		}
+	}
+}
@@ -5,18 +5,27 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"image/color"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/xo/dburl"
+	"github.com/xo/resvg"
 	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/drivers/metadata"
 	"github.com/xo/usql/env"
+	"github.com/xo/usql/history"
+	"github.com/xo/usql/stmt"
 	"github.com/xo/usql/text"
 )
 
@@ -92,6 +101,62 @@ func Drivers(p *Params) error {
 	return nil
 }
 
+// ErrVerbose is a General meta command (\errverbose). Writes the most recent
+// error to the output, with all available error fields.
+//
+// Descs:
+//
+//	errverbose	show most recent error message at maximum verbosity
+func ErrVerbose(p *Params) error {
+	stdout := p.Handler.IO().Stdout()
+	err := p.Handler.LastError()
+	if err == nil {
+		fmt.Fprintln(stdout, text.NoPreviousError)
+		return nil
+	}
+	detail := drivers.ErrDetail(err)
+	severity := detail.Severity
+	if severity == "" {
+		severity = "ERROR"
+	}
+	if detail.Code != "" {
+		fmt.Fprintf(stdout, "%s:  %s: %s\n", severity, detail.Code, detail.Message)
+	} else {
+		fmt.Fprintf(stdout, "%s:  %s\n", severity, detail.Message)
+	}
+	for _, f := range []struct {
+		name, value string
+	}{
+		{"DETAIL", detail.Detail},
+		{"HINT", detail.Hint},
+		{"POSITION", positionString(detail)},
+		{"CONTEXT", detail.Where},
+		{"SCHEMA NAME", detail.Schema},
+		{"TABLE NAME", detail.Table},
+		{"COLUMN NAME", detail.Column},
+		{"DATATYPE NAME", detail.DataType},
+		{"CONSTRAINT NAME", detail.Constraint},
+		{"LOCATION", detail.Location},
+	} {
+		if f.value != "" {
+			fmt.Fprintf(stdout, "%s:  %s\n", f.name, f.value)
+		}
+	}
+	return nil
+}
+
+// positionString returns the error position as a string, or an empty string
+// when there is no position.
+func positionString(detail *drivers.ErrorDetail) string {
+	switch {
+	case detail.Position <= 0:
+		return ""
+	case detail.Line > 0:
+		return fmt.Sprintf("%d (line %d)", detail.Position, detail.Line)
+	}
+	return strconv.Itoa(detail.Position)
+}
+
 // Help is a Help meta command (\?). Writes a help message to the output.
 //
 // Descs:
@@ -140,6 +205,7 @@ func Help(p *Params) error {
 //	G	[(OPTIONS)] [FILE]	as \g, but forces vertical output mode
 //	ego:G
 //	gx	[(OPTIONS)] [FILE]	as \g, but forces expanded output mode
+//	gdesc	describe result of query, without executing it
 //	gexec	execute query and execute each value of the result
 //	gset	[PREFIX]	execute query and store results in {{CommandName}} variables
 func Execute(p *Params) error {
@@ -162,6 +228,8 @@ func Execute(p *Params) error {
 			p.Option.Exec = ExecSet
 			p.Option.ParseParams(params, "prefix")
 		}
+	case "gdesc":
+		p.Option.Exec = ExecDesc
 	case "gexec":
 		p.Option.Exec = ExecExec
 	}
@@ -169,33 +237,97 @@ func Execute(p *Params) error {
 }
 
 // Bind is a Query Execute meta command (\bind). Sets (or unsets) variables to
-// be used when executing a query.
+// be used when executing a query. Values can be annotated with a type (ie,
+// 42::int or x'beef'::bytes), and the unquoted NULL binds a NULL value.
 //
 // Descs:
 //
 //	bind	[PARAM]...	set query parameters
 func Bind(p *Params) error {
-	bind, err := p.All(true)
+	v, err := p.bindValues()
 	if err != nil {
 		return err
 	}
-	var v []interface{}
-	if n := len(bind); n != 0 {
-		v = make([]interface{}, len(bind))
-		for i := range n {
-			v[i] = bind[i]
+	p.Handler.Bind(v)
+	return nil
+}
+
+// Parse is a Query Execute meta command (\parse). Prepares the query buffer as
+// a named prepared statement, or lists the named prepared statements.
+//
+// Descs:
+//
+//	parse	[NAME]	prepare query buffer as named statement, or list statements
+func Parse(p *Params) error {
+	name, err := p.Next(true)
+	switch {
+	case err != nil:
+		return err
+	case name != "":
+		return p.Handler.Parse(context.Background(), name)
+	}
+	stdout := p.Handler.IO().Stdout()
+	prepared := p.Handler.Prepared()
+	if len(prepared) == 0 {
+		fmt.Fprintln(stdout, text.NoPreparedStatements)
+		return nil
+	}
+	names := slices.Sorted(maps.Keys(prepared))
+	for _, name := range names {
+		fmt.Fprintf(stdout, "%s:\n", name)
+		for _, line := range strings.Split(prepared[name], "\n") {
+			fmt.Fprintln(stdout, "  "+line)
 		}
 	}
-	p.Handler.Bind(v)
 	return nil
 }
 
+// BindNamed is a Query Execute meta command (\bind_named). Sets the query
+// parameters for a named prepared statement, which is executed in place of the
+// query buffer.
+//
+// Descs:
+//
+//	bind_named	NAME [PARAM]...	set query parameters for a named prepared statement
+func BindNamed(p *Params) error {
+	name, err := p.Next(true)
+	switch {
+	case err != nil:
+		return err
+	case name == "":
+		return text.ErrMissingRequiredArgument
+	}
+	v, err := p.bindValues()
+	if err != nil {
+		return err
+	}
+	return p.Handler.BindNamed(name, v)
+}
+
+// Close is a Query Execute meta command (\close). Closes a named prepared
+// statement.
+//
+// Descs:
+//
+//	close	NAME	close a named prepared statement
+func Close(p *Params) error {
+	name, err := p.Next(true)
+	switch {
+	case err != nil:
+		return err
+	case name == "":
+		return text.ErrMissingRequiredArgument
+	}
+	return p.Handler.Deallocate(name)
+}
+
 // Timing is a Query Execute meta command (\timing). Sets (or toggles) writing
-// timing information for executed queries to the output.
+// timing information for executed queries to the output. When verbose, also
+// writes the time to the first row, fetching, and formatting of query results.
 //
 // Descs:
 //
-//	timing	[on|off]	toggle timing of commands
+//	timing	[on|off|verbose]	toggle timing of commands
 func Timing(p *Params) error {
 	v, err := p.Next(true)
 	switch {
@@ -203,6 +335,10 @@ func Timing(p *Params) error {
 		return err
 	case v == "":
 		p.Handler.SetTiming(!p.Handler.GetTiming())
+		p.Handler.SetTimingVerbose(false)
+	case strings.EqualFold(v, "verbose"):
+		p.Handler.SetTiming(true)
+		p.Handler.SetTimingVerbose(true)
 	default:
 		s, err := env.ParseBool(v, `\timing`)
 		if err != nil {
@@ -215,9 +351,13 @@ func Timing(p *Params) error {
 			b = true
 		}
 		p.Handler.SetTiming(b)
+		p.Handler.SetTimingVerbose(false)
 	}
 	setting := "off"
-	if p.Handler.GetTiming() {
+	switch {
+	case p.Handler.GetTimingVerbose():
+		setting = "verbose"
+	case p.Handler.GetTiming():
 		setting = "on"
 	}
 	p.Handler.Print(text.TimingSet, setting)
@@ -283,28 +423,114 @@ func Chart(p *Params) error {
 }
 
 // Watch is a Query View meta command (\watch). Executes (and re-executes) the
-// active query on the open database connection until canceled by the user.
+// active query on the open database connection until canceled by the user,
+// the query has been executed count (c) times, the query returns fewer than
+// min_rows (m) rows, or the value of the while (w) column is false. The diff
+// (d) option highlights values that changed since the previous execution.
+//
+// The file and chart options append the results of each execution, along with
+// a timestamp, to a csv or jsonl (JSON lines) file (per the format option, or
+// the file's extension), or display them as a live-updating chart. The file
+// and chart options require a query returning rows, and can not be used with
+// the diff option.
 //
 // Descs:
 //
-//	watch	[(OPTIONS)] [INTERVAL]	execute query every specified interval
+//	watch	[[i=]SEC] [c=N] [m=MIN]	execute query every specified interval
+//	watch	[w=COL] [d]	as \watch, but stop when COL is false, or highlight changes
+//	watch	[file=F] [chart=TYPE]	as \watch, but append timestamped results to file (format=csv|jsonl) or a chart
 func Watch(p *Params) error {
 	p.Option.Exec = ExecWatch
 	p.Option.Watch = 2 * time.Second
-	switch s, ok, err := p.NextOK(true); {
-	case err != nil:
+	params, err := p.All(true)
+	if err != nil {
 		return err
-	case ok:
-		d, err := time.ParseDuration(s)
-		if err != nil {
-			if f, err := strconv.ParseFloat(s, 64); err == nil {
-				d = time.Duration(f * float64(time.Second))
+	}
+	for _, param := range params {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok {
+			name, value = "", param
+		}
+		switch name {
+		case "", "i", "interval":
+			if name == "" && (value == "d" || value == "diff") {
+				p.Option.WatchDiff = true
+				continue
+			}
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				if f, err := strconv.ParseFloat(value, 64); err == nil {
+					d = time.Duration(f * float64(time.Second))
+				}
+			}
+			if d <= 0 {
+				return text.ErrInvalidWatchDuration
+			}
+			p.Option.Watch = d
+		case "c", "count":
+			if p.Option.WatchCount, err = strconv.Atoi(value); err != nil || p.Option.WatchCount <= 0 {
+				return fmt.Errorf(text.InvalidOption, param)
 			}
+		case "m", "min_rows":
+			if p.Option.WatchMinRows, err = strconv.Atoi(value); err != nil || p.Option.WatchMinRows <= 0 {
+				return fmt.Errorf(text.InvalidOption, param)
+			}
+		case "w", "while":
+			if err := env.ValidIdentifier(value); err != nil {
+				return fmt.Errorf(text.InvalidOption, param)
+			}
+			p.Option.WatchWhile = value
+		case "d", "diff":
+			b, err := env.ParseBool(value, name)
+			if err != nil {
+				return err
+			}
+			p.Option.WatchDiff = b == "on"
+		case "file":
+			p.Option.WatchFile = value
+		case "format":
+			if value != "csv" && value != "jsonl" {
+				return fmt.Errorf(text.InvalidOption, param)
+			}
+			p.Option.WatchFormat = value
+		case "chart":
+			p.Option.WatchChart = value
+		default:
+			return fmt.Errorf(text.InvalidOption, param)
 		}
-		if d == 0 {
-			return text.ErrInvalidWatchDuration
+	}
+	if p.Option.WatchDiff && (p.Option.WatchFile != "" || p.Option.WatchChart != "") {
+		return text.ErrWatchDiffWithSeries
+	}
+	if p.Option.WatchFile != "" && p.Option.WatchFormat == "" {
+		switch strings.ToLower(filepath.Ext(p.Option.WatchFile)) {
+		case ".json", ".jsonl", ".ndjson":
+			p.Option.WatchFormat = "jsonl"
+		default:
+			p.Option.WatchFormat = "csv"
 		}
-		p.Option.Watch = d
+	}
+	return nil
+}
+
+// Explain is a Query View meta command (\explain). Executes EXPLAIN for the
+// active query on the open database connection, using the driver's EXPLAIN
+// form, and displays the plan as an indented tree with the most expensive
+// nodes highlighted. When analyzing, the query is executed and the actual
+// timings are included.
+//
+// Descs:
+//
+//	explain	[analyze]	show the query plan of the query as a tree, optionally executing it
+func Explain(p *Params) error {
+	p.Option.Exec = ExecExplain
+	switch v, err := p.Next(true); {
+	case err != nil:
+		return err
+	case strings.EqualFold(v, "analyze"):
+		p.Option.ExplainAnalyze = true
+	case v != "":
+		return fmt.Errorf(text.InvalidOption, v)
 	}
 	return nil
 }
@@ -422,6 +648,69 @@ func Edit(p *Params) error {
 	return nil
 }
 
+// EditDefinition is a Query Buffer meta command (\ef, \ev). Opens a
+// function's or view's definition for editing in an external application,
+// placing the result in the query buffer. The definition is loaded as the
+// statements replacing the existing function or view, and is executed with \g
+// or a terminating semicolon.
+//
+// Descs:
+//
+//	ef	[FUNCNAME [LINE]]	edit function definition with external editor
+//	ev	[VIEWNAME [LINE]]	edit view definition with external editor
+func EditDefinition(p *Params) error {
+	params, err := p.All(true)
+	if err != nil {
+		return err
+	}
+	var line string
+	if n := len(params); n > 1 && isNumber(params[n-1]) {
+		line, params = params[n-1], params[:n-1]
+	}
+	typ, tpl := "function", drivers.FunctionTemplate
+	if p.Name == "ev" {
+		typ, tpl = "view", drivers.ViewTemplate
+	}
+	def := tpl(p.Handler.URL())
+	if name := strings.Join(params, " "); name != "" {
+		r, err := p.Handler.MetadataReader(context.Background())
+		if err != nil {
+			return err
+		}
+		switch def, err = metadata.GetReplacementDefinition(r, typ, name); {
+		case err == text.ErrNotSupported:
+			return fmt.Errorf(text.NotSupportedByDriver, `\`+p.Name, p.Handler.URL().Driver)
+		case err != nil:
+			return err
+		}
+	}
+	out, err := env.EditFile(p.Handler.User(), "", line, []byte(def))
+	if err != nil {
+		return err
+	}
+	// save edited buffer to history
+	p.Handler.IO().Save(string(out))
+	// load the definition into the query buffer without executing it, as it
+	// may contain multiple statements
+	buf := p.Handler.Buf()
+	buf.Reset(nil)
+	buf.Append([]rune(strings.TrimRightFunc(string(out), unicode.IsSpace)), nil)
+	return nil
+}
+
+// isNumber returns true when s is made up of only digits.
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || '9' < c {
+			return false
+		}
+	}
+	return true
+}
+
 // Print is a Query Buffer meta command (\p, \print, \raw, \exec). Writes the
 // query buffer to the output.
 //
@@ -481,6 +770,65 @@ func Write(p *Params) error {
 	return os.WriteFile(name, []byte(strings.TrimSuffix(s, "\n")+"\n"), 0o644)
 }
 
+// History is a Query Buffer meta command (\s). Writes the statement history to
+// the output or a file, optionally only the statements matching a pattern.
+//
+// Descs:
+//
+//	s[+]	[PATTERN] [FILE]	display or save history matching pattern to file, with + show execution time and connection
+func History(p *Params) error {
+	pattern, err := p.Next(true)
+	if err != nil {
+		return err
+	}
+	file, err := p.Next(true)
+	if err != nil {
+		return err
+	}
+	entries, err := p.Handler.History()
+	if err != nil {
+		return err
+	}
+	if pattern != "" {
+		if entries, err = history.Search(entries, pattern); err != nil {
+			return err
+		}
+	}
+	verbose := p.Name == "s+"
+	if file == "" {
+		return writeHistory(p.Handler.IO().Stdout(), entries, verbose)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := writeHistory(f, entries, verbose); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	p.Handler.Print(text.HistoryWritten, file)
+	return nil
+}
+
+// writeHistory writes the history entries to w, preceding each entry with a
+// comment containing its execution time and connection when verbose.
+func writeHistory(w io.Writer, entries []history.Entry, verbose bool) error {
+	for _, e := range entries {
+		if verbose && !e.Time.IsZero() {
+			if _, err := fmt.Fprintf(w, "-- %s %s\n", e.Time.Format(time.DateTime), e.Conn); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w, e.Stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Reset is a Query Buffer meta command (\r, \reset). Clears (resets) the query
 // buffer.
 //
@@ -562,13 +910,25 @@ func Out(p *Params) error {
 	return nil
 }
 
-// Copy is a Input/Output meta command (\copy). Copies data between databases.
+// Copy is a Input/Output meta command (\copy). Copies data between databases,
+// or between a table or query on the current database connection and a
+// local file.
 //
 // Descs:
 //
 //	copy	SRC DST QUERY TABLE	copy results of query from source database into table on destination database
 //	copy	SRC DST QUERY TABLE(A,...)	copy results of query from source database into table's columns on destination database
+//	copy	TABLE FROM FILE [OPTS]	copy data from local file (csv or text) into table on current database
+//	copy	TABLE|(QUERY) TO FILE [OPTS]	copy table or results of query on current database to local file
 func Copy(p *Params) error {
+	raw := p.Raw()
+	switch cf, ok, err := parseCopyFile(raw); {
+	case err != nil:
+		return err
+	case ok:
+		return copyLocal(p, cf)
+	}
+	p.Params = stmt.NewParams(raw)
 	srcstr, err := p.Next(true)
 	if err != nil {
 		return err
@@ -621,6 +981,47 @@ func Copy(p *Params) error {
 	return nil
 }
 
+// copyLocal copies data between a table or query on the current database
+// connection and a local file.
+func copyLocal(p *Params, cf copyFile) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	var n int64
+	switch {
+	case cf.from:
+		r := io.Reader(os.Stdin)
+		if cf.path != "" {
+			_, f, err := env.OpenFile(p.Handler.User(), cf.path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		var err error
+		if n, err = p.Handler.CopyFrom(ctx, r, cf.target, cf.opts); err != nil {
+			return err
+		}
+	case cf.path == "":
+		_, err := p.Handler.CopyTo(ctx, p.Handler.GetOutput(), cf.query(), cf.opts)
+		return err
+	default:
+		f, err := os.OpenFile(cf.path, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if n, err = p.Handler.CopyTo(ctx, f, cf.query(), cf.opts); err != nil {
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	p.Handler.Print("COPY %d", n)
+	return nil
+}
+
 // Include is a Control/Conditional meta command (\i, \include and variants).
 // Includes (runs) the specified file in the current execution environment.
 //
@@ -885,14 +1286,19 @@ func Prompt(p *Params) error {
 //
 //	d[S+]	[NAME]	list tables, views, and sequences or describe table, view, sequence, or index
 //	da[S+]	[PATTERN]	list aggregates
+//	dconfig[+]	[PATTERN]	list configuration parameters
 //	df[S+]	[PATTERN]	list functions
+//	dg[S+]	[PATTERN]	list roles
 //	di[S+]	[PATTERN]	list indexes
 //	dm[S+]	[PATTERN]	list materialized views
 //	dn[S+]	[PATTERN]	list schemas
 //	dp[S]	[PATTERN]	list table, view, and sequence access privileges
 //	ds[S+]	[PATTERN]	list sequences
 //	dt[S+]	[PATTERN]	list tables
+//	dT[S+]	[PATTERN]	list data types
+//	du[S+]	[PATTERN]	list roles
 //	dv[S+]	[PATTERN]	list views
+//	dx[+]	[PATTERN]	list extensions, with + their objects (PostgreSQL only)
 //	l[+]	list databases
 func Describe(p *Params) error {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
@@ -926,6 +1332,14 @@ func Describe(p *Params) error {
 		return m.ListAllDbs(p.Handler.URL(), pattern, verbose)
 	case "dp":
 		return m.ListPrivilegeSummaries(p.Handler.URL(), pattern, showSystem)
+	case "dT":
+		return m.ListTypes(p.Handler.URL(), pattern, verbose, showSystem)
+	case "du", "dg":
+		return m.ListRoles(p.Handler.URL(), pattern, verbose, showSystem)
+	case "dx":
+		return m.ListExtensions(p.Handler.URL(), pattern, verbose)
+	case "dconfig":
+		return m.ListSettings(p.Handler.URL(), pattern, verbose)
 	}
 	return nil
 }
@@ -935,7 +1349,7 @@ func Describe(p *Params) error {
 //
 // Descs:
 //
-//	ss[+]	[TABLE|QUERY] [k]	show stats for a table or a query
+//	ss[+]	[TABLE|QUERY] [k]	show stats for a table or a query, with sample=N rows or P% of rows, or profile=on
 func Stats(p *Params) error {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
@@ -945,7 +1359,7 @@ func Stats(p *Params) error {
 	}
 	verbose := strings.ContainsRune(p.Name, '+')
 	name := strings.TrimRight(p.Name, "+")
-	pattern, err := p.Next(true)
+	params, err := p.All(true)
 	if err != nil {
 		return err
 	}
@@ -956,17 +1370,273 @@ func Stats(p *Params) error {
 	if name == "ss" {
 		name = "sswnulhmkf"
 	}
-	val, ok, err := p.NextOK(true)
-	switch {
-	case err != nil:
-		return err
-	case ok:
+	var pattern, sample string
+	var profile bool
+	var args []string
+	for _, param := range params {
+		if s, ok := strings.CutPrefix(param, "sample="); ok {
+			sample = s
+			continue
+		}
+		if s, ok := strings.CutPrefix(param, "profile="); ok {
+			b, err := env.ParseBool(s, "profile")
+			if err != nil {
+				return err
+			}
+			profile = b == "on"
+			continue
+		}
+		args = append(args, param)
+	}
+	switch len(args) {
+	case 0:
+	case 2:
 		verbose = true
-		if k, err = strconv.Atoi(val); err != nil {
+		if k, err = strconv.Atoi(args[1]); err != nil {
+			return err
+		}
+		fallthrough
+	case 1:
+		pattern = args[0]
+	default:
+		return fmt.Errorf(text.InvalidOption, args[2])
+	}
+	return m.ShowStats(p.Handler.URL(), name, pattern, verbose, k, sample, profile)
+}
+
+// ShowDefinition is a Informational meta command (\sf, \sv). Queries the open
+// database connection for the definition of a function or view and writes it
+// to the output.
+//
+// Descs:
+//
+//	sf[+]	FUNCNAME	show a function's definition
+//	sv[+]	VIEWNAME	show a view's definition
+func ShowDefinition(p *Params) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	m, err := p.Handler.MetadataWriter(ctx)
+	if err != nil {
+		return err
+	}
+	verbose := strings.ContainsRune(p.Name, '+')
+	name := strings.TrimRight(p.Name, "+")
+	// function argument types may contain spaces, ie: \sf f(integer, text)
+	v, err := p.All(true)
+	if err != nil {
+		return err
+	}
+	return m.ShowDefinition(p.Handler.URL(), name, strings.Join(v, " "), verbose)
+}
+
+// DumpSchema is a Informational meta command (\dump). Queries the open database
+// connection for the schema of the tables matching the pattern, and writes
+// their CREATE statements, along with their constraints, indexes, and
+// triggers, to the output.
+//
+// Descs:
+//
+//	dump	[dialect=NAME] [PATTERN]	write CREATE statements of schemas, sequences, and tables with their constraints, indexes, and triggers
+func DumpSchema(p *Params) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	m, err := p.Handler.MetadataWriter(ctx)
+	if err != nil {
+		return err
+	}
+	params, err := p.All(true)
+	if err != nil {
+		return err
+	}
+	var dialect, pattern string
+	for _, param := range params {
+		switch name, value, ok := strings.Cut(param, "="); {
+		case ok && name == "dialect":
+			dialect = value
+		case ok:
+			return fmt.Errorf(text.InvalidOption, param)
+		case pattern != "":
+			return fmt.Errorf(text.InvalidOption, param)
+		default:
+			pattern = param
+		}
+	}
+	return m.Dump(p.Handler.URL(), pattern, dialect)
+}
+
+// SchemaDiff is a Informational meta command (\schemadiff). Compares the
+// schemas of the tables matching the pattern in two database connections, and
+// writes the differences to the output.
+//
+// Descs:
+//
+//	schemadiff[+]	SRC DST [PATTERN]	compare schemas of two connections, with + write ALTER statements updating DST
+func SchemaDiff(p *Params) error {
+	alter := strings.ContainsRune(p.Name, '+')
+	srcstr, err := p.Next(true)
+	if err != nil {
+		return err
+	}
+	deststr, err := p.Next(true)
+	if err != nil {
+		return err
+	}
+	if srcstr == "" || deststr == "" {
+		return fmt.Errorf(`\%s: %w`, strings.TrimRight(p.Name, "+"), text.ErrMissingRequiredArgument)
+	}
+	pattern, err := p.Next(true)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	src, srcDb, err := openConn(ctx, p, srcstr)
+	if err != nil {
+		return err
+	}
+	defer srcDb.Close()
+	dest, destDb, err := openConn(ctx, p, deststr)
+	if err != nil {
+		return err
+	}
+	defer destDb.Close()
+	stdout := p.Handler.IO().Stdout()
+	srcReader, err := drivers.NewMetadataReader(ctx, src, srcDb, stdout)
+	if err != nil {
+		return err
+	}
+	destReader, err := drivers.NewMetadataReader(ctx, dest, destDb, stdout)
+	if err != nil {
+		return err
+	}
+	return metadata.SchemaDiff(stdout, src, srcReader, dest, destReader, pattern, alter)
+}
+
+// openConn opens a database connection for the named connection (see \cset)
+// or URL.
+func openConn(ctx context.Context, p *Params, name string) (*dburl.URL, *sql.DB, error) {
+	u, err := p.Handler.ResolveURL(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := drivers.Open(ctx, u, p.Handler.IO().Stdout, p.Handler.IO().Stderr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, db, nil
+}
+
+// Erd is a Informational meta command (\erd). Queries the open database
+// connection for the tables matching the pattern and their foreign keys, and
+// writes an entity-relationship diagram to the file or output, or renders it
+// inline when terminal graphics are available.
+//
+// Descs:
+//
+//	erd	[PATTERN] [FILE]	write entity-relationship diagram, with format=mermaid, dot, plantuml, or svg
+func Erd(p *Params) error {
+	params, err := p.All(true)
+	if err != nil {
+		return err
+	}
+	var format string
+	var args []string
+	for _, param := range params {
+		switch name, value, ok := strings.Cut(param, "="); {
+		case ok && name == "format":
+			format = value
+		case ok, len(args) == 2:
+			return fmt.Errorf(text.InvalidOption, param)
+		default:
+			args = append(args, param)
+		}
+	}
+	var pattern, file string
+	if len(args) > 0 {
+		pattern = args[0]
+	}
+	if len(args) > 1 {
+		file = args[1]
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	m, err := p.Handler.MetadataWriter(ctx)
+	if err != nil {
+		return err
+	}
+	u, stdout := p.Handler.URL(), p.Handler.IO().Stdout()
+	switch typ := env.TermGraphics(); {
+	case file != "":
+		if format == "" {
+			if format, _ = metadata.ERDFormat(filepath.Ext(file)); format == "" {
+				format = "mermaid"
+			}
+		}
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		if err := m.ERD(u, f, pattern, format); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	case format == "" && typ.Available():
+		buf := new(bytes.Buffer)
+		if err := m.ERD(u, buf, pattern, "svg"); err != nil {
+			return err
+		}
+		if !bytes.HasPrefix(buf.Bytes(), []byte("<svg")) {
+			_, err := stdout.Write(buf.Bytes())
+			return err
+		}
+		img, err := resvg.Render(buf.Bytes(), resvg.WithBackground(color.White))
+		if err != nil {
+			return err
+		}
+		return typ.Encode(stdout, img)
+	}
+	return m.ERD(u, stdout, pattern, format)
+}
+
+// DataDiff is a Informational meta command (\datadiff). Compares the rows of
+// a table or query on two database connections by the key columns, and writes
+// the missing, extra, and changed rows to the output.
+//
+// Descs:
+//
+//	datadiff	SRC DST Q1 Q2 KEYS	compare rows of table or query Q1 on SRC and Q2 on DST by comma-separated KEYS
+func DataDiff(p *Params) error {
+	var v []string
+	for range 5 {
+		s, err := p.Next(true)
+		if err != nil {
 			return err
 		}
+		if s == "" {
+			return fmt.Errorf(`\%s: %w`, p.Name, text.ErrMissingRequiredArgument)
+		}
+		v = append(v, s)
+	}
+	var keys []string
+	for _, k := range strings.Split(v[4], ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	src, srcDb, err := openConn(ctx, p, v[0])
+	if err != nil {
+		return err
+	}
+	defer srcDb.Close()
+	dest, destDb, err := openConn(ctx, p, v[1])
+	if err != nil {
+		return err
 	}
-	return m.ShowStats(p.Handler.URL(), name, pattern, verbose, k)
+	defer destDb.Close()
+	return drivers.DataDiff(ctx, p.Handler.IO().Stdout(), src, srcDb, dest, destDb, v[2], v[3], keys)
 }
 
 // Conditional is a Control/Conditional meta command (\if, \elif, \else,
@@ -981,10 +1651,21 @@ func Stats(p *Params) error {
 //	endif	end conditional block
 func Conditional(p *Params) error {
 	switch p.Name {
-	case "if":
-	case "elif":
+	case "if", "elif":
+		f := p.Handler.If
+		if p.Name == "elif" {
+			f = p.Handler.ElseIf
+		}
+		err := f(func() (bool, error) {
+			return evalCond(p)
+		})
+		// discard unevaluated expression
+		_ = p.Raw()
+		return err
 	case "else":
+		return p.Handler.Else()
 	case "endif":
+		return p.Handler.EndIf()
 	}
 	return nil
 }
@@ -0,0 +1,245 @@
+package metacmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/text"
+)
+
+// copyFileRE matches the \copy forms that copy between a table or query and a
+// file:
+//
+//	\copy table [(column, ...)] FROM {'filename' | stdin | pstdin} [[WITH] (option, ...)]
+//	\copy {table [(column, ...)] | (query)} TO {'filename' | stdout | pstdout} [[WITH] (option, ...)]
+var copyFileRE = regexp.MustCompile(`(?is)^\s*(\(.*\)|[^\s(]+(?:\s*\([^)]*\))?)\s+(from|to)\s+(.*?)\s*$`)
+
+// copyFile holds the parsed parameters of a \copy to or from a file.
+type copyFile struct {
+	// target is the table (with optional column list) or parenthesized query.
+	target string
+	// from indicates copying from the file into the table.
+	from bool
+	// path is the file path, or empty when using standard input/output.
+	path string
+	// opts are the copy options.
+	opts drivers.CopyOptions
+}
+
+// query returns the query for copying the target to a file.
+func (cf copyFile) query() string {
+	if strings.HasPrefix(cf.target, "(") {
+		return strings.TrimSpace(cf.target[1 : len(cf.target)-1])
+	}
+	table, cols := cf.target, "*"
+	if i := strings.IndexRune(table, '('); i != -1 {
+		table, cols = strings.TrimSpace(table[:i]), strings.TrimSuffix(table[i+1:], ")")
+	}
+	return "SELECT " + cols + " FROM " + table
+}
+
+// parseCopyFile parses the \copy parameters when they match copyFileRE.
+func parseCopyFile(s string) (copyFile, bool, error) {
+	m := copyFileRE.FindStringSubmatch(s)
+	if m == nil {
+		return copyFile{}, false, nil
+	}
+	cf := copyFile{
+		target: strings.TrimSpace(m[1]),
+		from:   strings.EqualFold(m[2], "from"),
+	}
+	if cf.from && strings.HasPrefix(cf.target, "(") {
+		return cf, true, text.ErrCopyFromQuery
+	}
+	toks := copyLex(m[3])
+	if len(toks) == 0 {
+		return cf, true, text.ErrMissingRequiredArgument
+	}
+	switch t := toks[0]; {
+	case t.quoted:
+		cf.path = t.s
+	case strings.EqualFold(t.s, "program"):
+		return cf, true, fmt.Errorf("%s: %w", t.s, text.ErrNotSupported)
+	case strings.EqualFold(t.s, "stdin"), strings.EqualFold(t.s, "pstdin"):
+		if !cf.from {
+			return cf, true, fmt.Errorf(text.UnknownCopyOption, t.s)
+		}
+	case strings.EqualFold(t.s, "stdout"), strings.EqualFold(t.s, "pstdout"):
+		if cf.from {
+			return cf, true, fmt.Errorf(text.UnknownCopyOption, t.s)
+		}
+	default:
+		cf.path = t.s
+	}
+	var err error
+	cf.opts, err = parseCopyOptions(toks[1:])
+	return cf, true, err
+}
+
+// ParseCopyOptions parses the options of a COPY statement or \copy meta
+// command (ie, the "WITH (format csv, header)" portion).
+func ParseCopyOptions(s string) (drivers.CopyOptions, error) {
+	return parseCopyOptions(copyLex(s))
+}
+
+// parseCopyOptions parses copy options, either in the parenthesized form
+// (ie, WITH (format csv, header)) or the older unparenthesized form (ie, WITH
+// csv header).
+func parseCopyOptions(toks []copyToken) (drivers.CopyOptions, error) {
+	if len(toks) != 0 && !toks[0].quoted && strings.EqualFold(toks[0].s, "with") {
+		toks = toks[1:]
+	}
+	var names, values []string
+	switch {
+	case len(toks) != 0 && toks[0].is("("):
+		if !toks[len(toks)-1].is(")") {
+			return drivers.CopyOptions{}, text.ErrInvalidFormatOption
+		}
+		for _, opt := range splitCopyTokens(toks[1 : len(toks)-1]) {
+			if len(opt) == 0 || len(opt) > 2 {
+				return drivers.CopyOptions{}, text.ErrInvalidFormatOption
+			}
+			names = append(names, strings.ToLower(opt[0].s))
+			var v string
+			if len(opt) == 2 {
+				v = opt[1].s
+			}
+			values = append(values, v)
+		}
+	default:
+		for i := 0; i < len(toks); i++ {
+			name := strings.ToLower(toks[i].s)
+			var v string
+			switch name {
+			case "csv", "binary", "text":
+				name, v = "format", name
+			case "header":
+			case "delimiter", "null", "quote":
+				if i+1 < len(toks) && strings.EqualFold(toks[i+1].s, "as") {
+					i++
+				}
+				if i+1 >= len(toks) {
+					return drivers.CopyOptions{}, text.ErrMissingRequiredArgument
+				}
+				i++
+				v = toks[i].s
+			default:
+				return drivers.CopyOptions{}, fmt.Errorf(text.UnknownCopyOption, toks[i].s)
+			}
+			names, values = append(names, name), append(values, v)
+		}
+	}
+	// determine format first, as it determines defaults
+	var format string
+	for i, name := range names {
+		if name == "format" {
+			format = values[i]
+		}
+	}
+	opts, err := drivers.NewCopyOptions(format)
+	if err != nil {
+		return opts, err
+	}
+	for i, name := range names {
+		switch v := values[i]; name {
+		case "format":
+		case "delimiter":
+			if opts.Delimiter, err = copyChar(v, text.ErrCopyInvalidDelimiter); err != nil {
+				return opts, err
+			}
+		case "quote":
+			if opts.Quote, err = copyChar(v, text.ErrCopyInvalidQuote); err != nil {
+				return opts, err
+			}
+		case "null":
+			opts.Null = v
+		case "header":
+			switch strings.ToLower(v) {
+			case "", "match":
+				opts.Header = true
+			default:
+				b, err := env.ParseBool(v, "header")
+				if err != nil {
+					return opts, err
+				}
+				opts.Header = b == "on"
+			}
+		default:
+			return opts, fmt.Errorf(text.UnknownCopyOption, name)
+		}
+	}
+	return opts, nil
+}
+
+// copyChar returns the single character in s, or err.
+func copyChar(s string, err error) (rune, error) {
+	if utf8.RuneCountInString(s) != 1 {
+		return 0, err
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return r, nil
+}
+
+// copyToken is a \copy parameter token.
+type copyToken struct {
+	s      string
+	quoted bool
+}
+
+// is returns true when the token is the unquoted string s.
+func (t copyToken) is(s string) bool {
+	return !t.quoted && t.s == s
+}
+
+// copyLex splits s into tokens, separating parentheses and commas, and
+// unquoting single and double quoted strings.
+func copyLex(s string) []copyToken {
+	var toks []copyToken
+	r := []rune(s)
+	for i := 0; i < len(r); i++ {
+		switch c := r[i]; {
+		case unicode.IsSpace(c):
+		case c == '(' || c == ')' || c == ',':
+			toks = append(toks, copyToken{s: string(c)})
+		case c == '\'' || c == '"':
+			var b strings.Builder
+			for i++; i < len(r); i++ {
+				if r[i] == c {
+					if i+1 < len(r) && r[i+1] == c {
+						i++
+					} else {
+						break
+					}
+				}
+				b.WriteRune(r[i])
+			}
+			toks = append(toks, copyToken{s: b.String(), quoted: true})
+		default:
+			start := i
+			for i+1 < len(r) && !unicode.IsSpace(r[i+1]) && !strings.ContainsRune("(),", r[i+1]) {
+				i++
+			}
+			toks = append(toks, copyToken{s: string(r[start : i+1])})
+		}
+	}
+	return toks
+}
+
+// splitCopyTokens splits toks on commas.
+func splitCopyTokens(toks []copyToken) [][]copyToken {
+	var v [][]copyToken
+	var opt []copyToken
+	for _, t := range toks {
+		if t.is(",") {
+			v, opt = append(v, opt), nil
+			continue
+		}
+		opt = append(opt, t)
+	}
+	return append(v, opt)
+}
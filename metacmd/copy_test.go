@@ -0,0 +1,63 @@
+package metacmd
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/xo/usql/drivers"
+)
+
+func TestParseCopyFile(t *testing.T) {
+	tests := []struct {
+		s     string
+		ok    bool
+		err   bool
+		query string
+		exp   copyFile
+	}{
+		{`pg://localhost sq:file.db 'select 1' t`, false, false, "", copyFile{}},
+		{
+			`t FROM 'file.csv' WITH (format csv, header)`, true, false, "SELECT * FROM t",
+			copyFile{target: "t", from: true, path: "file.csv", opts: drivers.CopyOptions{Format: "csv", Delimiter: ',', Quote: '"', Header: true}},
+		},
+		{
+			`t (a, b) from file.txt`, true, false, "SELECT a, b FROM t",
+			copyFile{target: "t (a, b)", from: true, path: "file.txt", opts: drivers.CopyOptions{Format: "text", Delimiter: '\t', Null: `\N`}},
+		},
+		{
+			`(select a, b from t) TO 'out file.csv' with csv header delimiter as ';' null 'NULL'`, true, false, "select a, b from t",
+			copyFile{target: "(select a, b from t)", path: "out file.csv", opts: drivers.CopyOptions{Format: "csv", Delimiter: ';', Quote: '"', Null: "NULL", Header: true}},
+		},
+		{
+			`t to stdout (format text, delimiter '|', header false)`, true, false, "SELECT * FROM t",
+			copyFile{target: "t", opts: drivers.CopyOptions{Format: "text", Delimiter: '|', Null: `\N`}},
+		},
+		{`(select 1) from 'file.csv'`, true, true, "", copyFile{}},
+		{`t from stdout`, true, true, "", copyFile{}},
+		{`t from 'file' (format binary)`, true, true, "", copyFile{}},
+		{`t from 'file' (delimiter '||')`, true, true, "", copyFile{}},
+		{`t from 'file' (format csv`, true, true, "", copyFile{}},
+		{`t from 'file' (encoding 'utf8')`, true, true, "", copyFile{}},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			cf, ok, err := parseCopyFile(test.s)
+			switch {
+			case ok != test.ok:
+				t.Fatalf("expected ok %t, got: %t", test.ok, ok)
+			case test.err && err == nil:
+				t.Fatalf("expected error, got: %+v", cf)
+			case !test.err && err != nil:
+				t.Fatalf("expected no error, got: %v", err)
+			case !ok || test.err:
+				return
+			}
+			if cf != test.exp {
+				t.Errorf("expected %+v, got: %+v", test.exp, cf)
+			}
+			if q := cf.query(); q != test.query {
+				t.Errorf("expected query %q, got: %q", test.query, q)
+			}
+		})
+	}
+}
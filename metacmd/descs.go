@@ -36,6 +36,7 @@ func init() {
 			{Quit, `quit`, ``, `alias for \q`, true, false},
 			{Copyright, `copyright`, ``, `show usage and distribution terms for ` + text.CommandName + ``, false, false},
 			{Drivers, `drivers`, ``, `show database drivers available to ` + text.CommandName + ``, false, false},
+			{ErrVerbose, `errverbose`, ``, `show most recent error message at maximum verbosity`, false, false},
 		},
 		// Help
 		{
@@ -61,10 +62,14 @@ func init() {
 			{Execute, `G`, `[(OPTIONS)] [FILE]`, `as \g, but forces vertical output mode`, false, false},
 			{Execute, `ego`, ``, `alias for \G`, true, false},
 			{Execute, `gx`, `[(OPTIONS)] [FILE]`, `as \g, but forces expanded output mode`, false, false},
+			{Execute, `gdesc`, ``, `describe result of query, without executing it`, false, false},
 			{Execute, `gexec`, ``, `execute query and execute each value of the result`, false, false},
 			{Execute, `gset`, `[PREFIX]`, `execute query and store results in ` + text.CommandName + ` variables`, false, false},
 			{Bind, `bind`, `[PARAM]...`, `set query parameters`, false, false},
-			{Timing, `timing`, `[on|off]`, `toggle timing of commands`, false, false},
+			{Parse, `parse`, `[NAME]`, `prepare query buffer as named statement, or list statements`, false, false},
+			{BindNamed, `bind_named`, `NAME [PARAM]...`, `set query parameters for a named prepared statement`, false, false},
+			{Close, `close`, `NAME`, `close a named prepared statement`, false, false},
+			{Timing, `timing`, `[on|off|verbose]`, `toggle timing of commands`, false, false},
 		},
 		// Query View
 		{
@@ -72,18 +77,24 @@ func init() {
 			{Crosstab, `crosstabview`, ``, `alias for \crosstab`, true, false},
 			{Crosstab, `xtab`, ``, `alias for \crosstab`, true, false},
 			{Chart, `chart`, `CHART [(OPTIONS)]`, `execute query and display results as a chart`, false, false},
-			{Watch, `watch`, `[(OPTIONS)] [INTERVAL]`, `execute query every specified interval`, false, false},
+			{Watch, `watch`, `[[i=]SEC] [c=N] [m=MIN]`, `execute query every specified interval`, false, false},
+			{Watch, `watch`, `[w=COL] [d]`, `as \watch, but stop when COL is false, or highlight changes`, false, false},
+			{Watch, `watch`, `[file=F] [chart=TYPE]`, `as \watch, but append timestamped results to file (format=csv|jsonl) or a chart`, false, false},
+			{Explain, `explain`, `[analyze]`, `show the query plan of the query as a tree, optionally executing it`, false, false},
 		},
 		// Query Buffer
 		{
 			{Edit, `e`, `[-raw|-exec] [FILE] [LINE]`, `edit the query buffer, raw (non-interpolated) buffer, the exec buffer, or a file with external editor`, false, false},
 			{Edit, `edit`, ``, `alias for \e`, true, false},
+			{EditDefinition, `ef`, `[FUNCNAME [LINE]]`, `edit function definition with external editor`, false, false},
+			{EditDefinition, `ev`, `[VIEWNAME [LINE]]`, `edit view definition with external editor`, false, false},
 			{Print, `p`, `[-raw|-exec]`, `show the contents of the query buffer, the raw (non-interpolated) buffer or the exec buffer`, false, false},
 			{Print, `print`, ``, `alias for \p`, true, false},
 			{Print, `raw`, ``, `alias for \p`, true, false},
 			{Print, `exec`, ``, `alias for \p`, true, false},
 			{Write, `w`, `[-raw|-exec] FILE`, `write the contents of the query buffer, raw (non-interpolated) buffer, or exec buffer to file`, false, false},
 			{Write, `write`, ``, `alias for \w`, true, false},
+			{History, `s[+]`, `[PATTERN] [FILE]`, `display or save history matching pattern to file, with + show execution time and connection`, false, false},
 			{Reset, `r`, ``, `reset (clear) the query buffer`, false, false},
 			{Reset, `reset`, ``, `alias for \r`, true, false},
 		},
@@ -91,16 +102,27 @@ func init() {
 		{
 			{Describe, `d[S+]`, `[NAME]`, `list tables, views, and sequences or describe table, view, sequence, or index`, false, false},
 			{Describe, `da[S+]`, `[PATTERN]`, `list aggregates`, false, false},
+			{Describe, `dconfig[+]`, `[PATTERN]`, `list configuration parameters`, false, false},
 			{Describe, `df[S+]`, `[PATTERN]`, `list functions`, false, false},
+			{Describe, `dg[S+]`, `[PATTERN]`, `list roles`, false, false},
 			{Describe, `di[S+]`, `[PATTERN]`, `list indexes`, false, false},
 			{Describe, `dm[S+]`, `[PATTERN]`, `list materialized views`, false, false},
 			{Describe, `dn[S+]`, `[PATTERN]`, `list schemas`, false, false},
 			{Describe, `dp[S]`, `[PATTERN]`, `list table, view, and sequence access privileges`, false, false},
 			{Describe, `ds[S+]`, `[PATTERN]`, `list sequences`, false, false},
 			{Describe, `dt[S+]`, `[PATTERN]`, `list tables`, false, false},
+			{Describe, `dT[S+]`, `[PATTERN]`, `list data types`, false, false},
+			{Describe, `du[S+]`, `[PATTERN]`, `list roles`, false, false},
 			{Describe, `dv[S+]`, `[PATTERN]`, `list views`, false, false},
+			{Describe, `dx[+]`, `[PATTERN]`, `list extensions, with + their objects (PostgreSQL only)`, false, false},
 			{Describe, `l[+]`, ``, `list databases`, false, false},
-			{Stats, `ss[+]`, `[TABLE|QUERY] [k]`, `show stats for a table or a query`, false, false},
+			{Stats, `ss[+]`, `[TABLE|QUERY] [k]`, `show stats for a table or a query, with sample=N rows or P% of rows, or profile=on`, false, false},
+			{ShowDefinition, `sf[+]`, `FUNCNAME`, `show a function's definition`, false, false},
+			{ShowDefinition, `sv[+]`, `VIEWNAME`, `show a view's definition`, false, false},
+			{DumpSchema, `dump`, `[dialect=NAME] [PATTERN]`, `write CREATE statements of schemas, sequences, and tables with their constraints, indexes, and triggers`, false, false},
+			{SchemaDiff, `schemadiff[+]`, `SRC DST [PATTERN]`, `compare schemas of two connections, with + write ALTER statements updating DST`, false, false},
+			{Erd, `erd`, `[PATTERN] [FILE]`, `write entity-relationship diagram, with format=mermaid, dot, plantuml, or svg`, false, false},
+			{DataDiff, `datadiff`, `SRC DST Q1 Q2 KEYS`, `compare rows of table or query Q1 on SRC and Q2 on DST by comma-separated KEYS`, false, false},
 		},
 		// Variables
 		{
@@ -127,6 +149,8 @@ func init() {
 			{Out, `out`, ``, `alias for \o`, true, false},
 			{Copy, `copy`, `SRC DST QUERY TABLE`, `copy results of query from source database into table on destination database`, false, false},
 			{Copy, `copy`, `SRC DST QUERY TABLE(A,...)`, `copy results of query from source database into table's columns on destination database`, false, false},
+			{Copy, `copy`, `TABLE FROM FILE [OPTS]`, `copy data from local file (csv or text) into table on current database`, false, false},
+			{Copy, `copy`, `TABLE|(QUERY) TO FILE [OPTS]`, `copy table or results of query on current database to local file`, false, false},
 		},
 		// Control/Conditional
 		{
@@ -0,0 +1,283 @@
+package metacmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/stmt"
+	"github.com/xo/usql/text"
+)
+
+// exprToken is a conditional expression token.
+type exprToken struct {
+	// s is the token value.
+	s string
+	// lit indicates the token is a literal value (ie, a quoted string), and
+	// is never treated as an operator.
+	lit bool
+}
+
+// exprOps are the conditional expression operators, ordered so that longer
+// operators are matched first.
+var exprOps = []string{
+	"<>", "!=", "<=", ">=", "==", "&&", "||",
+	"(", ")", "=", "<", ">", "!",
+}
+
+// evalCond evaluates the remaining command parameters as a conditional
+// expression.
+//
+// Parameters are interpolated and backticks are executed, as with any other
+// command parameter. Supports the comparison operators =, ==, <>, !=, <, <=,
+// >, and >= (compared numerically when both sides are numbers, otherwise as
+// strings), the boolean operators NOT (!), AND (&&), and OR (||), and
+// parentheses for grouping. A bare value is interpreted as a boolean.
+//
+// Defined variables can be tested with :{?NAME}.
+func evalCond(p *Params) (bool, error) {
+	toks, err := readExpr(p.Params, env.Untick(p.Handler.User(), env.Vars(), true))
+	switch {
+	case err != nil:
+		return false, err
+	case len(toks) == 0:
+		return false, text.ErrMissingRequiredArgument
+	}
+	return evalExpr(p.Name, toks)
+}
+
+// exprMark delimits the index of a substituted value in a parameter.
+const exprMark = "\x00"
+
+// exprMarkRE matches a marked substituted value.
+var exprMarkRE = regexp.MustCompile(exprMark + `([0-9]+)` + exprMark)
+
+// readExpr reads the remaining command parameters as conditional expression
+// tokens.
+//
+// Only the bare text of the parameters is split into operators and values.
+// Quoted strings, backticks, and interpolated variables are replaced by
+// markers before splitting, and are restored as literal values.
+func readExpr(params *stmt.Params, unquote func(string, bool) (string, bool, error)) ([]exprToken, error) {
+	var vals []string
+	var toks []exprToken
+	for {
+		s, ok, err := params.Next(func(s string, isvar bool) (string, bool, error) {
+			z, ok, err := unquote(s, isvar)
+			if err != nil || !ok {
+				return z, ok, err
+			}
+			vals = append(vals, z)
+			return exprMark + strconv.Itoa(len(vals)-1) + exprMark, true, nil
+		})
+		switch {
+		case err != nil:
+			return nil, err
+		case !ok:
+			return toks, nil
+		}
+		for _, tok := range splitExpr(s) {
+			if strings.Contains(tok.s, exprMark) {
+				tok.s = exprMarkRE.ReplaceAllStringFunc(tok.s, func(m string) string {
+					i, _ := strconv.Atoi(strings.Trim(m, exprMark))
+					return vals[i]
+				})
+				tok.lit = true
+			}
+			toks = append(toks, tok)
+		}
+	}
+}
+
+// splitExpr splits s into operators and values.
+func splitExpr(s string) []exprToken {
+	var toks []exprToken
+	start := 0
+	for i := 0; i < len(s); {
+		op := exprOp(s[i:])
+		if op == "" {
+			i++
+			continue
+		}
+		if start < i {
+			toks = append(toks, exprToken{s: s[start:i]})
+		}
+		toks = append(toks, exprToken{s: op})
+		i += len(op)
+		start = i
+	}
+	if start < len(s) {
+		toks = append(toks, exprToken{s: s[start:]})
+	}
+	return toks
+}
+
+// exprOp returns the operator at the start of s.
+func exprOp(s string) string {
+	for _, op := range exprOps {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// evalExpr evaluates the conditional expression toks for the named command.
+func evalExpr(name string, toks []exprToken) (bool, error) {
+	e := &exprParser{name: name, toks: toks}
+	v, err := e.or()
+	switch {
+	case err != nil:
+		return false, err
+	case e.i < len(e.toks):
+		return false, e.unexpected()
+	}
+	return v, nil
+}
+
+// exprParser is a recursive descent parser for conditional expressions.
+type exprParser struct {
+	name string
+	toks []exprToken
+	i    int
+}
+
+// peek returns the next operator or keyword, if any.
+func (e *exprParser) peek() string {
+	if e.i >= len(e.toks) || e.toks[e.i].lit {
+		return ""
+	}
+	return strings.ToUpper(e.toks[e.i].s)
+}
+
+// unexpected returns an unexpected token error for the current token.
+func (e *exprParser) unexpected() error {
+	if e.i >= len(e.toks) {
+		return fmt.Errorf(text.InvalidCondExpr, e.name, text.ErrUnexpectedEndOfExpression)
+	}
+	return fmt.Errorf(text.InvalidCondExpr, e.name, fmt.Errorf(text.UnexpectedTokenInExpr, e.toks[e.i].s))
+}
+
+// or parses and evaluates an OR expression.
+func (e *exprParser) or() (bool, error) {
+	v, err := e.and()
+	if err != nil {
+		return false, err
+	}
+	for op := e.peek(); op == "OR" || op == "||"; op = e.peek() {
+		e.i++
+		w, err := e.and()
+		if err != nil {
+			return false, err
+		}
+		v = v || w
+	}
+	return v, nil
+}
+
+// and parses and evaluates an AND expression.
+func (e *exprParser) and() (bool, error) {
+	v, err := e.not()
+	if err != nil {
+		return false, err
+	}
+	for op := e.peek(); op == "AND" || op == "&&"; op = e.peek() {
+		e.i++
+		w, err := e.not()
+		if err != nil {
+			return false, err
+		}
+		v = v && w
+	}
+	return v, nil
+}
+
+// not parses and evaluates a NOT expression.
+func (e *exprParser) not() (bool, error) {
+	if op := e.peek(); op == "NOT" || op == "!" {
+		e.i++
+		v, err := e.not()
+		return !v, err
+	}
+	return e.cmp()
+}
+
+// cmp parses and evaluates a parenthesized expression, a comparison, or a
+// bare boolean value.
+func (e *exprParser) cmp() (bool, error) {
+	if e.peek() == "(" {
+		e.i++
+		v, err := e.or()
+		if err != nil {
+			return false, err
+		}
+		if e.peek() != ")" {
+			return false, e.unexpected()
+		}
+		e.i++
+		return v, nil
+	}
+	a, err := e.value()
+	if err != nil {
+		return false, err
+	}
+	switch op := e.peek(); op {
+	case "=", "==", "<>", "!=", "<", "<=", ">", ">=":
+		e.i++
+		b, err := e.value()
+		if err != nil {
+			return false, err
+		}
+		return compare(op, a, b), nil
+	}
+	v, err := env.ParseBool(a, `\`+e.name)
+	if err != nil {
+		return false, fmt.Errorf(text.UnrecognizedValueForCond, a, e.name)
+	}
+	return v == "on", nil
+}
+
+// value returns the next value.
+func (e *exprParser) value() (string, error) {
+	if e.i >= len(e.toks) {
+		return "", e.unexpected()
+	}
+	if op := e.peek(); exprOp(op) == op && op != "" {
+		return "", e.unexpected()
+	}
+	s := e.toks[e.i].s
+	e.i++
+	return s, nil
+}
+
+// compare compares a and b using op, numerically when both a and b are
+// numbers, otherwise as strings.
+func compare(op, a, b string) bool {
+	var c int
+	x, xerr := strconv.ParseFloat(a, 64)
+	y, yerr := strconv.ParseFloat(b, 64)
+	switch {
+	case xerr == nil && yerr == nil && x < y:
+		c = -1
+	case xerr == nil && yerr == nil && x > y:
+		c = 1
+	case xerr == nil && yerr == nil:
+	default:
+		c = strings.Compare(a, b)
+	}
+	switch op {
+	case "<>", "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return c == 0
+}
@@ -0,0 +1,124 @@
+package metacmd
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/xo/usql/stmt"
+)
+
+func TestEvalExpr(t *testing.T) {
+	tests := []struct {
+		s   string
+		exp bool
+		err bool
+	}{
+		{`true`, true, false},
+		{`off`, false, false},
+		{`1`, true, false},
+		{`NOT true`, false, false},
+		{`!false`, true, false},
+		{`true AND false`, false, false},
+		{`true && true`, true, false},
+		{`false OR true`, true, false},
+		{`false || false`, false, false},
+		{`NOT false AND false`, false, false},
+		{`NOT (false AND false)`, true, false},
+		{`false AND false OR true`, true, false},
+		{`(false OR true) AND true`, true, false},
+		{`150000 >= 150000`, true, false},
+		{`150000>=90000`, true, false},
+		{`90000 < 150000`, true, false},
+		{`9 < 10`, true, false},
+		{`a < b`, true, false},
+		{`10 = 10.0`, true, false},
+		{`foo = foo`, true, false},
+		{`foo <> bar`, true, false},
+		{`foo != foo`, false, false},
+		{`foo == foo AND 1 <= 2`, true, false},
+		{`TRUE = TRUE`, true, false},
+		{`foo`, false, true},
+		{``, false, true},
+		{`1 =`, false, true},
+		{`= 1`, false, true},
+		{`(true`, false, true},
+		{`true)`, false, true},
+		{`true false`, false, true},
+		{`NOT`, false, true},
+		{`1 < 2 < 3`, false, true},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var toks []exprToken
+			for _, s := range strings.Fields(test.s) {
+				toks = append(toks, splitExpr(s)...)
+			}
+			v, err := evalExpr("if", toks)
+			switch {
+			case test.err && err == nil:
+				t.Fatalf("expected error for %q, got: %t", test.s, v)
+			case !test.err && err != nil:
+				t.Fatalf("expected no error for %q, got: %v", test.s, err)
+			case v != test.exp:
+				t.Errorf("expected %q to be %t, got: %t", test.s, test.exp, v)
+			}
+		})
+	}
+}
+
+func TestEvalExprLiteral(t *testing.T) {
+	toks := []exprToken{{s: "AND", lit: true}, {s: "="}, {s: "AND", lit: true}}
+	v, err := evalExpr("if", toks)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !v {
+		t.Errorf("expected literal comparison to be true")
+	}
+}
+
+func TestReadExpr(t *testing.T) {
+	vars := map[string]string{
+		"lt":  "a<b",
+		"eq":  "x=y",
+		"and": "foo AND bar",
+		"v":   "x",
+	}
+	unquote := func(s string, isvar bool) (string, bool, error) {
+		if isvar {
+			v, ok := vars[s]
+			return v, ok, nil
+		}
+		return s[1 : len(s)-1], true, nil
+	}
+	tests := []struct {
+		s   string
+		exp bool
+	}{
+		{`:v = 'x'`, true},
+		{`:v='x'`, true},
+		{`:lt = 'a<b'`, true},
+		{`:eq = 'x=y'`, true},
+		{`:eq = x`, false},
+		{`:and = 'foo AND bar'`, true},
+		{`:and <> foo`, true},
+		{`NOT :v = y AND :{?eq}`, true},
+		{`:{?missing}`, false},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			toks, err := readExpr(stmt.NewParams(test.s), unquote)
+			if err != nil {
+				t.Fatalf("expected no error for %q, got: %v", test.s, err)
+			}
+			v, err := evalExpr("if", toks)
+			switch {
+			case err != nil:
+				t.Fatalf("expected no error for %q, got: %v", test.s, err)
+			case v != test.exp:
+				t.Errorf("expected %q to be %t, got: %t", test.s, test.exp, v)
+			}
+		})
+	}
+}
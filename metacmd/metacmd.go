@@ -16,6 +16,7 @@ import (
 	"github.com/xo/usql/drivers"
 	"github.com/xo/usql/drivers/metadata"
 	"github.com/xo/usql/env"
+	"github.com/xo/usql/history"
 	"github.com/xo/usql/rline"
 	"github.com/xo/usql/stmt"
 	"github.com/xo/usql/text"
@@ -37,14 +38,29 @@ type Handler interface {
 	LastPrint() string
 	// LastRaw returns the last raw (non-interpolated) query.
 	LastRaw() string
+	// LastError returns the last error returned when executing a query.
+	LastError() error
+	// History returns the statement history.
+	History() ([]history.Entry, error)
 	// Buf returns the current query buffer.
 	Buf() *stmt.Stmt
 	// Reset resets the last and current query buffer.
 	Reset([]rune)
 	// Bind binds query parameters.
 	Bind([]interface{})
+	// Parse prepares the query buffer as a named prepared statement.
+	Parse(context.Context, string) error
+	// BindNamed binds query parameters for a named prepared statement.
+	BindNamed(string, []interface{}) error
+	// Deallocate closes a named prepared statement.
+	Deallocate(string) error
+	// Prepared returns the queries of the named prepared statements.
+	Prepared() map[string]string
 	// Open opens a database connection.
 	Open(context.Context, ...string) error
+	// ResolveURL resolves the URL of a named connection or URL, as when
+	// opening a database connection.
+	ResolveURL(...string) (*dburl.URL, error)
 	// Close closes the current database connection.
 	Close() error
 	// ChangePassword changes the password for a user.
@@ -59,16 +75,36 @@ type Handler interface {
 	Commit() error
 	// Rollback aborts the current transaction.
 	Rollback() error
+	// If starts a conditional block, evaluating the condition only when the
+	// block is reachable.
+	If(func() (bool, error)) error
+	// ElseIf starts an alternative branch in the current conditional block.
+	ElseIf(func() (bool, error)) error
+	// Else starts the final branch in the current conditional block.
+	Else() error
+	// EndIf ends the current conditional block.
+	EndIf() error
+	// CopyFrom copies copy data into a table on the current connection.
+	CopyFrom(context.Context, io.Reader, string, drivers.CopyOptions) (int64, error)
+	// CopyTo copies the results of a query on the current connection as copy
+	// data.
+	CopyTo(context.Context, io.Writer, string, drivers.CopyOptions) (int64, error)
 	// Highlight highlights the statement.
 	Highlight(io.Writer, string) error
 	// GetTiming mode.
 	GetTiming() bool
 	// SetTiming mode.
 	SetTiming(bool)
+	// GetTimingVerbose mode.
+	GetTimingVerbose() bool
+	// SetTimingVerbose mode.
+	SetTimingVerbose(bool)
 	// GetOutput writer.
 	GetOutput() io.Writer
 	// SetOutput writer.
 	SetOutput(io.WriteCloser)
+	// MetadataReader retrieves the metadata reader for the handler.
+	MetadataReader(context.Context) (metadata.Reader, error)
 	// MetadataWriter retrieves the metadata writer for the handler.
 	MetadataWriter(context.Context) (metadata.Writer, error)
 	// Print formats according to a format specifier and writes to handler's standard output.
@@ -193,6 +229,28 @@ type Option struct {
 	Crosstab []string
 	// Watch is the watch duration interval.
 	Watch time.Duration
+	// WatchCount is the number of times to execute the query when watching,
+	// or 0 to execute until canceled.
+	WatchCount int
+	// WatchMinRows stops watching when the query returns fewer rows.
+	WatchMinRows int
+	// WatchWhile is the name of the result column (and variable) that stops
+	// watching when its value is false.
+	WatchWhile string
+	// WatchDiff highlights values that changed since the previous execution
+	// when watching.
+	WatchDiff bool
+	// WatchFile is the file the results of each execution are appended to
+	// when watching.
+	WatchFile string
+	// WatchFormat is the format (csv or jsonl) of the watch file.
+	WatchFormat string
+	// WatchChart is the chart type the results of all executions are
+	// displayed as when watching.
+	WatchChart string
+	// ExplainAnalyze executes the query when explaining it, including the
+	// actual timings in the plan.
+	ExplainAnalyze bool
 }
 
 func (opt *Option) ParseParams(params []string, defaultKey string) error {
@@ -245,6 +303,12 @@ const (
 	ExecChart
 	// ExecWatch indicates repeated execution with a fixed time interval.
 	ExecWatch
+	// ExecDesc indicates describing the result columns of the query, without
+	// executing it (\gdesc).
+	ExecDesc
+	// ExecExplain indicates displaying the query plan of the query
+	// (\explain).
+	ExecExplain
 )
 
 // desc wraps a meta command description.
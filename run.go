@@ -156,6 +156,9 @@ func New(cliargs []string) ContextExecutor {
 	// command / file flags
 	flags.VarP(commandOrFile{args, true}, "command", "c", "run only single command (SQL or internal) and exit")
 	flags.VarP(commandOrFile{args, false}, "file", "f", "execute commands from file and exit")
+	flags.StringVar(&args.Dump, "dump", "", "write CREATE statements of the tables matching `PATTERN` and exit")
+	flags.Lookup("dump").NoOptDefVal = "*"
+	flags.StringVar(&args.DumpDialect, "dump-dialect", "", "SQL `DIALECT` of --dump (postgres, mysql, sqlite, sqlserver, oracle, duckdb, or generic)")
 
 	// general flags
 	flags.BoolVarP(&args.NoPassword, "no-password", "w", false, "never prompt for password")
@@ -232,7 +235,7 @@ func Run(ctx context.Context, args *Args) error {
 	// determine if interactive
 	interactive := isatty.IsTerminal(os.Stdout.Fd()) && isatty.IsTerminal(os.Stdin.Fd())
 	cygwin := isatty.IsCygwinTerminal(os.Stdout.Fd()) && isatty.IsCygwinTerminal(os.Stdin.Fd())
-	forceNonInteractive := len(args.CommandOrFiles) != 0
+	forceNonInteractive := len(args.CommandOrFiles) != 0 || args.Dump != ""
 
 	// enable term graphics
 	if !forceNonInteractive && interactive && !cygwin {
@@ -348,6 +351,9 @@ func Run(ctx context.Context, args *Args) error {
 	if len(args.CommandOrFiles) != 0 {
 		f = runCommandOrFiles(h, args.CommandOrFiles)
 	}
+	if args.Dump != "" {
+		f = runDump(ctx, h, runCommandOrFiles(h, args.CommandOrFiles), args.Dump, args.DumpDialect)
+	}
 	// run
 	if err = f(); err != nil {
 		return err
@@ -363,6 +369,8 @@ func Run(ctx context.Context, args *Args) error {
 type Args struct {
 	DSN               string
 	CommandOrFiles    []CommandOrFile
+	Dump              string
+	DumpDialect       string
 	Out               string
 	ForcePassword     bool
 	NoPassword        bool
@@ -539,6 +547,21 @@ func runCommandOrFiles(h *handler.Handler, commandsOrFiles []CommandOrFile) func
 	}
 }
 
+// runDump writes the CREATE statements of the tables matching the pattern,
+// after processing any supplied commands or files.
+func runDump(ctx context.Context, h *handler.Handler, f func() error, pattern, dialect string) func() error {
+	return func() error {
+		if err := f(); err != nil {
+			return err
+		}
+		m, err := h.MetadataWriter(ctx)
+		if err != nil {
+			return err
+		}
+		return m.Dump(h.URL(), pattern, dialect)
+	}
+}
+
 // sf sets a flag.
 func sf(flags *pflag.FlagSet, v *[]string, name, short, usage, placeholder string, vals ...string) {
 	f := flags.VarPF(vs{v, vals, placeholder}, name, short, usage)
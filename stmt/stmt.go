@@ -3,6 +3,8 @@ package stmt
 
 import (
 	"bytes"
+	"io"
+	"strings"
 	"unicode"
 )
 
@@ -135,6 +137,33 @@ func (b *Stmt) Reset(r []rune) {
 	}
 }
 
+// Truncate truncates the statement buffer to the first n runes, discarding
+// any variables encountered past n and resetting the quote, comment, and
+// ready state. Used to discard statement text read in inactive conditional
+// blocks.
+func (b *Stmt) Truncate(n int) {
+	if n <= 0 {
+		b.Reset(nil)
+		return
+	}
+	if n >= b.Len && !b.ready && b.quote == 0 && !b.multilineComment {
+		return
+	}
+	if n < b.Len {
+		b.Buf, b.Len = b.Buf[:n], n
+	}
+	for i, v := range b.Vars {
+		if v.I >= n {
+			b.Vars = b.Vars[:i]
+			break
+		}
+	}
+	b.quote, b.quoteDollarTag = 0, ""
+	b.multilineComment = false
+	b.ready = false
+	b.Prefix = findPrefix(b.Buf, prefixCount, b.allowCComments, b.allowHashComments, b.allowMultilineComments)
+}
+
 // Next reads the next statement from the rune source, returning when either
 // the statement has been terminated, or a meta command has been read from the
 // rune source. After a call to Next, the collected statement is available in
@@ -308,6 +337,52 @@ parse:
 	return cmd, params, nil
 }
 
+// CopyData returns a reader for an inline data block following the current
+// statement (ie, the rows following a COPY ... FROM STDIN statement).
+//
+// The reader reads lines directly from the rune source until a line
+// consisting of only \. or the end of the rune source is encountered. Any
+// unprocessed runes remaining on the current line are discarded.
+func (b *Stmt) CopyData() io.Reader {
+	b.r, b.rlen = nil, 0
+	return &copyData{f: b.f}
+}
+
+// copyData reads an inline data block from a rune source.
+type copyData struct {
+	f    func() ([]rune, error)
+	buf  []byte
+	done bool
+}
+
+// Read satisfies the [io.Reader] interface.
+func (d *copyData) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		r, err := d.f()
+		switch {
+		case err == io.EOF:
+			d.done = true
+			if len(r) == 0 {
+				continue
+			}
+		case err != nil:
+			return 0, err
+		}
+		s := strings.TrimSuffix(strings.TrimSuffix(string(r), "\n"), "\r")
+		if s == `\.` {
+			d.done = true
+			continue
+		}
+		d.buf = append([]byte(s), '\n')
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
 // Append appends r to b.Buf separated by sep when b.Buf is not already empty.
 //
 // Dynamically grows b.Buf as necessary to accommodate r and the separator.
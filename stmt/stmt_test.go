@@ -315,6 +315,98 @@ func TestEmptyVariablesRawString(t *testing.T) {
 	}
 }
 
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		n     int
+		exp   string
+		state string
+	}{
+		{"select 1;", 0, "", "="},
+		{"select\n1;", 6, "select", "-"},
+		{"select\n'1", 6, "select", "-"},
+		{"select\n/* 1", 6, "select", "-"},
+		{"select 1", 20, "select 1", "-"},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			lines := strings.Split(test.s, "\n")
+			b := New(func() ([]rune, error) {
+				if len(lines) == 0 {
+					return nil, io.EOF
+				}
+				s := lines[0]
+				lines = lines[1:]
+				return []rune(s), nil
+			}, WithAllowMultilineComments(true))
+			for range strings.Count(test.s, "\n") + 1 {
+				if _, _, err := b.Next(func(string, bool) (string, bool, error) { return "", false, nil }); err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+			}
+			b.Truncate(test.n)
+			if s := b.String(); s != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, s)
+			}
+			if b.Ready() {
+				t.Errorf("expected truncated statement to not be ready")
+			}
+			if state := b.State(); state != test.state {
+				t.Errorf("expected state %q, got: %q", test.state, state)
+			}
+		})
+	}
+}
+
+func TestCopyData(t *testing.T) {
+	tests := []struct {
+		s    string
+		data string
+		next string
+	}{
+		{"copy t from stdin;\n1\ta\n2\tb\n\\.\nselect 1;", "1\ta\n2\tb\n", "select 1;"},
+		{"copy t from stdin;\r\n1\ta\r\n\\.\r\nselect 1;", "1\ta\n", "select 1;"},
+		{"copy t from stdin;\n\\.\nselect 1;", "", "select 1;"},
+		{"copy t from stdin;\n1\ta", "1\ta\n", ""},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			lines := strings.SplitAfter(test.s, "\n")
+			b := New(func() ([]rune, error) {
+				if len(lines) == 0 {
+					return nil, io.EOF
+				}
+				s := lines[0]
+				lines = lines[1:]
+				return []rune(s), nil
+			})
+			unquote := func(string, bool) (string, bool, error) { return "", false, nil }
+			if _, _, err := b.Next(unquote); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if !b.Ready() {
+				t.Fatalf("expected statement to be ready")
+			}
+			buf, err := io.ReadAll(b.CopyData())
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if s := string(buf); s != test.data {
+				t.Errorf("expected data %q, got: %q", test.data, s)
+			}
+			b.Reset(nil)
+			for !b.Ready() {
+				if _, _, err := b.Next(unquote); err == io.EOF {
+					break
+				}
+			}
+			if s := strings.TrimSpace(b.String()); s != test.next {
+				t.Errorf("expected next statement %q, got: %q", test.next, s)
+			}
+		})
+	}
+}
+
 func TestVarSubstitute(t *testing.T) {
 	a512 := sl(512, 'a')
 	tests := []struct {
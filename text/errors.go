@@ -67,6 +67,10 @@ var (
 	ErrInvalidFormatOption = errors.New(`invalid format option`)
 	// ErrInvalidWatchDuration is the invalid watch duration error.
 	ErrInvalidWatchDuration = errors.New(`invalid watch duration`)
+	// ErrWatchDiffWithSeries is the watch diff with file or chart error.
+	ErrWatchDiffWithSeries = errors.New(`\watch: d can not be used with file or chart`)
+	// ErrWatchSeriesNotQuery is the watch file or chart without a query error.
+	ErrWatchSeriesNotQuery = errors.New(`\watch: file and chart require a query returning rows`)
 	// ErrUnableToNormalizeURL is the unable to normalize URL error.
 	ErrUnableToNormalizeURL = errors.New(`unable to normalize URL`)
 	// ErrInvalidIsolationLevel is the invalid isolation level error.
@@ -85,4 +89,24 @@ var (
 	ErrIfEscaped = errors.New(`\if escaped`)
 	// ErrEndIfNoMatchingIf is the endif no matching if error.
 	ErrEndIfNoMatchingIf = errors.New(`\endif: no matching \if`)
+	// ErrElifNoMatchingIf is the elif no matching if error.
+	ErrElifNoMatchingIf = errors.New(`\elif: no matching \if`)
+	// ErrElseNoMatchingIf is the else no matching if error.
+	ErrElseNoMatchingIf = errors.New(`\else: no matching \if`)
+	// ErrElifAfterElse is the elif after else error.
+	ErrElifAfterElse = errors.New(`\elif: cannot occur after \else`)
+	// ErrElseAfterElse is the else after else error.
+	ErrElseAfterElse = errors.New(`\else: cannot occur after \else`)
+	// ErrUnterminatedIf is the unterminated if error.
+	ErrUnterminatedIf = errors.New(`reached EOF without finding closing \endif(s)`)
+	// ErrUnexpectedEndOfExpression is the unexpected end of expression error.
+	ErrUnexpectedEndOfExpression = errors.New(`unexpected end of expression`)
+	// ErrCopyFromQuery is the copy from query error.
+	ErrCopyFromQuery = errors.New(`cannot copy from a query`)
+	// ErrCopyInvalidDelimiter is the copy invalid delimiter error.
+	ErrCopyInvalidDelimiter = errors.New(`copy delimiter must be a single character`)
+	// ErrCopyInvalidQuote is the copy invalid quote error.
+	ErrCopyInvalidQuote = errors.New(`copy quote must be a single character`)
+	// ErrQueryBufferEmpty is the query buffer is empty error.
+	ErrQueryBufferEmpty = errors.New(`query buffer is empty`)
 )
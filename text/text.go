@@ -27,7 +27,10 @@ var (
 	ExitPrefix               = `exit`
 	WelcomeDesc              = `Type "` + HelpPrefix + `" for help.`
 	QueryBufferEmpty         = `Query buffer is empty.`
+	QueryHasNoColumns        = `The command has no result, or the result has no columns.`
+	DescribeNotSupported     = `cannot describe the result of %s statements without executing them`
 	QueryBufferReset         = `Query buffer reset (cleared).`
+	HistoryWritten           = `Wrote history to file "%s".`
 	InvalidCommand           = `Invalid command \%s. Try \? for help.`
 	ExtraArgumentIgnored     = `\%s: extra argument %q ignored`
 	MissingRequiredArg       = `\%s: missing required argument`
@@ -45,6 +48,14 @@ var (
 	ChartParseFailed         = `\chart: invalid argument for %q: %v`
 	CommandIgnoredUseEndIf   = `%s command ignored; use \endif or %s to exit current \if block`
 	UnrecognizedValueForCond = `unrecognized value %q for "\%s expression": Boolean expected`
+	InvalidCondExpr          = `\%s: invalid expression: %v`
+	UnexpectedTokenInExpr    = `unexpected %q`
+	UnknownCopyFormat        = `unknown copy format %q`
+	UnknownCopyOption        = `unknown copy option %q`
+	CopyWrongColumnCount     = `line %d: expected %d columns, got %d`
+	CopyUnterminatedCSVQuote = `line %d: unterminated CSV quoted field`
+	EnterCopyData            = "Enter data to be copied followed by a newline.\nEnd with a backslash and a period on a line by itself, or an EOF signal."
+	CopyDataPrompt           = `>> `
 	// PasswordChangeSucceeded = `\password succeeded for %q`
 	HelpDesc          string
 	HelpDescShort     = `Use \? for help or press control-C to clear the input buffer.`
@@ -92,10 +103,33 @@ var (
 	}
 	TimingSet                 = `Timing is %s.`
 	TimingDesc                = `Time: %0.3f ms`
+	TimingVerboseDesc         = `First row: %s ms, fetch: %s ms, format: %s ms, rows: %d, bytes: %d`
 	InvalidValue              = `invalid -%s value %q: %s`
 	NotSupportedByDriver      = `%s not supported by %s driver`
 	RelationNotFound          = `Did not find any relation named "%s".`
+	ExtensionNotFound         = `Did not find any extension named "%s".`
+	ColumnStatsNotFound       = `Did not find any column stats for "%s"; analyze the table, or use profile=on to profile its rows.`
+	ColumnNotFound            = `column "%s" does not exist in the query result`
+	WatchColumnsMismatch      = `result columns (%s) do not match the columns (%s) of %q`
+	PreparedStatementExists   = `prepared statement "%s" already exists`
+	PreparedStatementNotFound = `prepared statement "%s" does not exist`
+	NoPreparedStatements      = `No prepared statements.`
+	UnknownBindType           = `unknown bind type %q`
+	InvalidBindValue          = `invalid bind value %q for type %s`
+	UndefinedBindVariable     = `undefined bind variable %q`
+	NoPreviousError           = `There is no previous error.`
+	DefinitionNotFound        = `%s "%s" does not exist`
+	DefinitionAmbiguous       = `more than one %s named "%s"`
+	FunctionTemplate          = "CREATE FUNCTION  ()\nRETURNS \nAS\nBEGIN\n\nEND"
+	ViewTemplate              = "CREATE VIEW  AS\nSELECT\n  -- something...\n"
 	InvalidOption             = `invalid option %q`
+	UnknownDialect            = `unknown dialect %q`
+	NoSchemaDifferences       = `No schema differences found.`
+	UnknownDiagramFormat      = `unknown diagram format %q`
+	DataDiffKeyNotFound       = `key column %q not found in %s rows`
+	DataDiffDuplicateKey      = `duplicate key in %s rows: %s`
+	DataDiffNotSorted         = `%s rows not sorted by key at: %s (keys must sort the same on both connections; check the collation and NULL ordering)`
+	DataDiffSummary           = `Compared %d source and %d destination rows: %d missing, %d extra, %d changed, %d unchanged.`
 	NotificationReceived      = `Asynchronous notification %q %sreceived from server process with PID %d.`
 	NotificationPayload       = `with payload %q `
 	UnknownShortAlias         = `(unk)`